@@ -0,0 +1,117 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/rand"
+)
+
+// ErrNoAPIKey indicates that api key does not exist.
+var ErrNoAPIKey = apperr.NewClass(apperr.KindNotFound, "api_key_not_found", "api key does not exist")
+
+const (
+	// KeyPrefix is prepended to every generated api key, so it can be told apart from session tokens.
+	KeyPrefix = "kn_"
+
+	// secretLength is the length of random part of api key.
+	secretLength = 40
+	// displayLength is the number of leading key characters stored in plain text for identification.
+	displayLength = len(KeyPrefix) + 8
+)
+
+// Scope defines what an api key is allowed to do.
+type Scope string
+
+const (
+	// ScopeRead allows only reading data.
+	ScopeRead Scope = "read"
+	// ScopeWrite allows reading and modifying data.
+	ScopeWrite Scope = "write"
+)
+
+// IsValid checks if the value of scope is known.
+func (scope Scope) IsValid() bool {
+	switch scope {
+	case ScopeRead, ScopeWrite:
+		return true
+	}
+	return false
+}
+
+// Allows checks if scope grants access required by an action.
+func (scope Scope) Allows(required Scope) bool {
+	return scope == ScopeWrite || scope == required
+}
+
+// DB exposes access to api keys db.
+//
+// architecture: DB
+type DB interface {
+	// WithTx runs fn in a transaction, repository calls made with the context passed to fn take part in it.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// Create inserts an api key in the database.
+	Create(ctx context.Context, key *APIKey) error
+	// GetByHash returns api key by hash of its value.
+	GetByHash(ctx context.Context, hash []byte) (*APIKey, error)
+	// ListByUser returns all api keys of the user.
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	// Revoke marks user's api key as revoked.
+	Revoke(ctx context.Context, userID, id uuid.UUID, revokedAt time.Time) error
+	// UpdateLastUsed updates time when api key was used last time.
+	UpdateLastUsed(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error
+}
+
+// APIKey describes personal api key entity. Only hash of the key is stored.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"userID"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       []byte     `json:"-"`
+	Scope      Scope      `json:"scope"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// IsRevoked checks if api key was revoked.
+func (key *APIKey) IsRevoked() bool {
+	return key.RevokedAt != nil
+}
+
+// NewAPIKey generates new api key and returns it along with its plain text value.
+func NewAPIKey(userID uuid.UUID, name string, scope Scope) (*APIKey, string, error) {
+	secret, err := rand.RandStr(secretLength, rand.TypeAlphaNum)
+	if err != nil {
+		return nil, "", ErrAPIKeys.Wrap(err)
+	}
+
+	value := KeyPrefix + secret
+
+	return &APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    value[:displayLength],
+		Hash:      Hash(value),
+		Scope:     scope,
+		CreatedAt: time.Now().UTC(),
+	}, value, nil
+}
+
+// Hash returns hash of api key value to store and look it up by.
+func Hash(value string) []byte {
+	hash := sha256.Sum256([]byte(value))
+	return hash[:]
+}
+
+// IsAPIKey checks if value looks like an api key rather than a session token.
+func IsAPIKey(value string) bool {
+	return strings.HasPrefix(value, KeyPrefix)
+}
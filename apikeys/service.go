@@ -0,0 +1,95 @@
+package apikeys
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/audit"
+	"kitchen_nerd/pkg/apperr"
+)
+
+var (
+	// ErrAPIKeys indicates that there was an error in the service.
+	ErrAPIKeys = errs.Class("api keys service error")
+
+	// ErrInvalidAPIKey indicates that api key parameters are invalid.
+	ErrInvalidAPIKey = apperr.NewClass(apperr.KindValidation, "invalid_api_key", "api key is invalid")
+)
+
+// Service is handling api keys related logic.
+//
+// architecture: Service
+type Service struct {
+	apiKeys DB
+	audit   *audit.Service
+}
+
+// NewService is a constructor for api keys service.
+func NewService(apiKeys DB, audit *audit.Service) *Service {
+	return &Service{apiKeys: apiKeys, audit: audit}
+}
+
+// Create creates new api key for the user and returns its plain text value, which can't be restored later.
+func (service *Service) Create(ctx context.Context, userID uuid.UUID, name string, scope Scope) (*APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrInvalidAPIKey.New("name is empty")
+	}
+	if !scope.IsValid() {
+		return nil, "", ErrInvalidAPIKey.New("unknown scope %q", scope)
+	}
+
+	key, value, err := NewAPIKey(userID, name, scope)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = service.apiKeys.Create(ctx, key); err != nil {
+		return nil, "", ErrAPIKeys.Wrap(err)
+	}
+
+	service.audit.Record(ctx, audit.ActionAPIKeyCreate, audit.TargetAPIKey, key.ID.String(), nil, key)
+
+	return key, value, nil
+}
+
+// List returns all api keys of the user.
+func (service *Service) List(ctx context.Context, userID uuid.UUID) ([]APIKey, error) {
+	keys, err := service.apiKeys.ListByUser(ctx, userID)
+	return keys, ErrAPIKeys.Wrap(err)
+}
+
+// Revoke revokes user's api key.
+func (service *Service) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	if err := service.apiKeys.Revoke(ctx, userID, id, time.Now().UTC()); err != nil {
+		return ErrAPIKeys.Wrap(err)
+	}
+
+	service.audit.Record(ctx, audit.ActionAPIKeyRevoke, audit.TargetAPIKey, id.String(), nil, nil)
+
+	return nil
+}
+
+// Authenticate returns active api key by its plain text value and records its usage.
+func (service *Service) Authenticate(ctx context.Context, value string) (*APIKey, error) {
+	key, err := service.apiKeys.GetByHash(ctx, Hash(value))
+	if err != nil {
+		return nil, ErrAPIKeys.Wrap(err)
+	}
+
+	if key.IsRevoked() {
+		return nil, ErrAPIKeys.Wrap(ErrNoAPIKey.New("api key is revoked"))
+	}
+
+	now := time.Now().UTC()
+	if err = service.apiKeys.UpdateLastUsed(ctx, key.ID, now); err != nil {
+		return nil, ErrAPIKeys.Wrap(err)
+	}
+	key.LastUsedAt = &now
+
+	return key, nil
+}
@@ -0,0 +1,99 @@
+package apikeys_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/database/memory"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/users"
+)
+
+// newTestService returns api keys service backed by in-memory database along with id of a user who owns keys.
+func newTestService(t *testing.T) (*apikeys.Service, uuid.UUID) {
+	db := memory.New()
+
+	user := users.User{ID: uuid.New(), Email: "cook@example.com", Name: "Cook", Status: users.StatusUser, PasswordHash: []byte("hash")}
+	if err := db.Users().Create(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+
+	return apikeys.NewService(db.APIKeys(), audit.NewService(logger.NewNop(), db.Audit())), user.ID
+}
+
+func TestCreateAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	service, userID := newTestService(t)
+
+	for _, invalid := range []struct {
+		name  string
+		scope apikeys.Scope
+	}{
+		{" ", apikeys.ScopeRead},
+		{"ci", "admin"},
+	} {
+		_, _, err := service.Create(ctx, userID, invalid.name, invalid.scope)
+		if !apikeys.ErrInvalidAPIKey.Has(err) {
+			t.Fatalf("%q %q: expected invalid api key error, got %v", invalid.name, invalid.scope, err)
+		}
+	}
+
+	key, value, err := service.Create(ctx, userID, " ci ", apikeys.ScopeRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !apikeys.IsAPIKey(value) || !strings.HasPrefix(value, key.Prefix) || key.Name != "ci" {
+		t.Fatalf("unexpected api key %+v with value %q", key, value)
+	}
+
+	// only hash of the value is stored, it's enough to find the key.
+	authenticated, err := service.Authenticate(ctx, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authenticated.ID != key.ID || authenticated.Scope != apikeys.ScopeRead || authenticated.LastUsedAt == nil {
+		t.Fatalf("unexpected authenticated api key %+v", authenticated)
+	}
+
+	_, err = service.Authenticate(ctx, value+"x")
+	if !apikeys.ErrNoAPIKey.Has(err) {
+		t.Fatalf("expected no api key error for unknown value, got %v", err)
+	}
+
+	list, err := service.List(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].LastUsedAt == nil {
+		t.Fatalf("unexpected api keys %+v", list)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	service, userID := newTestService(t)
+
+	key, value, err := service.Create(ctx, userID, "ci", apikeys.ScopeWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = service.Revoke(ctx, uuid.New(), key.ID); !apikeys.ErrNoAPIKey.Has(err) {
+		t.Fatalf("expected other user not to revoke the key, got %v", err)
+	}
+	if _, err = service.Authenticate(ctx, value); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = service.Revoke(ctx, userID, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = service.Authenticate(ctx, value); !apikeys.ErrNoAPIKey.Has(err) {
+		t.Fatalf("expected revoked key to be rejected, got %v", err)
+	}
+}
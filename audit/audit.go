@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/pkg/util"
+)
+
+// Action defines the kind of audited action.
+type Action string
+
+const (
+	// ActionUserRegister is recorded when a new user registers.
+	ActionUserRegister Action = "user.register"
+	// ActionUserLogin is recorded on successful login.
+	ActionUserLogin Action = "user.login"
+	// ActionUserLoginFailed is recorded when login attempt fails.
+	ActionUserLoginFailed Action = "user.login_failed"
+	// ActionUserLogout is recorded when user ends a session.
+	ActionUserLogout Action = "user.logout"
+	// ActionUserPasswordReset is recorded when user's password is reset.
+	ActionUserPasswordReset Action = "user.password_reset"
+	// ActionUserStatusChange is recorded when user is promoted, demoted or disabled.
+	ActionUserStatusChange Action = "user.status_change"
+	// ActionAPIKeyCreate is recorded when personal api key is created.
+	ActionAPIKeyCreate Action = "api_key.create"
+	// ActionAPIKeyRevoke is recorded when personal api key is revoked.
+	ActionAPIKeyRevoke Action = "api_key.revoke"
+	// ActionRecipeCreate is recorded when recipe is created.
+	ActionRecipeCreate Action = "recipe.create"
+	// ActionRecipeUpdate is recorded when recipe is updated.
+	ActionRecipeUpdate Action = "recipe.update"
+	// ActionRecipeDelete is recorded when recipe is deleted.
+	ActionRecipeDelete Action = "recipe.delete"
+)
+
+// Target types of audited actions.
+const (
+	TargetUser   = "user"
+	TargetAPIKey = "api_key"
+	TargetRecipe = "recipe"
+)
+
+// DB exposes access to audit log db. Audit log is append-only, so events can't be changed or removed.
+//
+// architecture: DB
+type DB interface {
+	// Create appends an event to the audit log.
+	Create(ctx context.Context, event Event) error
+	// List returns a page of events matching the filter, newest first.
+	List(ctx context.Context, filter Filter, pagination *util.PaginationReq) ([]Event, error)
+	// Count returns number of events matching the filter.
+	Count(ctx context.Context, filter Filter) (uint64, error)
+	// Iterate calls fn for every event matching the filter, oldest first.
+	Iterate(ctx context.Context, filter Filter, fn func(Event) error) error
+}
+
+// Event describes a single audit log entry.
+type Event struct {
+	ID         uuid.UUID       `json:"id"`
+	ActorID    uuid.UUID       `json:"actorID"`
+	Action     Action          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   string          `json:"targetID"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"userAgent"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// Filter defines which events to select, empty fields are not filtered by.
+type Filter struct {
+	ActorID    uuid.UUID `json:"actorID"`
+	Action     Action    `json:"action"`
+	TargetType string    `json:"targetType"`
+	TargetID   string    `json:"targetID"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+}
+
+// ctxKey is a type of audit context keys.
+type ctxKey int
+
+// requestInfoKey is a context key of RequestInfo.
+const requestInfoKey ctxKey = iota
+
+// RequestInfo describes who performs an action and from where.
+type RequestInfo struct {
+	ActorID   uuid.UUID
+	IP        string
+	UserAgent string
+}
+
+// WithRequestInfo returns context carrying request info.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+// WithActor returns context carrying request info with actor set.
+func WithActor(ctx context.Context, actorID uuid.UUID) context.Context {
+	info := GetRequestInfo(ctx)
+	info.ActorID = actorID
+	return WithRequestInfo(ctx, info)
+}
+
+// GetRequestInfo returns request info from the context.
+func GetRequestInfo(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey).(RequestInfo)
+	return info
+}
@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/pkg/util"
+)
+
+// ErrAudit indicates that there was an error in the service.
+var ErrAudit = errs.Class("audit service error")
+
+// Service is handling audit log related logic.
+//
+// architecture: Service
+type Service struct {
+	log   logger.Logger
+	audit DB
+}
+
+// NewService is a constructor for audit service.
+func NewService(log logger.Logger, audit DB) *Service {
+	return &Service{log: log, audit: audit}
+}
+
+// Record appends an action performed by the actor from the context to the audit log.
+// Before and after are states of the target, which are stored as json, nil values are omitted.
+// Audit log failures are logged and don't interrupt the action itself.
+func (service *Service) Record(ctx context.Context, action Action, targetType, targetID string, before, after interface{}) {
+	info := GetRequestInfo(ctx)
+
+	event := Event{
+		ID:         uuid.New(),
+		ActorID:    info.ActorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         info.IP,
+		UserAgent:  info.UserAgent,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	log := logger.FromContext(ctx, service.log).With(logger.String("action", string(action)))
+
+	var err error
+	if event.Before, err = marshalState(before); err != nil {
+		log.Error("could not encode audit event state", ErrAudit.Wrap(err))
+	}
+	if event.After, err = marshalState(after); err != nil {
+		log.Error("could not encode audit event state", ErrAudit.Wrap(err))
+	}
+
+	if err = service.audit.Create(ctx, event); err != nil {
+		log.Error("could not record audit event", ErrAudit.Wrap(err))
+	}
+}
+
+// List returns a page of events matching the filter and total number of such events.
+func (service *Service) List(ctx context.Context, filter Filter, pagination *util.PaginationReq) ([]Event, uint64, error) {
+	count, err := service.audit.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, ErrAudit.Wrap(err)
+	}
+
+	if count == 0 {
+		return make([]Event, 0), 0, nil
+	}
+
+	events, err := service.audit.List(ctx, filter, pagination)
+	if err != nil {
+		return nil, 0, ErrAudit.Wrap(err)
+	}
+
+	return events, count, nil
+}
+
+// Export writes all events matching the filter to w as json lines, oldest first.
+func (service *Service) Export(ctx context.Context, filter Filter, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	err := service.audit.Iterate(ctx, filter, func(event Event) error {
+		return encoder.Encode(event)
+	})
+
+	return ErrAudit.Wrap(err)
+}
+
+// marshalState encodes target state as json.
+func marshalState(state interface{}) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(state)
+}
@@ -0,0 +1,98 @@
+package audit_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/audit"
+	"kitchen_nerd/database/memory"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/pkg/util"
+)
+
+func TestRecordAndList(t *testing.T) {
+	ctx := context.Background()
+	service := audit.NewService(logger.NewNop(), memory.New().Audit())
+	actorID := uuid.New()
+
+	events, count, err := service.List(ctx, audit.Filter{}, util.NewPaginationReq(10, 1))
+	if err != nil || count != 0 || events == nil || len(events) != 0 {
+		t.Fatalf("expected empty non-nil list, got %v %d %v", events, count, err)
+	}
+
+	ctx = audit.WithRequestInfo(ctx, audit.RequestInfo{IP: "192.0.2.1", UserAgent: "test"})
+	service.Record(ctx, audit.ActionUserLoginFailed, audit.TargetUser, "cook@example.com", nil, nil)
+	service.Record(audit.WithActor(ctx, actorID), audit.ActionRecipeUpdate, audit.TargetRecipe, "1",
+		map[string]string{"title": "Borsch"}, map[string]string{"title": "Green borsch"})
+
+	events, count, err = service.List(ctx, audit.Filter{ActorID: actorID}, util.NewPaginationReq(10, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || len(events) != 1 {
+		t.Fatalf("expected single event of the actor, got %d %+v", count, events)
+	}
+
+	event := events[0]
+	if event.Action != audit.ActionRecipeUpdate || event.TargetID != "1" || event.IP != "192.0.2.1" ||
+		event.UserAgent != "test" || event.CreatedAt.IsZero() || event.ID == uuid.Nil ||
+		string(event.Before) != `{"title":"Borsch"}` || string(event.After) != `{"title":"Green borsch"}` {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	events, _, err = service.List(ctx, audit.Filter{Action: audit.ActionUserLoginFailed}, util.NewPaginationReq(10, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ActorID != uuid.Nil || events[0].Before != nil || events[0].After != nil {
+		t.Fatalf("expected anonymous event without states, got %+v", events)
+	}
+}
+
+func TestRecordUnencodableState(t *testing.T) {
+	ctx := context.Background()
+	service := audit.NewService(logger.NewNop(), memory.New().Audit())
+
+	// event is recorded even if state can't be encoded, action itself must not fail because of audit log.
+	service.Record(ctx, audit.ActionRecipeCreate, audit.TargetRecipe, "1", nil, func() {})
+
+	events, count, err := service.List(ctx, audit.Filter{}, util.NewPaginationReq(10, 1))
+	if err != nil || count != 1 || events[0].After != nil {
+		t.Fatalf("expected event without state, got %+v %d %v", events, count, err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	service := audit.NewService(logger.NewNop(), memory.New().Audit())
+
+	for _, targetID := range []string{"1", "2", "3"} {
+		service.Record(ctx, audit.ActionRecipeCreate, audit.TargetRecipe, targetID, nil, nil)
+	}
+	service.Record(ctx, audit.ActionUserLogout, audit.TargetUser, uuid.NewString(), nil, nil)
+
+	var b bytes.Buffer
+	if err := service.Export(ctx, audit.Filter{TargetType: audit.TargetRecipe}, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	var targetIDs []string
+	scanner := bufio.NewScanner(&b)
+	for scanner.Scan() {
+		var event audit.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("expected json line, got %q: %v", scanner.Text(), err)
+		}
+		targetIDs = append(targetIDs, event.TargetID)
+	}
+
+	// events are exported oldest first.
+	if len(targetIDs) != 3 || targetIDs[0] != "1" || targetIDs[1] != "2" || targetIDs[2] != "3" {
+		t.Fatalf("expected recipe events in order of creation, got %v", targetIDs)
+	}
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"kitchen_nerd"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/console/consoleserver/controllers/auth"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/recipes"
+	"kitchen_nerd/seed"
+	"kitchen_nerd/tokens"
+	"kitchen_nerd/users"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// administrative commands, they print results as json for scripting.
+var (
+	usersCmd = &cobra.Command{
+		Use:   "users",
+		Short: "users administration commands",
+	}
+
+	usersCreateCmd = &cobra.Command{
+		Use:   "create",
+		Short: "creates a user, e.g. the first admin with --admin flag",
+		Args:  cobra.NoArgs,
+		RunE:  cmdUsersCreate,
+	}
+
+	usersPromoteCmd = &cobra.Command{
+		Use:   "promote <email>",
+		Short: "makes the user an admin",
+		Args:  cobra.ExactArgs(1),
+		RunE:  cmdUsersSetStatus(users.StatusAdmin),
+	}
+
+	usersDemoteCmd = &cobra.Command{
+		Use:   "demote <email>",
+		Short: "makes the user a regular one, also re-enables disabled users",
+		Args:  cobra.ExactArgs(1),
+		RunE:  cmdUsersSetStatus(users.StatusUser),
+	}
+
+	usersDisableCmd = &cobra.Command{
+		Use:   "disable <email>",
+		Short: "denies the user to log in and ends user's sessions",
+		Args:  cobra.ExactArgs(1),
+		RunE:  cmdUsersSetStatus(users.StatusDisabled),
+	}
+
+	usersResetPasswordCmd = &cobra.Command{
+		Use:   "reset-password <email>",
+		Short: "sets new password of the user and ends user's sessions",
+		Args:  cobra.ExactArgs(1),
+		RunE:  cmdUsersResetPassword,
+	}
+
+	tokensCmd = &cobra.Command{
+		Use:   "tokens",
+		Short: "access tokens administration commands",
+	}
+
+	tokensPurgeCmd = &cobra.Command{
+		Use:   "purge",
+		Short: "removes expired tokens and revocations",
+		Args:  cobra.NoArgs,
+		RunE:  cmdTokensPurge,
+	}
+
+	tokensRevokeCmd = &cobra.Command{
+		Use:   "revoke",
+		Short: "revokes all tokens of the user set by --user or a single one set by --token",
+		Args:  cobra.NoArgs,
+		RunE:  cmdTokensRevoke,
+	}
+
+	recipesCmd = &cobra.Command{
+		Use:   "recipes",
+		Short: "recipes administration commands",
+	}
+
+	recipesListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "lists recipes without photos",
+		Args:  cobra.NoArgs,
+		RunE:  cmdRecipesList,
+	}
+
+	recipesDeleteCmd = &cobra.Command{
+		Use:   "delete <id>",
+		Short: "deletes the recipe with its ingredients",
+		Args:  cobra.ExactArgs(1),
+		RunE:  cmdRecipesDelete,
+	}
+
+	seedCmd = &cobra.Command{
+		Use:   "seed",
+		Short: "loads demo recipes with photos of the static directory, existing ones are skipped",
+		Args:  cobra.NoArgs,
+		RunE:  cmdSeed,
+	}
+)
+
+// passwordFlags defines how password is passed to commands.
+type passwordFlags struct {
+	password      string
+	passwordStdin bool
+}
+
+var usersCreateFlags struct {
+	email string
+	name  string
+	admin bool
+	passwordFlags
+}
+
+var usersResetPasswordFlags passwordFlags
+
+var tokensRevokeFlags struct {
+	user  string
+	token string
+}
+
+// recipesListQuery has the same format as query of recipes list api.
+var recipesListQuery string
+
+// seedReset recreates seeded recipes discarding their changes.
+var seedReset bool
+
+func init() {
+	rootCmd.AddCommand(usersCmd, tokensCmd, recipesCmd, seedCmd)
+
+	usersCmd.AddCommand(usersCreateCmd, usersPromoteCmd, usersDemoteCmd, usersDisableCmd, usersResetPasswordCmd)
+	usersCreateCmd.Flags().StringVar(&usersCreateFlags.email, "email", "", "email of the user")
+	usersCreateCmd.Flags().StringVar(&usersCreateFlags.name, "name", "", "name of the user")
+	usersCreateCmd.Flags().BoolVar(&usersCreateFlags.admin, "admin", false, "create the user as an admin")
+	bindPasswordFlags(usersCreateCmd, &usersCreateFlags.passwordFlags)
+	bindPasswordFlags(usersResetPasswordCmd, &usersResetPasswordFlags)
+
+	tokensCmd.AddCommand(tokensPurgeCmd, tokensRevokeCmd)
+	tokensRevokeCmd.Flags().StringVar(&tokensRevokeFlags.user, "user", "", "email of the user whose tokens are revoked")
+	tokensRevokeCmd.Flags().StringVar(&tokensRevokeFlags.token, "token", "", "token to revoke")
+
+	recipesCmd.AddCommand(recipesListCmd, recipesDeleteCmd)
+	recipesListCmd.Flags().StringVar(&recipesListQuery, "query", "", `pagination, sorting and filters, e.g. "size=20&page=2&sort=title&filter[title][contains]=soup"`)
+
+	seedCmd.Flags().BoolVar(&seedReset, "reset", false, "delete seeded recipes and load them again, other recipes are kept")
+}
+
+// bindPasswordFlags registers flags of the password.
+func bindPasswordFlags(cmd *cobra.Command, flags *passwordFlags) {
+	cmd.Flags().StringVar(&flags.password, "password", "", "password, prefer --password-stdin to keep it out of shell history")
+	cmd.Flags().BoolVar(&flags.passwordStdin, "password-stdin", false, "read password from the first line of stdin")
+}
+
+// read returns password set by flags.
+func (flags passwordFlags) read(stdin io.Reader) (string, error) {
+	if !flags.passwordStdin {
+		if flags.password == "" {
+			return "", Error.New("password is required, set --password or --password-stdin")
+		}
+		return flags.password, nil
+	}
+
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", Error.Wrap(err)
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", Error.New("password read from stdin is empty")
+	}
+
+	return password, nil
+}
+
+// services are used by administrative commands.
+type services struct {
+	users   *users.Service
+	tokens  *tokens.Service
+	recipes *recipes.Service
+	seed    *seed.Service
+}
+
+// withServices connects to the configured database and runs fn with services on top of it.
+// Messages of services are discarded, since results are written to stdout.
+func withServices(cmd *cobra.Command, fn func(ctx context.Context, services services) error) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	tokensConfig, err := cfg.TokensConfig()
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	usersConfig, err := cfg.UsersConfig()
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	return withDatabase(cmd, func(db kitchen_nerd.DB) error {
+		log := logger.NewNop()
+		auditService := audit.NewService(log, db.Audit())
+		tokensService := tokens.NewService(log, tokensConfig, db.Tokens())
+		recipesService := recipes.NewService(db.Recipes(), auditService)
+
+		return fn(cmd.Context(), services{
+			users:   users.NewService(log, usersConfig, db.Users(), tokensService, auditService),
+			tokens:  tokensService,
+			recipes: recipesService,
+			seed:    seed.NewService(recipesService, cfg.StaticDir, seed.Recipes),
+		})
+	})
+}
+
+// printJSON writes value to stdout as indented json.
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return Error.Wrap(encoder.Encode(value))
+}
+
+func cmdUsersCreate(cmd *cobra.Command, _ []string) error {
+	password, err := usersCreateFlags.read(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	// users created by admins follow the same rules as registered ones.
+	request := auth.RegistrationRequest{
+		Email:            usersCreateFlags.email,
+		UserName:         usersCreateFlags.name,
+		Password:         password,
+		RepeatedPassword: password,
+	}
+	if err := request.Validate(); err != nil {
+		return Error.Wrap(err)
+	}
+
+	status := users.StatusUser
+	if usersCreateFlags.admin {
+		status = users.StatusAdmin
+	}
+
+	return withServices(cmd, func(ctx context.Context, services services) error {
+		account, err := services.users.CreateAccount(ctx, request.UserName, request.Email, request.Password, status)
+		if err != nil {
+			return err
+		}
+
+		return printJSON(account)
+	})
+}
+
+// cmdUsersSetStatus returns command which changes status of the user.
+func cmdUsersSetStatus(status users.Status) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return withServices(cmd, func(ctx context.Context, services services) error {
+			account, err := services.users.SetStatus(ctx, args[0], status)
+			if err != nil {
+				return err
+			}
+
+			return printJSON(account)
+		})
+	}
+}
+
+func cmdUsersResetPassword(cmd *cobra.Command, args []string) error {
+	password, err := usersResetPasswordFlags.read(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	return withServices(cmd, func(ctx context.Context, services services) error {
+		if err := services.users.ResetPassword(ctx, args[0], password); err != nil {
+			return err
+		}
+
+		account, err := services.users.GetAccount(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		return printJSON(account)
+	})
+}
+
+func cmdTokensPurge(cmd *cobra.Command, _ []string) error {
+	return withServices(cmd, func(ctx context.Context, services services) error {
+		stats, err := services.tokens.Purge(ctx)
+		if err != nil {
+			return err
+		}
+
+		return printJSON(stats)
+	})
+}
+
+func cmdTokensRevoke(cmd *cobra.Command, _ []string) error {
+	if (tokensRevokeFlags.user == "") == (tokensRevokeFlags.token == "") {
+		return Error.New("either --user or --token must be set")
+	}
+
+	return withServices(cmd, func(ctx context.Context, services services) error {
+		if tokensRevokeFlags.token != "" {
+			if err := services.tokens.Revoke(ctx, tokensRevokeFlags.token); err != nil {
+				return err
+			}
+
+			return printJSON(struct {
+				Revoked bool `json:"revoked"`
+			}{Revoked: true})
+		}
+
+		account, err := services.users.GetAccount(ctx, tokensRevokeFlags.user)
+		if err != nil {
+			return err
+		}
+
+		if err = services.tokens.RevokeUser(ctx, account.ID); err != nil {
+			return err
+		}
+
+		return printJSON(struct {
+			Revoked bool      `json:"revoked"`
+			UserID  uuid.UUID `json:"userId"`
+		}{Revoked: true, UserID: account.ID})
+	})
+}
+
+// recipeSummary is a recipe listed by cli, photos are left out to keep output readable.
+type recipeSummary struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func cmdRecipesList(cmd *cobra.Command, _ []string) error {
+	values, err := url.ParseQuery(recipesListQuery)
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	pagination := new(util.PaginationReq)
+	if err = pagination.ProcessQueryParams(values); err != nil {
+		return Error.Wrap(err)
+	}
+
+	query, err := util.ParseListQuery(values, recipes.ListFields)
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	return withServices(cmd, func(ctx context.Context, services services) error {
+		list, page, err := services.recipes.List(ctx, pagination, query)
+		if err != nil {
+			return err
+		}
+
+		summaries := make([]recipeSummary, 0, len(list))
+		for _, recipe := range list {
+			summaries = append(summaries, recipeSummary{
+				ID:          recipe.ID,
+				Title:       recipe.Title,
+				Description: recipe.Description,
+				CreatedAt:   recipe.CreatedAt,
+			})
+		}
+
+		return printJSON(struct {
+			Recipes    []recipeSummary          `json:"recipes"`
+			Pagination *util.PaginationResponse `json:"pagination"`
+		}{Recipes: summaries, Pagination: page})
+	})
+}
+
+func cmdRecipesDelete(cmd *cobra.Command, args []string) error {
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return Error.New("invalid recipe id %q: %v", args[0], err)
+	}
+
+	return withServices(cmd, func(ctx context.Context, services services) error {
+		if err := services.recipes.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return printJSON(struct {
+			Deleted bool      `json:"deleted"`
+			ID      uuid.UUID `json:"id"`
+		}{Deleted: true, ID: id})
+	})
+}
+
+func cmdSeed(cmd *cobra.Command, _ []string) error {
+	return withServices(cmd, func(ctx context.Context, services services) error {
+		seedFn := services.seed.Seed
+		if seedReset {
+			seedFn = services.seed.Reset
+		}
+
+		result, err := seedFn(ctx)
+		if err != nil {
+			return err
+		}
+
+		return printJSON(result)
+	})
+}
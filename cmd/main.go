@@ -1,14 +1,23 @@
 package main
 
 import (
-	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"kitchen_nerd"
+	"kitchen_nerd/audit"
 	"kitchen_nerd/database"
-	"log"
+	"kitchen_nerd/pkg/config"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/pkg/logger/zaplog"
 	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
-	"github.com/caarlos0/env/v6"
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 	"github.com/zeebo/errs"
 )
@@ -16,18 +25,24 @@ import (
 // Error is a default error type for kitchen_nerd cli.
 var Error = errs.Class("kitchen_nerd cli error")
 
-// Config contains configurable values for kitchen nerd project.
-type Config struct {
-	Database            string `json:"database"`
-	kitchen_nerd.Config `json:"config"`
-}
-
 // commands.
 var (
 	// kitchen_nerd root cmd.
 	rootCmd = &cobra.Command{
 		Use:   "kitchen_nerd",
 		Short: "cli for interacting with kitchen nerd project",
+		Long: `cli for interacting with kitchen nerd project.
+
+Settings are taken from the following sources, each of them overrides the previous ones:
+  1. defaults;
+  2. YAML or JSON config file set by --config flag or CONFIG_FILE env;
+  3. environment variables, including ones from .env file of the working directory,
+     .env file is loaded only when no config file is set, so it doesn't override the config file;
+  4. command line flags.
+
+Names of config file keys match flags, e.g. DATABASE_URL env is "database-url" key and --database-url flag.
+Use "kitchen_nerd config print" to see the effective configuration.`,
+		SilenceUsage: true,
 	}
 
 	runCmd = &cobra.Command{
@@ -36,10 +51,90 @@ var (
 		RunE:        cmdRun,
 		Annotations: map[string]string{"type": "run"},
 	}
+
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "database schema migrations commands",
+	}
+
+	migrateUpCmd = &cobra.Command{
+		Use:   "up",
+		Short: "applies all pending migrations",
+		RunE:  cmdMigrateUp,
+	}
+
+	migrateDownCmd = &cobra.Command{
+		Use:   "down",
+		Short: "rolls back latest applied migrations",
+		RunE:  cmdMigrateDown,
+	}
+
+	migrateStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "shows which migrations are applied",
+		RunE:  cmdMigrateStatus,
+	}
+
+	auditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "audit log commands",
+	}
+
+	auditExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "exports audit log to stdout as json lines",
+		RunE:  cmdAuditExport,
+	}
+
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "configuration commands",
+	}
+
+	configPrintCmd = &cobra.Command{
+		Use:   "print",
+		Short: "prints effective configuration as YAML with secrets redacted",
+		RunE:  cmdConfigPrint,
+	}
 )
 
+// configFile is a path of config file, CONFIG_FILE env is used when it's not set.
+var configFile string
+
+// migrateDownSteps is the number of migrations to roll back.
+var migrateDownSteps int
+
+// auditExportFlags defines filter of exported audit events.
+var auditExportFlags struct {
+	actor      string
+	action     string
+	targetType string
+	targetID   string
+	from       string
+	to         string
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "path to YAML or JSON config file, overrides env CONFIG_FILE")
+	config.BindFlags(rootCmd.PersistentFlags(), new(kitchen_nerd.Config))
+
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd)
+	migrateDownCmd.Flags().IntVar(&migrateDownSteps, "steps", 1, "number of migrations to roll back")
+
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditExportCmd)
+
+	auditExportCmd.Flags().StringVar(&auditExportFlags.actor, "actor", "", "id of user who performed actions")
+	auditExportCmd.Flags().StringVar(&auditExportFlags.action, "action", "", "action, e.g. recipe.delete")
+	auditExportCmd.Flags().StringVar(&auditExportFlags.targetType, "target-type", "", "type of action target, e.g. recipe")
+	auditExportCmd.Flags().StringVar(&auditExportFlags.targetID, "target-id", "", "id of action target")
+	auditExportCmd.Flags().StringVar(&auditExportFlags.from, "from", "", "export events since time in RFC 3339")
+	auditExportCmd.Flags().StringVar(&auditExportFlags.to, "to", "", "export events before time in RFC 3339")
+
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configPrintCmd)
 }
 
 func main() {
@@ -49,37 +144,182 @@ func main() {
 	}
 }
 
-func cmdRun(_ *cobra.Command, _ []string) (err error) {
-	ctx := context.Background()
-	config := new(kitchen_nerd.Config)
-
-	err = env.Parse(config)
+func cmdRun(cmd *cobra.Command, _ []string) (err error) {
+	ctx := cmd.Context()
+	cfg, err := loadConfig(cmd)
 	if err != nil {
-		log.Println("could not parse env to config:", err)
-		return
+		return err
 	}
 
-	db, err := database.New(ctx, config.DatabaseURL)
+	log, err := zaplog.NewLog(cfg.LogLevel)
 	if err != nil {
-		//log.Error("Error starting master database on kitchen_nerd service", Error.Wrap(err))
 		return Error.Wrap(err)
 	}
-	defer db.Close()
 
-	// TODO: remove for production.
-	err = db.CreateSchema(ctx)
+	db, err := database.Open(ctx, cfg.DatabaseURL, databaseConfig(cfg))
 	if err != nil {
-		log.Println("Error creating schema", Error.Wrap(err))
+		return Error.Wrap(err)
+	}
+	defer func() {
+		db.Close()
+		log.Info("database closed")
+	}()
+
+	if cfg.AutoMigrate {
+		if err = db.MigrateUp(ctx); err != nil {
+			log.Error("Error migrating database", Error.Wrap(err))
+			return Error.Wrap(err)
+		}
 	}
 
-	kitchenNerd, err := kitchen_nerd.New(*config, db)
+	kitchenNerd, err := kitchen_nerd.New(log, *cfg, db)
 	if err != nil {
-		log.Println("Error starting kitchen_nerd service", Error.Wrap(err))
+		log.Error("Error starting kitchen_nerd service", Error.Wrap(err))
 		return Error.Wrap(err)
 	}
 
+	// first signal starts graceful shutdown, the second one kills the process as usual.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		log.Info("shutting down", logger.Duration("timeout", cfg.ShutdownTimeout))
+	}()
+
+	log.Info("kitchen_nerd started", logger.String("address", cfg.ServerAddress))
 	runError := kitchenNerd.Run(ctx)
 	closeError := kitchenNerd.Close()
+	log.Info("kitchen_nerd stopped")
 
 	return Error.Wrap(errs.Combine(runError, closeError))
 }
+
+func cmdAuditExport(cmd *cobra.Command, _ []string) (err error) {
+	ctx := cmd.Context()
+
+	filter := audit.Filter{
+		Action:     audit.Action(auditExportFlags.action),
+		TargetType: auditExportFlags.targetType,
+		TargetID:   auditExportFlags.targetID,
+	}
+	if auditExportFlags.actor != "" {
+		if filter.ActorID, err = uuid.Parse(auditExportFlags.actor); err != nil {
+			return Error.Wrap(err)
+		}
+	}
+	if auditExportFlags.from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, auditExportFlags.from); err != nil {
+			return Error.Wrap(err)
+		}
+	}
+	if auditExportFlags.to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, auditExportFlags.to); err != nil {
+			return Error.Wrap(err)
+		}
+	}
+
+	return withDatabase(cmd, func(db kitchen_nerd.DB) error {
+		// events are written to stdout, so messages of the service are discarded.
+		return audit.NewService(logger.NewNop(), db.Audit()).Export(ctx, filter, os.Stdout)
+	})
+}
+
+func cmdMigrateUp(cmd *cobra.Command, _ []string) error {
+	return withDatabase(cmd, func(db kitchen_nerd.DB) error {
+		return db.MigrateUp(cmd.Context())
+	})
+}
+
+func cmdMigrateDown(cmd *cobra.Command, _ []string) error {
+	if migrateDownSteps < 1 {
+		return Error.New("steps must be positive")
+	}
+
+	return withDatabase(cmd, func(db kitchen_nerd.DB) error {
+		return db.MigrateDown(cmd.Context(), migrateDownSteps)
+	})
+}
+
+func cmdMigrateStatus(cmd *cobra.Command, _ []string) error {
+	return withDatabase(cmd, func(db kitchen_nerd.DB) error {
+		statuses, err := db.MigrationStatus(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state, appliedAt := "pending", ""
+			if status.Applied {
+				state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Modified {
+				state = "modified"
+			}
+
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+
+		return w.Flush()
+	})
+}
+
+func cmdConfigPrint(cmd *cobra.Command, _ []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	return Error.Wrap(config.Print(os.Stdout, cfg))
+}
+
+// loadConfig loads configuration from defaults, config file, env and flags of the command and validates it.
+func loadConfig(cmd *cobra.Command) (*kitchen_nerd.Config, error) {
+	path := configFile
+	if !cmd.Flags().Changed("config") {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		// .env is meant for local development, variables of the environment take precedence over it.
+		if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, Error.Wrap(err)
+		}
+	}
+
+	cfg := new(kitchen_nerd.Config)
+	if err := config.Load(cfg, path, cmd.Flags()); err != nil {
+		return nil, Error.Wrap(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, Error.Wrap(err)
+	}
+
+	return cfg, nil
+}
+
+// databaseConfig returns settings of database connections.
+func databaseConfig(cfg *kitchen_nerd.Config) database.Config {
+	return database.Config{
+		MaxConns:        cfg.DatabaseMaxConns,
+		MinConns:        cfg.DatabaseMinConns,
+		MaxConnLifetime: cfg.DatabaseMaxConnLifetime,
+		MaxConnIdleTime: cfg.DatabaseMaxConnIdleTime,
+	}
+}
+
+// withDatabase connects to the database configured for the command and runs fn.
+func withDatabase(cmd *cobra.Command, fn func(db kitchen_nerd.DB) error) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.Open(cmd.Context(), cfg.DatabaseURL, databaseConfig(cfg))
+	if err != nil {
+		return Error.Wrap(err)
+	}
+	defer db.Close()
+
+	return Error.Wrap(fn(db))
+}
@@ -0,0 +1,53 @@
+package consoleserver
+
+import (
+	"kitchen_nerd/console/consoleserver/openapi"
+)
+
+// paginationParams are query params of paged api lists.
+var paginationParams = []openapi.Parameter{
+	{Name: "page", Description: "Page number, starting from 1.", Schema: &openapi.Schema{Type: "integer"}},
+	{Name: "size", Description: "Number of items on a page.", Schema: &openapi.Schema{Type: "integer"}},
+}
+
+// listQueryParams are query params of sorted and filtered api lists.
+var listQueryParams = []openapi.Parameter{
+	{
+		Name:        "sort",
+		Description: "Comma separated fields to sort by, prefixed with - for descending order, e.g. -createdAt,title.",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	{
+		Name:        "filter",
+		Description: "Filters as filter[field][operator]=value, operators are eq, ne, contains, gt, gte, lt and lte.",
+		Schema:      &openapi.Schema{Type: "object", AdditionalProperties: &openapi.Schema{}},
+		Style:       "deepObject",
+	},
+}
+
+// cursorParam switches recipes list to keyset pagination.
+var cursorParam = openapi.Parameter{
+	Name:        "cursor",
+	Description: "Opaque cursor of the next or previous page, can't be combined with sort.",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+// auditFilterParams are query params of audit log filter.
+var auditFilterParams = []openapi.Parameter{
+	{Name: "actor", Description: "Id of the user who made the change.", Schema: &openapi.Schema{Type: "string", Format: "uuid"}},
+	{Name: "action", Description: "Action, e.g. recipe.create.", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "targetType", Description: "Type of changed entity.", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "targetID", Description: "Id of changed entity.", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "from", Description: "Lower bound of event time in RFC 3339.", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+	{Name: "to", Description: "Upper bound of event time in RFC 3339.", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+}
+
+// params joins query params lists.
+func params(lists ...[]openapi.Parameter) []openapi.Parameter {
+	var joined []openapi.Parameter
+	for _, list := range lists {
+		joined = append(joined, list...)
+	}
+
+	return joined
+}
@@ -0,0 +1,122 @@
+package apikeys_controller
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/console/consoleserver/controllers/auth"
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
+)
+
+var (
+	// ErrAPIKeys is an internal error type for api keys controller.
+	ErrAPIKeys = errs.Class("api keys controller error")
+)
+
+// maxRequestBodySize limits size of api key requests.
+const maxRequestBodySize = 4 << 10
+
+// APIKeys is a mvc controller that handles personal api keys management.
+type APIKeys struct {
+	apiKeys *apikeys.Service
+}
+
+// NewAPIKeys is a constructor for api keys controller.
+func NewAPIKeys(apiKeys *apikeys.Service) *APIKeys {
+	apiKeysController := &APIKeys{
+		apiKeys: apiKeys,
+	}
+
+	return apiKeysController
+}
+
+// List returns all api keys of authenticated user.
+func (c *APIKeys) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := c.sessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := c.apiKeys.List(ctx, userID)
+	if err != nil {
+		response.Error(w, ErrAPIKeys.Wrap(err))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, ListResponse{APIKeys: keys})
+}
+
+// Create creates new api key for authenticated user.
+func (c *APIKeys) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := c.sessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	var request CreateRequest
+	if err := response.Decode(w, r, maxRequestBodySize, &request); err != nil {
+		response.Error(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	key, value, err := c.apiKeys.Create(ctx, userID, request.Name, request.Scope)
+	if err != nil {
+		response.Error(w, ErrAPIKeys.Wrap(err))
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, CreateResponse{APIKey: key, Key: value})
+}
+
+// Revoke revokes api key of authenticated user.
+func (c *APIKeys) Revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := c.sessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+
+	err = c.apiKeys.Revoke(ctx, userID, id)
+	if err != nil {
+		response.Error(w, ErrAPIKeys.Wrap(err))
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// sessionUser returns id of user authenticated with a session token.
+// Api keys are not allowed to manage api keys.
+func (c *APIKeys) sessionUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	ctx := r.Context()
+
+	userID, ok := auth.GetUserID(ctx)
+	if !ok {
+		response.Error(w, apperr.ErrUnauthorized.New("authentication required"))
+		return uuid.Nil, false
+	}
+
+	if _, isAPIKey := ctx.Value(auth.KeyAPIKeyID).(uuid.UUID); isAPIKey {
+		response.Error(w, apperr.ErrForbidden.New("api keys can't be managed with an api key"))
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
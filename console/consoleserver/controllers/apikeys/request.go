@@ -0,0 +1,9 @@
+package apikeys_controller
+
+import "kitchen_nerd/apikeys"
+
+// CreateRequest contains api key creation fields.
+type CreateRequest struct {
+	Name  string        `json:"name"`
+	Scope apikeys.Scope `json:"scope"`
+}
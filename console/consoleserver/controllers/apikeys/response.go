@@ -0,0 +1,14 @@
+package apikeys_controller
+
+import "kitchen_nerd/apikeys"
+
+// CreateResponse contains created api key along with its value, which is shown only once.
+type CreateResponse struct {
+	APIKey *apikeys.APIKey `json:"apiKey"`
+	Key    string          `json:"key"`
+}
+
+// ListResponse contains all api keys of the user.
+type ListResponse struct {
+	APIKeys []apikeys.APIKey `json:"apiKeys"`
+}
@@ -0,0 +1,94 @@
+package audit_controller
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/audit"
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/util"
+)
+
+var (
+	// ErrAudit is an internal error type for audit controller.
+	ErrAudit = errs.Class("audit controller error")
+)
+
+// Audit is a mvc controller that handles audit log views.
+type Audit struct {
+	audit *audit.Service
+}
+
+// NewAudit is a constructor for audit controller.
+func NewAudit(audit *audit.Service) *Audit {
+	auditController := &Audit{
+		audit: audit,
+	}
+
+	return auditController
+}
+
+// ListResponse contains a page of audit events.
+type ListResponse struct {
+	Events             []audit.Event            `json:"events"`
+	PaginationResponse *util.PaginationResponse `json:"pagination"`
+}
+
+// List returns a page of audit events filtered by actor, action, targetType, targetID, from and to query params.
+func (c *Audit) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	pagination := util.NewPaginationReq(10, 1)
+	if err := pagination.ProcessQueryParams(query); err != nil {
+		response.Error(w, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+
+	filter, err := ParseFilter(query)
+	if err != nil {
+		response.Error(w, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+
+	events, total, err := c.audit.List(ctx, filter, pagination)
+	if err != nil {
+		response.Error(w, ErrAudit.Wrap(err))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, ListResponse{
+		Events:             events,
+		PaginationResponse: util.NewPaginationResponse(pagination.Size, pagination.Page, total),
+	})
+}
+
+// ParseFilter parses audit filter from query params, time bounds are expected in RFC 3339.
+func ParseFilter(query url.Values) (filter audit.Filter, err error) {
+	if actor := query.Get("actor"); actor != "" {
+		if filter.ActorID, err = uuid.Parse(actor); err != nil {
+			return filter, err
+		}
+	}
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return filter, err
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return filter, err
+		}
+	}
+
+	filter.Action = audit.Action(query.Get("action"))
+	filter.TargetType = query.Get("targetType")
+	filter.TargetID = query.Get("targetID")
+
+	return filter, nil
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"net/http"
+
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/tokens"
+)
+
+// CreateUser registers a new user account.
+func (c *Auth) CreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request RegistrationRequest
+	if err := response.Decode(w, r, maxRequestBodySize, &request); err != nil {
+		response.Error(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := request.Validate(); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	err := c.users.Create(ctx, request.UserName, request.Email, request.Password)
+	if err != nil {
+		response.Error(w, ErrAuth.Wrap(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// CreateSession logs user in, the session token is returned in the body and set in session cookies.
+func (c *Auth) CreateSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request LoginRequest
+	if err := response.Decode(w, r, maxRequestBodySize, &request); err != nil {
+		response.Error(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := request.Validate(); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	authToken, err := c.users.Login(ctx, request.Email, request.Password)
+	if err != nil {
+		response.Error(w, ErrAuth.Wrap(err))
+		return
+	}
+
+	if err = setSessionCookies(w, r, authToken); err != nil {
+		response.Error(w, ErrAuth.Wrap(err))
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, authToken)
+}
+
+// DeleteSession ends current session and removes its cookies.
+func (c *Auth) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token, ok := ctx.Value(KeyToken).(string)
+	if !ok {
+		response.Error(w, apperr.ErrUnauthorized.New("not logged in with a session"))
+		return
+	}
+
+	if err := c.users.Logout(ctx, token); err != nil && !tokens.ErrNoToken.Has(err) {
+		response.Error(w, ErrAuth.Wrap(err))
+		return
+	}
+
+	clearSessionCookies(w)
+	response.NoContent(w)
+}
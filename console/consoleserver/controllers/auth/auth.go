@@ -2,14 +2,17 @@ package auth
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"html/template"
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/logger"
 	"kitchen_nerd/tokens"
-	"log"
 	"net/http"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/zeebo/errs"
 
 	"kitchen_nerd/users"
@@ -22,10 +25,17 @@ var (
 
 const (
 	bearerPrefix = "Bearer "
+	apiKeyHeader = "X-API-Key"
+
+	// maxRequestBodySize limits size of login and registration requests.
+	maxRequestBodySize = 4 << 10
 
 	KeyUserID   = "user_id"
 	KeyToken    = "token"
 	KeyUsername = "username"
+	KeyStatus   = "status"
+	KeyScope    = "scope"
+	KeyAPIKeyID = "api_key_id"
 )
 
 // Templates holds all users auth related templates.
@@ -36,16 +46,19 @@ type Templates struct {
 
 // Auth is a mvc controller that handles all auth related views.
 type Auth struct {
-	users  *users.Service
-	tokens *tokens.Service
+	users   *users.Service
+	tokens  *tokens.Service
+	apiKeys *apikeys.Service
 
 	templates Templates
 }
 
 // NewAuth is a constructor for users controller.
-func NewAuth(users *users.Service, templates Templates) *Auth {
+func NewAuth(users *users.Service, tokens *tokens.Service, apiKeys *apikeys.Service, templates Templates) *Auth {
 	usersController := &Auth{
 		users:     users,
+		tokens:    tokens,
+		apiKeys:   apiKeys,
 		templates: templates,
 	}
 
@@ -56,6 +69,7 @@ func NewAuth(users *users.Service, templates Templates) *Auth {
 func (c *Auth) Register(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err := c.templates.Register.Execute(w, nil); err != nil {
 			http.Error(w, "could not execute register user template", http.StatusInternalServerError)
 			return
@@ -64,21 +78,20 @@ func (c *Auth) Register(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
 		var request RegistrationRequest
-		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			c.serveError(w, http.StatusBadRequest, ErrAuth.Wrap(err))
+		if err := response.Decode(w, r, maxRequestBodySize, &request); err != nil {
+			response.Error(w, err)
 			return
 		}
 		defer r.Body.Close()
 
-		if !request.IsValid() {
-			c.serveError(w, http.StatusBadRequest, ErrAuth.New("did not fill in all the fields"))
+		if err := request.Validate(); err != nil {
+			response.Error(w, err)
 			return
 		}
 
 		err := c.users.Create(ctx, request.UserName, request.Email, request.Password)
 		if err != nil {
-			log.Println("Unable to register new user", ErrAuth.Wrap(err))
-			c.serveError(w, http.StatusConflict, ErrAuth.Wrap(err))
+			response.Error(w, ErrAuth.Wrap(err))
 			return
 		}
 	}
@@ -87,6 +100,7 @@ func (c *Auth) Register(w http.ResponseWriter, r *http.Request) {
 func (c *Auth) Login(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err := c.templates.Login.Execute(w, nil); err != nil {
 			http.Error(w, "could not execute login user template", http.StatusInternalServerError)
 			return
@@ -94,92 +108,186 @@ func (c *Auth) Login(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost:
 		ctx := r.Context()
 		var request LoginRequest
-		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			c.serveError(w, http.StatusBadRequest, ErrAuth.Wrap(err))
+		if err := response.Decode(w, r, maxRequestBodySize, &request); err != nil {
+			response.Error(w, err)
 			return
 		}
 
-		if !request.IsValid() {
-			c.serveError(w, http.StatusBadRequest, ErrAuth.New("did not fill in all the fields"))
+		if err := request.Validate(); err != nil {
+			response.Error(w, err)
 			return
 		}
 
 		authToken, err := c.users.Login(ctx, request.Email, request.Password)
 		if err != nil {
-			if errors.As(users.ErrWrongCredentials, &err) {
-				c.serveError(w, http.StatusBadRequest, ErrAuth.Wrap(err))
-				return
-			}
-			log.Println("Unable to login a user", ErrAuth.Wrap(err))
-			c.serveError(w, http.StatusInternalServerError, ErrAuth.Wrap(err))
+			response.Error(w, ErrAuth.Wrap(err))
 			return
 		}
 
-		if err = json.NewEncoder(w).Encode(authToken); err != nil {
-			log.Println("failed to write json error response", ErrAuth.Wrap(err))
+		if err = setSessionCookies(w, r, authToken); err != nil {
+			response.Error(w, ErrAuth.Wrap(err))
+			return
 		}
+
+		response.JSON(w, http.StatusOK, authToken)
 	}
 }
 
-// serveError replies to request with specific code and error.
-func (c *Auth) serveError(w http.ResponseWriter, status int, err error) {
-	w.WriteHeader(status)
+// Logout removes current session and its cookies.
+func (c *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	var response struct {
-		Error string `json:"error"`
+	token, ok := ctx.Value(KeyToken).(string)
+	if !ok {
+		response.Error(w, apperr.ErrUnauthorized.New("not logged in"))
+		return
 	}
 
-	response.Error = err.Error()
-
-	if err = json.NewEncoder(w).Encode(response); err != nil {
-		log.Println("failed to write json error response", ErrAuth.Wrap(err))
+	if err := c.users.Logout(ctx, token); err != nil && !tokens.ErrNoToken.Has(err) {
+		response.Error(w, ErrAuth.Wrap(err))
+		return
 	}
+
+	clearSessionCookies(w)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// AuthMiddleware performs token check
+// AuthMiddleware performs token check. Requests are authenticated either with a session token
+// passed as a bearer token or in session cookie, or with a personal api key passed in X-API-Key
+// header or as a bearer token. State-changing requests authenticated with cookie must pass csrf check.
 func (c *Auth) AuthMiddleware(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-
 		ctx := r.Context()
 
-		token := r.Header.Get("Authorization")
-		if token == "" {
+		apiKey := r.Header.Get(apiKeyHeader)
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), bearerPrefix)
+		if apiKey == "" && apikeys.IsAPIKey(token) {
+			apiKey = token
+		}
+
+		if apiKey != "" {
+			key, err := c.apiKeys.Authenticate(ctx, apiKey)
+			if err != nil {
+				if apikeys.ErrNoAPIKey.Has(err) {
+					// unknown key is wrong credentials rather than a missing resource.
+					err = apperr.ErrUnauthorized.Wrap(err)
+				}
+				response.Error(w, ErrAuth.Wrap(err))
+				return
+			}
+
+			user, err := c.users.Get(ctx, key.UserID)
+			if err != nil {
+				response.Error(w, ErrAuth.Wrap(err))
+				return
+			}
+			if user.Status == users.StatusDisabled {
+				response.Error(w, ErrAuth.Wrap(users.ErrUserDisabled))
+				return
+			}
+
+			ctx = context.WithValue(ctx, KeyUserID, key.UserID)
+			ctx = context.WithValue(ctx, KeyUsername, user.Name)
+			ctx = context.WithValue(ctx, KeyStatus, string(user.Status))
+			ctx = context.WithValue(ctx, KeyScope, key.Scope)
+			ctx = context.WithValue(ctx, KeyAPIKeyID, key.ID)
+			ctx = audit.WithActor(ctx, key.UserID)
+			ctx = logger.Annotate(ctx, logger.String("user_id", key.UserID.String()))
+
 			handler.ServeHTTP(w, r.Clone(ctx))
 			return
+		}
 
-			//c.serveError(w, http.StatusUnauthorized, ErrAuth.New("Authorization header is not set"))
-			//return
+		fromCookie := false
+		if token == "" {
+			token = sessionFromCookie(r)
+			fromCookie = token != ""
+		}
+
+		if token == "" {
+			handler.ServeHTTP(w, r.Clone(ctx))
+			return
 		}
 
-		token = strings.TrimPrefix(token, bearerPrefix)
+		if fromCookie && isStateChanging(r.Method) {
+			if err := checkCSRF(r); err != nil {
+				response.Error(w, apperr.ErrForbidden.Wrap(err))
+				return
+			}
+		}
 
-		userToken, err := c.tokens.GetToken(ctx, token)
+		userToken, err := c.tokens.Authenticate(ctx, token)
 		if err != nil {
-			switch {
-			case tokens.ErrNoToken.Has(err):
-				c.serveError(w, http.StatusUnauthorized, ErrAuth.Wrap(err))
-			case ErrAuth.Has(err):
-				c.serveError(w, http.StatusUnauthorized, ErrAuth.Wrap(err))
-			default:
-				c.serveError(w, http.StatusInternalServerError, ErrAuth.Wrap(err))
+			// stale session cookie should not lock user out of pages.
+			if fromCookie && (tokens.ErrNoToken.Has(err) || tokens.ErrInvalidToken.Has(err)) {
+				clearSessionCookies(w)
+				handler.ServeHTTP(w, r.Clone(ctx))
+				return
 			}
 
+			response.Error(w, ErrAuth.Wrap(err))
 			return
 		}
 
-		user, err := c.users.Get(ctx, userToken.UserID)
-		if err != nil {
-			c.serveError(w, http.StatusInternalServerError, ErrAuth.Wrap(err))
-			return
+		// signed tokens already carry user information.
+		if !c.tokens.IsStateless() {
+			user, err := c.users.Get(ctx, userToken.UserID)
+			if err != nil {
+				response.Error(w, ErrAuth.Wrap(err))
+				return
+			}
+			if user.Status == users.StatusDisabled {
+				response.Error(w, ErrAuth.Wrap(users.ErrUserDisabled))
+				return
+			}
+			userToken.Username = user.Name
+			userToken.Status = string(user.Status)
 		}
-		// Добавим информацию о пользователе в контекст
 		ctx = context.WithValue(ctx, KeyUserID, userToken.UserID)
 		ctx = context.WithValue(ctx, KeyToken, userToken.Token)
-		ctx = context.WithValue(ctx, KeyUsername, user.Name)
+		ctx = context.WithValue(ctx, KeyUsername, userToken.Username)
+		ctx = context.WithValue(ctx, KeyStatus, userToken.Status)
+		ctx = context.WithValue(ctx, KeyScope, apikeys.ScopeWrite)
+		ctx = audit.WithActor(ctx, userToken.UserID)
+		ctx = logger.Annotate(ctx, logger.String("user_id", userToken.UserID.String()))
 
-		// Передаем контекст в обработчик
 		handler.ServeHTTP(w, r.Clone(ctx))
 	})
 }
+
+// RequireScope rejects requests made with an api key which scope doesn't allow the action.
+func (c *Auth) RequireScope(scope apikeys.Scope, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		granted, ok := r.Context().Value(KeyScope).(apikeys.Scope)
+		if ok && !granted.Allows(scope) {
+			response.Error(w, apperr.ErrForbidden.New("api key scope %q does not allow this action", granted))
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// RequireAdmin rejects requests of users who are not administrators.
+func (c *Auth) RequireAdmin(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, ok := r.Context().Value(KeyStatus).(string)
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized.New("authentication required"))
+			return
+		}
+
+		if users.Status(status) != users.StatusAdmin {
+			response.Error(w, apperr.ErrForbidden.New("administrator rights required"))
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// GetUserID returns id of authenticated user from the request context.
+func GetUserID(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(KeyUserID).(uuid.UUID)
+	return userID, ok
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"kitchen_nerd/pkg/rand"
+	"kitchen_nerd/tokens"
+)
+
+const (
+	// sessionCookie holds session token, it is not accessible from js.
+	sessionCookie = "session"
+	// csrfCookie holds csrf token, which js must send back in csrfHeader.
+	csrfCookie = "csrf_token"
+	csrfHeader = "X-CSRF-Token"
+
+	csrfTokenLength = 32
+)
+
+// setSessionCookies issues session and csrf cookies for the token.
+func setSessionCookies(w http.ResponseWriter, r *http.Request, token *tokens.UserToken) error {
+	csrfToken, err := rand.RandStr(csrfTokenLength, rand.TypeAlphaNum)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token.Token,
+		Path:     "/",
+		Expires:  token.ExpiredAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    csrfToken,
+		Path:     "/",
+		Expires:  token.ExpiredAt,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// clearSessionCookies removes session and csrf cookies.
+func clearSessionCookies(w http.ResponseWriter) {
+	for _, name := range []string{sessionCookie, csrfCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:    name,
+			Path:    "/",
+			Expires: time.Unix(0, 0),
+			MaxAge:  -1,
+		})
+	}
+}
+
+// sessionFromCookie returns session token from the request cookie.
+func sessionFromCookie(r *http.Request) string {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// isStateChanging checks if request method may change server state.
+func isStateChanging(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return true
+}
+
+// checkCSRF verifies that csrf header matches csrf cookie (double-submit cookie pattern).
+func checkCSRF(r *http.Request) error {
+	cookie, err := r.Cookie(csrfCookie)
+	if err != nil || cookie.Value == "" {
+		return ErrAuth.New("csrf cookie is not set")
+	}
+
+	header := r.Header.Get(csrfHeader)
+	if header == "" {
+		return ErrAuth.New("%s header is not set", csrfHeader)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+		return ErrAuth.New("csrf token mismatch")
+	}
+
+	return nil
+}
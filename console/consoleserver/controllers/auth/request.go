@@ -1,42 +1,27 @@
 package auth
 
+import "kitchen_nerd/pkg/validate"
+
 // RegistrationRequest contains user registration fields.
 type RegistrationRequest struct {
-	Email            string `json:"email"`
-	UserName         string `json:"username"`
-	Password         string `json:"password"`
-	RepeatedPassword string `json:"repeatedPassword"`
+	Email            string `json:"email" validate:"required,email"`
+	UserName         string `json:"username" validate:"required,max=64"`
+	Password         string `json:"password" validate:"required"`
+	RepeatedPassword string `json:"repeatedPassword" validate:"eqfield=Password"`
 }
 
-// LoginRequest contains user registration fields.
+// LoginRequest contains user login fields.
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
-// IsValid check the request for all conditions.
-func (uf *RegistrationRequest) IsValid() bool {
-	switch {
-	case uf.UserName == "":
-		return false
-	case uf.Email == "":
-		return false
-	case uf.Password == "":
-		return false
-	case uf.Password != uf.RepeatedPassword:
-		return false
-	default:
-		return true
-	}
+// Validate checks the request for all conditions.
+func (uf *RegistrationRequest) Validate() error {
+	return validate.Struct(uf)
 }
 
-func (uf *LoginRequest) IsValid() bool {
-	switch {
-	case uf.Email == "":
-		return false
-	case uf.Password == "":
-		return false
-	default:
-		return true
-	}
+// Validate checks the request for all conditions.
+func (uf *LoginRequest) Validate() error {
+	return validate.Struct(uf)
 }
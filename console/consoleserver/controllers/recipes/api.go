@@ -0,0 +1,188 @@
+package recipes_controller
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/pkg/validate"
+	"kitchen_nerd/recipes"
+)
+
+// RecipesAPI is a controller that handles recipes resource of the json api.
+type RecipesAPI struct {
+	recipes *recipes.Service
+}
+
+// NewRecipesAPI is a constructor for recipes api controller.
+func NewRecipesAPI(recipes *recipes.Service) *RecipesAPI {
+	return &RecipesAPI{
+		recipes: recipes,
+	}
+}
+
+// List returns a page of recipes, sorted and filtered by sort and filter query params.
+func (c *RecipesAPI) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	pagination := util.NewPaginationReq(10, 1)
+	if err := pagination.ProcessQueryParams(query); err != nil {
+		response.Error(w, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+
+	listQuery, err := util.ParseListQuery(query, recipes.ListFields)
+	if err != nil {
+		response.Error(w, ErrRecipes.Wrap(err))
+		return
+	}
+
+	list, paginationResponse, err := c.recipes.List(ctx, pagination, listQuery)
+	if err != nil {
+		response.Error(w, ErrRecipes.Wrap(err))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, NewListResponse(list, paginationResponse))
+}
+
+// Get returns recipe by id.
+func (c *RecipesAPI) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := recipeID(w, r)
+	if !ok {
+		return
+	}
+
+	recipe, err := c.recipes.Get(r.Context(), id)
+	if err != nil {
+		response.Error(w, ErrRecipes.Wrap(err))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, recipe)
+}
+
+// Create creates a recipe and returns it along with its location.
+func (c *RecipesAPI) Create(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeRecipeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	recipe := recipes.NewRecipe(request.Title, request.PhotoBase64, request.Description, request.Instructions)
+	if err := c.recipes.Create(r.Context(), recipe, request.Ingredients); err != nil {
+		response.Error(w, ErrRecipes.Wrap(err))
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/recipes/"+recipe.ID.String())
+	response.JSON(w, http.StatusCreated, recipe)
+}
+
+// Replace replaces all fields and ingredients of the recipe.
+func (c *RecipesAPI) Replace(w http.ResponseWriter, r *http.Request) {
+	id, ok := recipeID(w, r)
+	if !ok {
+		return
+	}
+
+	request, ok := decodeRecipeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ingredients := request.Ingredients
+	if ingredients == nil {
+		ingredients = []recipes.RecipeIngredient{}
+	}
+
+	recipe, err := c.recipes.Patch(r.Context(), id, recipes.Patch{
+		Title:        &request.Title,
+		PhotoBase64:  &request.PhotoBase64,
+		Description:  &request.Description,
+		Instructions: &request.Instructions,
+		Ingredients:  &ingredients,
+	})
+	if err != nil {
+		response.Error(w, ErrRecipes.Wrap(err))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, recipe)
+}
+
+// Patch changes fields of the recipe present in the request.
+func (c *RecipesAPI) Patch(w http.ResponseWriter, r *http.Request) {
+	id, ok := recipeID(w, r)
+	if !ok {
+		return
+	}
+
+	var patch recipes.Patch
+	if err := response.Decode(w, r, maxRequestBodySize, &patch); err != nil {
+		response.Error(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validate.Struct(patch); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	recipe, err := c.recipes.Patch(r.Context(), id, patch)
+	if err != nil {
+		response.Error(w, ErrRecipes.Wrap(err))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, recipe)
+}
+
+// Delete deletes the recipe.
+func (c *RecipesAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := recipeID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.recipes.Delete(r.Context(), id); err != nil {
+		response.Error(w, ErrRecipes.Wrap(err))
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// recipeID parses recipe id from the path.
+func recipeID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, apperr.ErrBadRequest.Wrap(err))
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// decodeRecipeRequest decodes recipe from the request body.
+func decodeRecipeRequest(w http.ResponseWriter, r *http.Request) (*RecipeRequest, bool) {
+	var request RecipeRequest
+	if err := response.Decode(w, r, maxRequestBodySize, &request); err != nil {
+		response.Error(w, err)
+		return nil, false
+	}
+	defer r.Body.Close()
+
+	if err := validate.Struct(request); err != nil {
+		response.Error(w, err)
+		return nil, false
+	}
+
+	return &request, true
+}
@@ -1,14 +1,15 @@
 package recipes_controller
 
 import (
-	"encoding/json"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/zeebo/errs"
 	"html/template"
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
 	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/pkg/validate"
 	"kitchen_nerd/recipes"
-	"log"
 	"net/http"
 )
 
@@ -17,6 +18,9 @@ var (
 	ErrRecipes = errs.Class("recipes controller error")
 )
 
+// maxRequestBodySize limits size of recipe requests, which carry base64 encoded photos.
+const maxRequestBodySize = 8 << 20
+
 type Templates struct {
 	List   *template.Template
 	Get    *template.Template
@@ -42,6 +46,7 @@ func NewRecipes(recipes *recipes.Service, templates Templates) *Recipes {
 func (c *Recipes) List(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err := c.templates.List.Execute(w, nil); err != nil {
 			http.Error(w, "could not execute recipes list template", http.StatusInternalServerError)
 			return
@@ -50,36 +55,42 @@ func (c *Recipes) List(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		pagination := util.NewPaginationReq(10, 1)
 		if err := pagination.ProcessQueryParams(r.URL.Query()); err != nil {
-			c.serveError(w, http.StatusInternalServerError, err)
+			response.Error(w, apperr.ErrBadRequest.Wrap(err))
 			return
 		}
 
-		res, total, err := c.recipes.List(ctx, pagination)
+		listQuery, err := util.ParseListQuery(r.URL.Query(), recipes.ListFields)
 		if err != nil {
-			c.serveError(w, http.StatusInternalServerError, err)
+			response.Error(w, ErrRecipes.Wrap(err))
 			return
 		}
-		response := NewListResponse(res, util.NewPaginationResponse(pagination.Size, pagination.Page, total))
-		if err = json.NewEncoder(w).Encode(response); err != nil {
-			log.Println("failed to write json error response", ErrRecipes.Wrap(err))
+
+		res, paginationResponse, err := c.recipes.List(ctx, pagination, listQuery)
+		if err != nil {
+			response.Error(w, ErrRecipes.Wrap(err))
 			return
 		}
+		response.JSON(w, http.StatusOK, NewListResponse(res, paginationResponse))
 	}
 }
 
 func (c *Recipes) Create(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err := c.templates.Create.Execute(w, nil); err != nil {
 			http.Error(w, "could not execute recipes list template", http.StatusInternalServerError)
 			return
 		}
 	case http.MethodPost:
 		ctx := r.Context()
-		var request *RecipeRequest
-		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			log.Println(err)
-			c.serveError(w, http.StatusBadRequest, ErrRecipes.Wrap(err))
+		var request RecipeRequest
+		if err := response.Decode(w, r, maxRequestBodySize, &request); err != nil {
+			response.Error(w, err)
+			return
+		}
+		if err := validate.Struct(request); err != nil {
+			response.Error(w, err)
 			return
 		}
 
@@ -87,7 +98,7 @@ func (c *Recipes) Create(w http.ResponseWriter, r *http.Request) {
 
 		err := c.recipes.Create(ctx, recipe, request.Ingredients)
 		if err != nil {
-			c.serveError(w, http.StatusInternalServerError, ErrRecipes.Wrap(err))
+			response.Error(w, ErrRecipes.Wrap(err))
 			return
 		}
 
@@ -126,6 +137,7 @@ func (c *Recipes) Create(w http.ResponseWriter, r *http.Request) {
 func (c *Recipes) Get(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err := c.templates.Get.Execute(w, nil); err != nil {
 			http.Error(w, "could not execute recipes list template", http.StatusInternalServerError)
 			return
@@ -135,19 +147,16 @@ func (c *Recipes) Get(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		id, err := uuid.Parse(vars["id"])
 		if err != nil {
-			c.serveError(w, http.StatusBadRequest, err)
+			response.Error(w, apperr.ErrBadRequest.Wrap(err))
 			return
 		}
 
 		recipe, err := c.recipes.Get(ctx, id)
 		if err != nil {
-			c.serveError(w, http.StatusBadRequest, err)
-			return
-		}
-		if err = json.NewEncoder(w).Encode(recipe); err != nil {
-			log.Println("failed to write json error response", ErrRecipes.Wrap(err))
+			response.Error(w, ErrRecipes.Wrap(err))
 			return
 		}
+		response.JSON(w, http.StatusOK, recipe)
 	}
 }
 
@@ -186,28 +195,13 @@ func (c *Recipes) Delete(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		c.serveError(w, http.StatusBadRequest, err)
+		response.Error(w, apperr.ErrBadRequest.Wrap(err))
 		return
 	}
 
 	err = c.recipes.Delete(ctx, id)
 	if err != nil {
-		c.serveError(w, http.StatusBadRequest, err)
+		response.Error(w, ErrRecipes.Wrap(err))
 		return
 	}
 }
-
-// serveError replies to request with specific code and error.
-func (c *Recipes) serveError(w http.ResponseWriter, status int, err error) {
-	w.WriteHeader(status)
-
-	var response struct {
-		Error string `json:"error"`
-	}
-
-	response.Error = err.Error()
-
-	if err = json.NewEncoder(w).Encode(response); err != nil {
-		log.Println("failed to write json error response", ErrRecipes.Wrap(err))
-	}
-}
@@ -2,9 +2,10 @@ package recipes_controller
 
 import "kitchen_nerd/recipes"
 
+// RecipeRequest contains recipe fields, photo is limited to about 5MB once base64 encoded.
 type RecipeRequest struct {
-	Title        string                     `json:"title"`
-	PhotoBase64  string                     `json:"photoBase64"`
+	Title        string                     `json:"title" validate:"required,max=255"`
+	PhotoBase64  string                     `json:"photoBase64" validate:"max=7000000"`
 	Description  string                     `json:"description"`
 	Instructions string                     `json:"instructions"`
 	Ingredients  []recipes.RecipeIngredient `json:"ingredients"`
@@ -1,13 +1,14 @@
 package controllers
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/zeebo/errs"
 
+	"kitchen_nerd/console/consoleserver/response"
 	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/pkg/validate"
 	"kitchen_nerd/users"
 )
 
@@ -16,6 +17,9 @@ var (
 	ErrUsers = errs.Class("users controller error")
 )
 
+// maxRequestBodySize limits size of registration requests.
+const maxRequestBodySize = 4 << 10
+
 // Users is a mvc controller that handles all users related views.
 type Users struct {
 	log   logger.Logger
@@ -34,26 +38,10 @@ func NewUsers(log logger.Logger, users *users.Service) *Users {
 
 // UserFields contains user registration fields.
 type UserFields struct {
-	FirstName string `json:"firstName"`
-	LastName  string `json:"lastName"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-}
-
-// IsValid check the request for all conditions.
-func (uf *UserFields) IsValid() bool {
-	switch {
-	case uf.FirstName == "":
-		return false
-	case uf.LastName == "":
-		return false
-	case uf.Email == "":
-		return false
-	case uf.Password == "":
-		return false
-	default:
-		return true
-	}
+	FirstName string `json:"firstName" validate:"required"`
+	LastName  string `json:"lastName" validate:"required"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required"`
 }
 
 // TokenResponse contains token.
@@ -68,36 +56,21 @@ func (u *Users) Register(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var request UserFields
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		u.serveError(w, http.StatusBadRequest, ErrUsers.Wrap(err))
+	if err := response.Decode(w, r, maxRequestBodySize, &request); err != nil {
+		response.Error(w, err)
 		return
 	}
 	defer r.Body.Close()
 
-	if !request.IsValid() {
-		u.serveError(w, http.StatusBadRequest, ErrUsers.New("did not fill in all the fields"))
+	if err := validate.Struct(request); err != nil {
+		response.Error(w, err)
 		return
 	}
 
 	err := u.users.Create(ctx, fmt.Sprintf("%s %s", request.FirstName, request.LastName), request.Email, request.Password)
 	if err != nil {
 		u.log.Error("Unable to register new user", ErrUsers.Wrap(err))
-		u.serveError(w, http.StatusInternalServerError, ErrUsers.Wrap(err))
+		response.Error(w, ErrUsers.Wrap(err))
 		return
 	}
 }
-
-// serveError replies to request with specific code and error.
-func (u *Users) serveError(w http.ResponseWriter, status int, err error) {
-	w.WriteHeader(status)
-
-	var response struct {
-		Error string `json:"error"`
-	}
-
-	response.Error = err.Error()
-
-	if err = json.NewEncoder(w).Encode(response); err != nil {
-		u.log.Error("failed to write json error response", ErrUsers.Wrap(err))
-	}
-}
@@ -1,14 +1,15 @@
 package users_controller
 
 import (
-	"encoding/json"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/zeebo/errs"
 	"html/template"
-	"log"
 	"net/http"
 
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/util"
 	"kitchen_nerd/users"
 )
 
@@ -39,6 +40,7 @@ func NewUsers(users *users.Service, templates Templates) *Users {
 func (c *Users) Profile(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err := c.templates.Profile.Execute(w, nil); err != nil {
 			http.Error(w, "could not execute user's profile tepmlate", http.StatusInternalServerError)
 			return
@@ -48,33 +50,67 @@ func (c *Users) Profile(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		id, err := uuid.Parse(vars["id"])
 		if err != nil {
-			c.serveError(w, http.StatusBadRequest, err)
+			response.Error(w, apperr.ErrBadRequest.Wrap(err))
 			return
 		}
 
 		profile, err := c.users.GetProfile(ctx, id)
 		if err != nil {
-			c.serveError(w, http.StatusBadRequest, err)
-			return
-		}
-		if err = json.NewEncoder(w).Encode(profile); err != nil {
-			log.Println("failed to write json error response", ErrUsers.Wrap(err))
+			response.Error(w, ErrUsers.Wrap(err))
 			return
 		}
+		response.JSON(w, http.StatusOK, profile)
+	}
+}
+
+// Get returns profile of the user.
+func (c *Users) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		response.Error(w, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+
+	profile, err := c.users.GetProfile(r.Context(), id)
+	if err != nil {
+		response.Error(w, ErrUsers.Wrap(err))
+		return
 	}
+
+	response.JSON(w, http.StatusOK, profile)
+}
+
+// ListResponse contains a page of users accounts.
+type ListResponse struct {
+	Users              []users.Account          `json:"users"`
+	PaginationResponse *util.PaginationResponse `json:"pagination"`
 }
 
-// serveError replies to request with specific code and error.
-func (c *Users) serveError(w http.ResponseWriter, status int, err error) {
-	w.WriteHeader(status)
+// List returns a page of users accounts, sorted and filtered by sort and filter query params.
+func (c *Users) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	var response struct {
-		Error string `json:"error"`
+	query := r.URL.Query()
+	pagination := util.NewPaginationReq(10, 1)
+	if err := pagination.ProcessQueryParams(query); err != nil {
+		response.Error(w, apperr.ErrBadRequest.Wrap(err))
+		return
 	}
 
-	response.Error = err.Error()
+	listQuery, err := util.ParseListQuery(query, users.ListFields)
+	if err != nil {
+		response.Error(w, ErrUsers.Wrap(err))
+		return
+	}
 
-	if err = json.NewEncoder(w).Encode(response); err != nil {
-		log.Println("failed to write json error response", ErrUsers.Wrap(err))
+	accounts, paginationResponse, err := c.users.List(ctx, pagination, listQuery)
+	if err != nil {
+		response.Error(w, ErrUsers.Wrap(err))
+		return
 	}
+
+	response.JSON(w, http.StatusOK, ListResponse{
+		Users:              accounts,
+		PaginationResponse: paginationResponse,
+	})
 }
@@ -0,0 +1,101 @@
+package consoleserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/logger"
+)
+
+// requestIDHeader carries id of the request, it's taken from the client when valid or generated otherwise.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits length of request ids accepted from clients.
+const maxRequestIDLength = 128
+
+// loggingMiddleware assigns request id and writes a log entry with method, path, status,
+// latency and user of every request. Handlers get the logger of the request through its context.
+func (server *Server) loggingMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(requestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		log := server.log.With(logger.String("request_id", requestID))
+		entry := new(logger.Entry)
+
+		ctx := logger.NewContext(r.Context(), log)
+		ctx = logger.WithEntry(ctx, entry)
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(recorder, r.WithContext(ctx))
+
+		fields := append([]logger.Field{
+			logger.String("method", r.Method),
+			logger.String("path", r.URL.Path),
+			logger.Int("status", recorder.status),
+			logger.Duration("latency", time.Since(start)),
+		}, entry.Fields()...)
+
+		if recorder.err != nil {
+			log.Error("request failed", recorder.err, fields...)
+			return
+		}
+		log.Info("request", fields...)
+	})
+}
+
+// isValidRequestID checks that request id of a client is safe to log and echo back.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		if r <= ' ' || r > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// responseRecorder remembers status and internal error of the response for the request log.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+	err         error
+}
+
+// WriteHeader records status and sends it.
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if !recorder.wroteHeader {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// Write sends body of the response, status is 200 unless written before.
+func (recorder *responseRecorder) Write(b []byte) (int, error) {
+	recorder.wroteHeader = true
+	return recorder.ResponseWriter.Write(b)
+}
+
+// RecordError implements response.ErrorRecorder, the error is passed to outer recorders as well.
+func (recorder *responseRecorder) RecordError(err error) {
+	recorder.err = err
+	if outer, ok := recorder.ResponseWriter.(response.ErrorRecorder); ok {
+		outer.RecordError(err)
+	}
+}
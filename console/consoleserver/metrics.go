@@ -0,0 +1,70 @@
+package consoleserver
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute is a route label of requests which matched no route, so unknown paths don't blow up cardinality.
+const unmatchedRoute = "unmatched"
+
+// httpMetrics counts requests and their latency per route template.
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// newHTTPMetrics registers http metrics in the registry.
+func newHTTPMetrics(registry prometheus.Registerer) *httpMetrics {
+	m := &httpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of handled http requests.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of http requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+	registry.MustRegister(m.requests, m.duration)
+
+	return m
+}
+
+// middleware measures requests to the matched route, it is used as a router middleware.
+func (m *httpMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := unmatchedRoute
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		m.measure(next, route, w, r)
+	})
+}
+
+// unmatched measures requests handled by not found and method not allowed handlers,
+// which router middlewares don't run for.
+func (m *httpMetrics) unmatched(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.measure(next, unmatchedRoute, w, r)
+	})
+}
+
+// measure serves the request with next and records its status and latency.
+func (m *httpMetrics) measure(next http.Handler, route string, w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(recorder, r)
+
+	m.requests.WithLabelValues(r.Method, route, strconv.Itoa(recorder.status)).Inc()
+	m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,320 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/zeebo/errs"
+)
+
+var (
+	// ErrOpenAPI is an error class that indicates that api specification could not be generated.
+	ErrOpenAPI = errs.Class("openapi error")
+)
+
+// Security schemes operations requiring authentication accept.
+const (
+	SecurityBearer  = "bearer"
+	SecurityAPIKey  = "apiKey"
+	SecuritySession = "session"
+)
+
+// Operation describes an api route.
+type Operation struct {
+	Summary string
+	// Request is a sample of request body, nil for requests without body.
+	Request interface{}
+	// Response is a sample of successful response body, nil for empty responses.
+	Response interface{}
+	// Status is a status of successful response, 200 by default.
+	Status int
+	// Query lists query params the operation accepts.
+	Query []Parameter
+	// Auth tells that operation requires authentication.
+	Auth bool
+}
+
+// Parameter describes query param.
+type Parameter struct {
+	Name        string
+	Description string
+	Schema      *Schema
+	// Style is a serialization style of the param, e.g. "deepObject" for filter[field][op]=value.
+	Style string
+}
+
+// Spec is an OpenAPI 3 document.
+type Spec struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info contains api metadata.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lowercase http methods to operations of a path.
+type PathItem map[string]*OperationObject
+
+// OperationObject describes an api operation in the specification.
+type OperationObject struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []ParameterObject     `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// ParameterObject describes path or query param in the specification.
+type ParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     bool    `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes request body.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes a way to authenticate.
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	Name   string `json:"name,omitempty"`
+	In     string `json:"in,omitempty"`
+}
+
+// Document collects descriptions of api routes.
+type Document struct {
+	info          Info
+	errorResponse interface{}
+	operations    map[*mux.Route]Operation
+}
+
+// NewDocument is a constructor for Document, errorResponse is a sample of error body all operations may reply with.
+func NewDocument(title, version string, errorResponse interface{}) *Document {
+	return &Document{
+		info:          Info{Title: title, Version: version},
+		errorResponse: errorResponse,
+		operations:    make(map[*mux.Route]Operation),
+	}
+}
+
+// Describe attaches operation description to the route.
+func (doc *Document) Describe(route *mux.Route, operation Operation) *mux.Route {
+	doc.operations[route] = operation
+	return route
+}
+
+// Spec generates specification of all routes of the router under the path prefix,
+// it fails if any of these routes is not described.
+func (doc *Document) Spec(router *mux.Router, prefix string) (*Spec, error) {
+	schemas := &schemas{components: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+	spec := &Spec{
+		OpenAPI: "3.0.3",
+		Info:    doc.info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: schemas.components,
+			SecuritySchemes: map[string]SecurityScheme{
+				SecurityBearer:  {Type: "http", Scheme: "bearer"},
+				SecurityAPIKey:  {Type: "apiKey", Name: "X-API-Key", In: "header"},
+				SecuritySession: {Type: "apiKey", Name: "session", In: "cookie"},
+			},
+		},
+	}
+	errorSchema := schemas.of(doc.errorResponse)
+
+	var undocumented []string
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, prefix) {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return ErrOpenAPI.New("route %s doesn't restrict methods", template)
+		}
+
+		operation, ok := doc.operations[route]
+		if !ok {
+			for _, method := range methods {
+				undocumented = append(undocumented, method+" "+template)
+			}
+			return nil
+		}
+
+		pathItem, ok := spec.Paths[template]
+		if !ok {
+			pathItem = make(PathItem)
+			spec.Paths[template] = pathItem
+		}
+		for _, method := range methods {
+			pathItem[strings.ToLower(method)] = operation.object(schemas, errorSchema, method, strings.TrimPrefix(template, prefix))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, ErrOpenAPI.Wrap(err)
+	}
+
+	if len(undocumented) > 0 {
+		sort.Strings(undocumented)
+		return nil, ErrOpenAPI.New("routes without schema: %s", strings.Join(undocumented, ", "))
+	}
+
+	return spec, nil
+}
+
+// object returns specification of the operation.
+func (operation Operation) object(schemas *schemas, errorSchema *Schema, method, template string) *OperationObject {
+	segments := strings.Split(strings.Trim(template, "/"), "/")
+
+	object := &OperationObject{
+		OperationID: operationID(method, segments),
+		Summary:     operation.Summary,
+		Tags:        []string{segments[0]},
+		Responses: map[string]Response{
+			"default": {Description: "Error", Content: jsonContent(errorSchema)},
+		},
+	}
+
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := strings.Trim(segment, "{}")
+			schema := &Schema{Type: "string"}
+			if name == "id" {
+				schema.Format = "uuid"
+			}
+
+			object.Parameters = append(object.Parameters, ParameterObject{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   schema,
+			})
+		}
+	}
+	for _, param := range operation.Query {
+		object.Parameters = append(object.Parameters, ParameterObject{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Style:       param.Style,
+			Explode:     param.Style != "",
+			Schema:      param.Schema,
+		})
+	}
+
+	if operation.Request != nil {
+		object.RequestBody = &RequestBody{Required: true, Content: jsonContent(schemas.of(operation.Request))}
+	}
+
+	status := operation.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := Response{Description: http.StatusText(status)}
+	if operation.Response != nil {
+		response.Content = jsonContent(schemas.of(operation.Response))
+	}
+	object.Responses[strconv.Itoa(status)] = response
+
+	if operation.Auth {
+		object.Security = []map[string][]string{
+			{SecurityBearer: {}},
+			{SecurityAPIKey: {}},
+			{SecuritySession: {}},
+		}
+	}
+
+	return object
+}
+
+// operationID returns id of the operation, e.g. "getRecipesById" for GET /recipes/{id}.
+func operationID(method string, segments []string) string {
+	id := strings.ToLower(method)
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, "{") {
+			segment = "by-" + strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.Split(segment, "-") {
+			if word != "" {
+				id += strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+	}
+
+	return id
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+//go:embed docs.html
+var docsPage []byte
+
+// SpecHandler serves the specification as json.
+func SpecHandler(spec *Spec) (http.HandlerFunc, error) {
+	body, err := json.Marshal(spec)
+	if err != nil {
+		return nil, ErrOpenAPI.Wrap(err)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(body)
+	}, nil
+}
+
+// DocsHandler serves page that renders the specification served at specURL.
+func DocsHandler(specURL string) http.HandlerFunc {
+	page := strings.ReplaceAll(string(docsPage), "{{SPEC_URL}}", specURL)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}
+}
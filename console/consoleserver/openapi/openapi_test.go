@@ -0,0 +1,98 @@
+package openapi_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"kitchen_nerd/console/consoleserver/openapi"
+)
+
+type note struct {
+	ID        uuid.UUID  `json:"id"`
+	Text      string     `json:"text"`
+	Secret    string     `json:"-"`
+	Tags      []string   `json:"tags"`
+	EditedAt  *time.Time `json:"editedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Replies   []note     `json:"replies"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {}
+
+func TestSpec(t *testing.T) {
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	router.HandleFunc("/page", handler).Methods(http.MethodGet)
+
+	docs := openapi.NewDocument("notes", "1.0.0", apiError{})
+	docs.Describe(api.HandleFunc("/notes/{id}", handler).Methods(http.MethodGet), openapi.Operation{
+		Summary:  "Get note",
+		Response: note{},
+	})
+	docs.Describe(api.HandleFunc("/notes", handler).Methods(http.MethodPost), openapi.Operation{
+		Summary:  "Create note",
+		Request:  note{},
+		Response: note{},
+		Status:   http.StatusCreated,
+		Auth:     true,
+	})
+
+	spec, err := docs.Spec(router, "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := spec.Paths["/api/notes/{id}"]["get"]
+	if get == nil || get.OperationID != "getNotesById" || len(get.Parameters) != 1 || get.Parameters[0].In != "path" {
+		t.Fatalf("unexpected get operation %+v", get)
+	}
+	if _, ok := get.Responses["default"]; !ok {
+		t.Fatal("operation doesn't describe error response")
+	}
+
+	post := spec.Paths["/api/notes"]["post"]
+	if post == nil || post.RequestBody == nil || len(post.Security) == 0 {
+		t.Fatalf("unexpected post operation %+v", post)
+	}
+	if _, ok := post.Responses["201"]; !ok {
+		t.Fatalf("unexpected post responses %+v", post.Responses)
+	}
+
+	schema := spec.Components.Schemas["openapi_test.note"]
+	if schema == nil {
+		t.Fatalf("note schema is missing in %+v", spec.Components.Schemas)
+	}
+	if _, ok := schema.Properties["Secret"]; ok || len(schema.Properties) != 6 {
+		t.Fatalf("unexpected note properties %+v", schema.Properties)
+	}
+	if id := schema.Properties["id"]; id.Type != "string" || id.Format != "uuid" {
+		t.Fatalf("unexpected id schema %+v", id)
+	}
+	if editedAt := schema.Properties["editedAt"]; editedAt.Format != "date-time" || !editedAt.Nullable {
+		t.Fatalf("unexpected editedAt schema %+v", editedAt)
+	}
+	if replies := schema.Properties["replies"]; replies.Items == nil || replies.Items.Ref != "#/components/schemas/openapi_test.note" {
+		t.Fatalf("unexpected replies schema %+v", replies)
+	}
+}
+
+func TestSpecUndocumentedRoute(t *testing.T) {
+	router := mux.NewRouter()
+	docs := openapi.NewDocument("notes", "1.0.0", apiError{})
+	docs.Describe(router.HandleFunc("/api/notes", handler).Methods(http.MethodGet), openapi.Operation{Summary: "List notes"})
+	router.HandleFunc("/api/notes/{id}", handler).Methods(http.MethodDelete)
+
+	_, err := docs.Spec(router, "/api")
+	if err == nil || !strings.Contains(err.Error(), "DELETE /api/notes/{id}") {
+		t.Fatalf("expected undocumented route error, got %v", err)
+	}
+}
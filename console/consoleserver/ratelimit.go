@@ -0,0 +1,76 @@
+package consoleserver
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kitchen_nerd/audit"
+	"kitchen_nerd/console/consoleserver/controllers/auth"
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/ratelimit"
+)
+
+// RateLimits contains policies of routes, nil policy doesn't limit its routes.
+type RateLimits struct {
+	// Auth limits login and registration, which are expensive and attractive for brute force.
+	Auth *ratelimit.Policy
+	// Read limits listing and viewing recipes.
+	Read *ratelimit.Policy
+	// Write limits changes of recipes.
+	Write *ratelimit.Policy
+}
+
+// rateLimiter limits requests of clients to routes by policies.
+type rateLimiter struct {
+	log     logger.Logger
+	service *ratelimit.Service
+}
+
+// limit wraps handler with policy. Authenticated clients are identified by user, others by address,
+// so it must be called on handlers which run after auth middleware. Requests are allowed when
+// limits can't be checked, unavailable storage must not take the whole service down.
+func (limiter *rateLimiter) limit(policy *ratelimit.Policy, handler http.HandlerFunc) http.HandlerFunc {
+	if limiter.service == nil || policy == nil {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := limiter.service.Allow(r.Context(), *policy, clientKey(r))
+		if err != nil {
+			limiter.log.Error("could not check rate limit", err, logger.String("policy", policy.Name))
+			handler(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("X-RateLimit-Reset", seconds(result.ResetAfter))
+
+		if !result.Allowed {
+			header.Set("Retry-After", seconds(result.RetryAfter))
+			response.Error(w, apperr.ErrRateLimited.New("too many requests, retry in %s", seconds(result.RetryAfter)+"s"))
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// clientKey identifies client of the request by user if it's authenticated and by address otherwise.
+func clientKey(r *http.Request) string {
+	if userID, ok := auth.GetUserID(r.Context()); ok {
+		return "user:" + userID.String()
+	}
+
+	return "ip:" + audit.GetRequestInfo(r.Context()).IP
+}
+
+// seconds formats duration as a number of whole seconds rounded up, as headers expect.
+func seconds(duration time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(duration.Seconds())), 10)
+}
@@ -0,0 +1,25 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"kitchen_nerd/pkg/apperr"
+)
+
+// Decode decodes json body of the request into v. Bodies larger than maxSize are rejected
+// as too large, malformed ones as bad requests.
+func Decode(w http.ResponseWriter, r *http.Request, maxSize int64, v interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return apperr.ErrTooLarge.New("request body exceeds %d bytes", maxBytesErr.Limit)
+		}
+
+		return apperr.ErrBadRequest.Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/validate"
+)
+
+// statuses maps kinds of domain errors to http statuses.
+var statuses = map[apperr.Kind]int{
+	apperr.KindInternal:     http.StatusInternalServerError,
+	apperr.KindBadRequest:   http.StatusBadRequest,
+	apperr.KindValidation:   http.StatusUnprocessableEntity,
+	apperr.KindUnauthorized: http.StatusUnauthorized,
+	apperr.KindForbidden:    http.StatusForbidden,
+	apperr.KindNotFound:     http.StatusNotFound,
+	apperr.KindConflict:     http.StatusConflict,
+	apperr.KindRateLimited:  http.StatusTooManyRequests,
+	apperr.KindTooLarge:     http.StatusRequestEntityTooLarge,
+}
+
+// ErrorEnvelope is a body of every error response.
+type ErrorEnvelope struct {
+	// Error is a human readable error message.
+	Error string `json:"error"`
+	// Kind is a category of the error, e.g. "not_found".
+	Kind string `json:"kind"`
+	// Code is a stable machine readable error code, e.g. "recipe_not_found".
+	Code string `json:"code"`
+	// Fields lists invalid fields of the request, set for validation errors only.
+	Fields validate.Errors `json:"fields,omitempty"`
+}
+
+// ErrorRecorder is implemented by response writers of logging middleware,
+// it receives errors which are not exposed to clients so that they are logged along with the request.
+type ErrorRecorder interface {
+	RecordError(err error)
+}
+
+// JSON replies to request with specific code and value encoded as json.
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		recordError(w, err)
+	}
+}
+
+// NoContent replies to request with 204 and empty body.
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Error replies to request with error envelope, status and code are defined by kind of the error.
+// Details of internal errors are recorded for the request log instead of being exposed to clients.
+func Error(w http.ResponseWriter, err error) {
+	domainErr, ok := apperr.Find(err)
+	if !ok {
+		recordError(w, err)
+		JSON(w, http.StatusInternalServerError, ErrorEnvelope{
+			Error: http.StatusText(http.StatusInternalServerError),
+			Kind:  string(apperr.KindInternal),
+			Code:  string(apperr.KindInternal),
+		})
+		return
+	}
+
+	envelope := ErrorEnvelope{
+		Error: err.Error(),
+		Kind:  string(domainErr.Kind),
+		Code:  domainErr.Code,
+	}
+	errors.As(err, &envelope.Fields)
+
+	JSON(w, Status(domainErr.Kind), envelope)
+}
+
+// ErrorStatus replies to request with error envelope of http level error which has no domain kind,
+// e.g. unsupported method.
+func ErrorStatus(w http.ResponseWriter, status int, err error) {
+	code := Code(status)
+	JSON(w, status, ErrorEnvelope{
+		Error: err.Error(),
+		Kind:  code,
+		Code:  code,
+	})
+}
+
+// Status returns http status of the error kind.
+func Status(kind apperr.Kind) int {
+	if status, ok := statuses[kind]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Code returns machine readable code of http status, e.g. "not_found" for 404.
+func Code(status int) string {
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
+
+// recordError passes err to the request log if response writer supports it.
+func recordError(w http.ResponseWriter, err error) {
+	if recorder, ok := w.(ErrorRecorder); ok {
+		recorder.RecordError(err)
+	}
+}
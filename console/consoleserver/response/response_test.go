@@ -0,0 +1,157 @@
+package response_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/validate"
+)
+
+func TestStatus(t *testing.T) {
+	for _, test := range []struct {
+		kind   apperr.Kind
+		status int
+	}{
+		{apperr.KindInternal, http.StatusInternalServerError},
+		{apperr.KindBadRequest, http.StatusBadRequest},
+		{apperr.KindValidation, http.StatusUnprocessableEntity},
+		{apperr.KindUnauthorized, http.StatusUnauthorized},
+		{apperr.KindForbidden, http.StatusForbidden},
+		{apperr.KindNotFound, http.StatusNotFound},
+		{apperr.KindConflict, http.StatusConflict},
+		{apperr.KindRateLimited, http.StatusTooManyRequests},
+		{apperr.KindTooLarge, http.StatusRequestEntityTooLarge},
+		{apperr.Kind("unknown"), http.StatusInternalServerError},
+	} {
+		if status := response.Status(test.kind); status != test.status {
+			t.Fatalf("%s: expected status %d, got %d", test.kind, test.status, status)
+		}
+	}
+}
+
+// recorder is a response recorder which keeps errors hidden from clients.
+type recorder struct {
+	*httptest.ResponseRecorder
+	errs []error
+}
+
+func (r *recorder) RecordError(err error) { r.errs = append(r.errs, err) }
+
+func TestError(t *testing.T) {
+	errNoRecipe := apperr.NewClass(apperr.KindNotFound, "recipe_not_found", "recipe not found")
+	errController := errs.Class("controller error")
+	internal := errors.New("connection refused")
+
+	for _, test := range []struct {
+		name     string
+		err      error
+		status   int
+		envelope map[string]interface{}
+		recorded bool
+	}{
+		{
+			name:   "internal",
+			err:    errController.Wrap(internal),
+			status: http.StatusInternalServerError,
+			envelope: map[string]interface{}{
+				"error": "Internal Server Error",
+				"kind":  "internal",
+				"code":  "internal",
+			},
+			recorded: true,
+		},
+		{
+			name:   "domain",
+			err:    errController.Wrap(errNoRecipe.New("")),
+			status: http.StatusNotFound,
+			envelope: map[string]interface{}{
+				"error": "controller error: recipe not found",
+				"kind":  "not_found",
+				"code":  "recipe_not_found",
+			},
+		},
+		{
+			name:   "validation",
+			err:    apperr.ErrValidation.Wrap(validate.Errors{{Field: "title", Message: "is required"}}),
+			status: http.StatusUnprocessableEntity,
+			envelope: map[string]interface{}{
+				"error":  "validation failed: title is required",
+				"kind":   "validation",
+				"code":   "validation_failed",
+				"fields": []interface{}{map[string]interface{}{"field": "title", "message": "is required"}},
+			},
+		},
+	} {
+		w := &recorder{ResponseRecorder: httptest.NewRecorder()}
+		response.Error(w, test.err)
+
+		if w.Code != test.status {
+			t.Fatalf("%s: expected status %d, got %d", test.name, test.status, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+			t.Fatalf("%s: unexpected content type %q", test.name, contentType)
+		}
+
+		var envelope map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(envelope, test.envelope) {
+			t.Fatalf("%s: expected envelope %v, got %v", test.name, test.envelope, envelope)
+		}
+
+		if test.recorded != (len(w.errs) == 1) || (test.recorded && !errors.Is(w.errs[0], internal)) {
+			t.Fatalf("%s: unexpected recorded errors %v", test.name, w.errs)
+		}
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	w := httptest.NewRecorder()
+	response.ErrorStatus(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+
+	var envelope response.ErrorEnvelope
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusMethodNotAllowed || envelope.Kind != "method_not_allowed" || envelope.Code != "method_not_allowed" {
+		t.Fatalf("unexpected response %d %+v", w.Code, envelope)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, test := range []struct {
+		body string
+		kind apperr.Kind
+	}{
+		{`{"title": "Borsch"}`, ""},
+		{`{"title": `, apperr.KindBadRequest},
+		{`{"title": 1}`, apperr.KindBadRequest},
+		{`{"title": "` + strings.Repeat("a", 64) + `"}`, apperr.KindTooLarge},
+	} {
+		var request struct {
+			Title string `json:"title"`
+		}
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+
+		err := response.Decode(httptest.NewRecorder(), r, 32, &request)
+		if test.kind == "" {
+			if err != nil || request.Title != "Borsch" {
+				t.Fatalf("%s: unexpected result %+v %v", test.body, request, err)
+			}
+			continue
+		}
+		if kind := apperr.KindOf(err); kind != test.kind {
+			t.Fatalf("%s: expected kind %s, got %s: %v", test.body, test.kind, kind, err)
+		}
+	}
+}
@@ -4,17 +4,32 @@ import (
 	"context"
 	"errors"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/cors"
 	"github.com/zeebo/errs"
 	"golang.org/x/sync/errgroup"
 	"html/template"
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/audit"
+	apikeys_controller "kitchen_nerd/console/consoleserver/controllers/apikeys"
+	audit_controller "kitchen_nerd/console/consoleserver/controllers/audit"
 	"kitchen_nerd/console/consoleserver/controllers/auth"
 	recipes_controller "kitchen_nerd/console/consoleserver/controllers/recipes"
 	users_controller "kitchen_nerd/console/consoleserver/controllers/users"
+	"kitchen_nerd/console/consoleserver/openapi"
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/health"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/ratelimit"
 	"kitchen_nerd/recipes"
+	"kitchen_nerd/tokens"
 	"net"
 	"net/http"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"kitchen_nerd/users"
 )
@@ -26,21 +41,41 @@ var (
 
 // Config contains configuration for console web server.
 type Config struct {
-	DatabaseURL    string `env:"DATABASE_URL,notEmpty"`
-	ServerAddress  string `env:"SERVER_ADDRESS,notEmpty"`
-	StaticDir      string `env:"STATIC_DIR,notEmpty"`
-	ExportDataPath string `env:"EXPORT_DATA_PATH,notEmpty"`
+	StaticDir string
+
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are passed to http.Server as is.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// ShutdownDelay is time server keeps serving with failing readiness before shutdown,
+	// so load balancers stop routing new requests to it.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout limits time of draining in-flight requests, remaining connections are closed after it.
+	ShutdownTimeout time.Duration
+
+	// TrustForwardedFor takes client address from the last X-Forwarded-For entry, which is added by
+	// a reverse proxy. It must be enabled only behind a proxy, otherwise clients can set any address.
+	TrustForwardedFor bool
+	RateLimits        RateLimits
 }
 
 // Server represents console web server.
 //
 // architecture: Endpoint
 type Server struct {
+	log    logger.Logger
 	config Config
 
 	listener net.Listener
 	server   http.Server
 
+	// shuttingDown fails readiness checks once server starts to shut down.
+	shuttingDown atomic.Bool
+
+	limiter rateLimiter
+
 	templates struct {
 		auth    auth.Templates
 		recipes recipes_controller.Templates
@@ -49,10 +84,12 @@ type Server struct {
 }
 
 // NewServer is a constructor for console web server.
-func NewServer(config Config, listener net.Listener, users *users.Service, recipes *recipes.Service) (*Server, error) {
+func NewServer(log logger.Logger, registry prometheus.Registerer, checker *health.Checker, config Config, listener net.Listener, usersService *users.Service, recipesService *recipes.Service, tokensService *tokens.Service, apiKeysService *apikeys.Service, auditService *audit.Service, rateLimitService *ratelimit.Service) (*Server, error) {
 	server := &Server{
+		log:      log,
 		config:   config,
 		listener: listener,
+		limiter:  rateLimiter{log: log, service: rateLimitService},
 	}
 
 	err := server.initializeTemplates()
@@ -60,33 +97,175 @@ func NewServer(config Config, listener net.Listener, users *users.Service, recip
 		return nil, err
 	}
 
-	authController := auth.NewAuth(users, server.templates.auth)
-	recipesController := recipes_controller.NewRecipes(recipes, server.templates.recipes)
-	usersController := users_controller.NewUsers(users, server.templates.users)
+	authController := auth.NewAuth(usersService, tokensService, apiKeysService, server.templates.auth)
+	recipesController := recipes_controller.NewRecipes(recipesService, server.templates.recipes)
+	usersController := users_controller.NewUsers(usersService, server.templates.users)
+	apiKeysController := apikeys_controller.NewAPIKeys(apiKeysService)
+	auditController := audit_controller.NewAudit(auditService)
+	recipesAPIController := recipes_controller.NewRecipesAPI(recipesService)
+	limit := server.limiter.limit
+	httpMetrics := newHTTPMetrics(registry)
 	router := mux.NewRouter()
+	router.NotFoundHandler = httpMetrics.unmatched(http.NotFoundHandler())
+	router.MethodNotAllowedHandler = httpMetrics.unmatched(http.HandlerFunc(methodNotAllowed))
+	router.Use(httpMetrics.middleware)
 	router.Use(cors.AllowAll().Handler)
+	router.Use(server.requestInfoMiddleware)
 
 	authRouter := router.PathPrefix("/auth").Subrouter()
-	authRouter.HandleFunc("/login", authController.Login).Methods(http.MethodGet, http.MethodPost)
-	authRouter.HandleFunc("/register", authController.Register).Methods(http.MethodGet, http.MethodPost)
+	// only submitted forms spend auth budget, rendering them doesn't.
+	authRouter.HandleFunc("/login", authController.Login).Methods(http.MethodGet)
+	authRouter.HandleFunc("/login", limit(config.RateLimits.Auth, authController.Login)).Methods(http.MethodPost)
+	authRouter.HandleFunc("/register", authController.Register).Methods(http.MethodGet)
+	authRouter.HandleFunc("/register", limit(config.RateLimits.Auth, authController.Register)).Methods(http.MethodPost)
+	authRouter.Handle("/logout", authController.AuthMiddleware(http.HandlerFunc(authController.Logout))).Methods(http.MethodPost)
 
 	recipesRouter := router.PathPrefix("/recipes").Subrouter()
 	recipesRouter.Use(authController.AuthMiddleware)
-	recipesRouter.HandleFunc("/list", recipesController.List).Methods(http.MethodGet, http.MethodPost)
-	recipesRouter.HandleFunc("/id/{id}", recipesController.Get).Methods(http.MethodGet, http.MethodPost)
+	recipesRouter.HandleFunc("/list", limit(config.RateLimits.Read, recipesController.List)).Methods(http.MethodGet, http.MethodPost)
+	recipesRouter.HandleFunc("/id/{id}", limit(config.RateLimits.Read, recipesController.Get)).Methods(http.MethodGet, http.MethodPost)
 
 	usersRouter := router.Path("/users").Subrouter()
 	usersRouter.HandleFunc("/{id}", usersController.Profile).Methods(http.MethodGet, http.MethodPost)
-	recipesRouter.Use()
-	//adminRecipesRouter := router.PathPrefix("/admin-recipes")
-	recipesRouter.HandleFunc("/add", recipesController.Create).Methods(http.MethodGet, http.MethodPost)
+	recipesRouter.HandleFunc("/add", recipesController.Create).Methods(http.MethodGet)
+	// pages change state for authenticated admins only, so requests without session cookie can't skip csrf check.
+	adminRecipesRouter := recipesRouter.NewRoute().Subrouter()
+	adminRecipesRouter.Use(authController.RequireAdmin)
+	adminRecipesRouter.HandleFunc("/add", limit(config.RateLimits.Write, authController.RequireScope(apikeys.ScopeWrite, recipesController.Create))).Methods(http.MethodPost)
 	//recipesRouter.HandleFunc("/id/{id}/update", recipesController.Update).Methods(http.MethodGet, http.MethodPost)
-	recipesRouter.HandleFunc("/id/{id}/delete", recipesController.Delete).Methods(http.MethodPost)
+	adminRecipesRouter.HandleFunc("/id/{id}/delete", limit(config.RateLimits.Write, authController.RequireScope(apikeys.ScopeWrite, recipesController.Delete))).Methods(http.MethodPost)
+
+	apiKeysRouter := router.PathPrefix("/api-keys").Subrouter()
+	apiKeysRouter.Use(authController.AuthMiddleware)
+	apiKeysRouter.HandleFunc("", apiKeysController.List).Methods(http.MethodGet)
+	apiKeysRouter.HandleFunc("", apiKeysController.Create).Methods(http.MethodPost)
+	apiKeysRouter.HandleFunc("/{id}", apiKeysController.Revoke).Methods(http.MethodDelete)
+	adminRouter := router.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(authController.AuthMiddleware, authController.RequireAdmin)
+	adminRouter.HandleFunc("/audit", auditController.List).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/users", usersController.List).Methods(http.MethodGet)
+
+	docs := openapi.NewDocument("Kitchen nerd API", "1.0.0", response.ErrorEnvelope{})
+	apiRouter := router.PathPrefix("/api/v1").Subrouter()
+	apiRouter.NotFoundHandler = httpMetrics.unmatched(http.HandlerFunc(apiNotFound))
+	apiRouter.MethodNotAllowedHandler = httpMetrics.unmatched(http.HandlerFunc(apiMethodNotAllowed))
+	docs.Describe(apiRouter.HandleFunc("/users", limit(config.RateLimits.Auth, authController.CreateUser)).Methods(http.MethodPost), openapi.Operation{
+		Summary: "Register a new user account",
+		Request: auth.RegistrationRequest{},
+		Status:  http.StatusCreated,
+	})
+	docs.Describe(apiRouter.HandleFunc("/sessions", limit(config.RateLimits.Auth, authController.CreateSession)).Methods(http.MethodPost), openapi.Operation{
+		Summary:  "Log in, the session token is also set in cookies",
+		Request:  auth.LoginRequest{},
+		Response: tokens.UserToken{},
+		Status:   http.StatusCreated,
+	})
+
+	authenticatedAPIRouter := apiRouter.NewRoute().Subrouter()
+	authenticatedAPIRouter.Use(authController.AuthMiddleware)
+	docs.Describe(authenticatedAPIRouter.HandleFunc("/sessions/current", authController.DeleteSession).Methods(http.MethodDelete), openapi.Operation{
+		Summary: "Log out of the current session",
+		Status:  http.StatusNoContent,
+		Auth:    true,
+	})
+	docs.Describe(authenticatedAPIRouter.HandleFunc("/users/{id}", usersController.Get).Methods(http.MethodGet), openapi.Operation{
+		Summary:  "Get user's profile",
+		Response: users.Profile{},
+	})
+	docs.Describe(authenticatedAPIRouter.HandleFunc("/recipes", limit(config.RateLimits.Read, recipesAPIController.List)).Methods(http.MethodGet), openapi.Operation{
+		Summary:  "List recipes",
+		Response: recipes_controller.ListResponse{},
+		Query:    params(paginationParams, []openapi.Parameter{cursorParam}, listQueryParams),
+	})
+	docs.Describe(authenticatedAPIRouter.HandleFunc("/recipes/{id}", limit(config.RateLimits.Read, recipesAPIController.Get)).Methods(http.MethodGet), openapi.Operation{
+		Summary:  "Get recipe",
+		Response: recipes.Recipe{},
+	})
+	docs.Describe(authenticatedAPIRouter.HandleFunc("/api-keys", apiKeysController.List).Methods(http.MethodGet), openapi.Operation{
+		Summary:  "List api keys of the user",
+		Response: apikeys_controller.ListResponse{},
+		Auth:     true,
+	})
+	docs.Describe(authenticatedAPIRouter.HandleFunc("/api-keys", apiKeysController.Create).Methods(http.MethodPost), openapi.Operation{
+		Summary:  "Create api key, its value is returned only once",
+		Request:  apikeys_controller.CreateRequest{},
+		Response: apikeys_controller.CreateResponse{},
+		Status:   http.StatusCreated,
+		Auth:     true,
+	})
+	docs.Describe(authenticatedAPIRouter.HandleFunc("/api-keys/{id}", apiKeysController.Revoke).Methods(http.MethodDelete), openapi.Operation{
+		Summary: "Revoke api key",
+		Status:  http.StatusNoContent,
+		Auth:    true,
+	})
+
+	recipesWriteAPIRouter := authenticatedAPIRouter.NewRoute().Subrouter()
+	recipesWriteAPIRouter.Use(authController.RequireAdmin)
+	docs.Describe(recipesWriteAPIRouter.HandleFunc("/recipes", limit(config.RateLimits.Write, authController.RequireScope(apikeys.ScopeWrite, recipesAPIController.Create))).Methods(http.MethodPost), openapi.Operation{
+		Summary:  "Create recipe",
+		Request:  recipes_controller.RecipeRequest{},
+		Response: recipes.Recipe{},
+		Status:   http.StatusCreated,
+		Auth:     true,
+	})
+	docs.Describe(recipesWriteAPIRouter.HandleFunc("/recipes/{id}", limit(config.RateLimits.Write, authController.RequireScope(apikeys.ScopeWrite, recipesAPIController.Replace))).Methods(http.MethodPut), openapi.Operation{
+		Summary:  "Replace recipe along with its ingredients",
+		Request:  recipes_controller.RecipeRequest{},
+		Response: recipes.Recipe{},
+		Auth:     true,
+	})
+	docs.Describe(recipesWriteAPIRouter.HandleFunc("/recipes/{id}", limit(config.RateLimits.Write, authController.RequireScope(apikeys.ScopeWrite, recipesAPIController.Patch))).Methods(http.MethodPatch), openapi.Operation{
+		Summary:  "Change given fields of recipe",
+		Request:  recipes.Patch{},
+		Response: recipes.Recipe{},
+		Auth:     true,
+	})
+	docs.Describe(recipesWriteAPIRouter.HandleFunc("/recipes/{id}", limit(config.RateLimits.Write, authController.RequireScope(apikeys.ScopeWrite, recipesAPIController.Delete))).Methods(http.MethodDelete), openapi.Operation{
+		Summary: "Delete recipe",
+		Status:  http.StatusNoContent,
+		Auth:    true,
+	})
+
+	adminAPIRouter := authenticatedAPIRouter.PathPrefix("/admin").Subrouter()
+	adminAPIRouter.Use(authController.RequireAdmin)
+	docs.Describe(adminAPIRouter.HandleFunc("/audit", auditController.List).Methods(http.MethodGet), openapi.Operation{
+		Summary:  "List audit log events",
+		Response: audit_controller.ListResponse{},
+		Query:    params(paginationParams, auditFilterParams),
+		Auth:     true,
+	})
+	docs.Describe(adminAPIRouter.HandleFunc("/users", usersController.List).Methods(http.MethodGet), openapi.Operation{
+		Summary:  "List user accounts",
+		Response: users_controller.ListResponse{},
+		Query:    params(paginationParams, listQueryParams),
+		Auth:     true,
+	})
+
+	spec, err := docs.Spec(router, "/api/v1")
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	specHandler, err := openapi.SpecHandler(spec)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	router.HandleFunc("/api/openapi.json", specHandler).Methods(http.MethodGet)
+	router.HandleFunc("/api/docs", openapi.DocsHandler("/api/openapi.json")).Methods(http.MethodGet)
+
+	checker.Add("templates", server.checkTemplates)
+	checker.Add("server", server.checkShutdown)
+	router.HandleFunc("/healthz", health.LivenessHandler).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", checker.ReadinessHandler).Methods(http.MethodGet)
+
 	web := http.FileServer(http.Dir(server.config.StaticDir))
 	router.PathPrefix("/web/").Handler(http.StripPrefix("/web/", web))
 
 	server.server = http.Server{
-		Handler: router,
+		Handler:           server.loggingMiddleware(router),
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
 	}
 
 	return server, nil
@@ -98,7 +277,7 @@ func (server *Server) Run(ctx context.Context) (err error) {
 	var group errgroup.Group
 	group.Go(func() error {
 		<-ctx.Done()
-		return Error.Wrap(server.server.Shutdown(context.Background()))
+		return server.shutdown()
 	})
 	group.Go(func() error {
 		defer cancel()
@@ -113,11 +292,72 @@ func (server *Server) Run(ctx context.Context) (err error) {
 	return Error.Wrap(group.Wait())
 }
 
+// shutdown fails readiness, waits for the shutdown delay and drains in-flight requests.
+// Connections which are still active after shutdown timeout are closed.
+func (server *Server) shutdown() error {
+	server.shuttingDown.Store(true)
+
+	if server.config.ShutdownDelay > 0 {
+		server.log.Info("console server is failing readiness before shutdown", logger.Duration("delay", server.config.ShutdownDelay))
+		time.Sleep(server.config.ShutdownDelay)
+	}
+
+	ctx := context.Background()
+	if server.config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, server.config.ShutdownTimeout)
+		defer cancel()
+	}
+
+	server.log.Info("console server is draining in-flight requests", logger.Duration("timeout", server.config.ShutdownTimeout))
+	err := server.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		server.log.Warn("console server shutdown timed out, closing remaining connections")
+		return Error.Wrap(server.server.Close())
+	}
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	server.log.Info("console server stopped")
+	return nil
+}
+
+// Handler returns http handler of the server.
+func (server *Server) Handler() http.Handler {
+	return server.server.Handler
+}
+
 // Close closes server and underlying listener.
 func (server *Server) Close() error {
 	return Error.Wrap(server.server.Close())
 }
 
+// checkTemplates checks that all templates are loaded.
+func (server *Server) checkTemplates(context.Context) error {
+	for name, tmpl := range map[string]*template.Template{
+		"auth/register.html": server.templates.auth.Register,
+		"auth/login.html":    server.templates.auth.Login,
+		"admins/create.html": server.templates.recipes.Create,
+		"index.html":         server.templates.recipes.List,
+	} {
+		if tmpl == nil {
+			return Error.New("template %s is not loaded", name)
+		}
+	}
+
+	return nil
+}
+
+// checkShutdown fails once server starts to shut down, so no new requests are routed to it.
+func (server *Server) checkShutdown(context.Context) error {
+	if server.shuttingDown.Load() {
+		return Error.New("server is shutting down")
+	}
+
+	return nil
+}
+
 // initializeTemplates initializes and caches templates for managers controller.
 func (server *Server) initializeTemplates() (err error) {
 	server.templates.auth.Register, err = template.ParseFiles(filepath.Join(server.config.StaticDir, "auth", "register.html"))
@@ -141,6 +381,44 @@ func (server *Server) initializeTemplates() (err error) {
 	return nil
 }
 
+// requestInfoMiddleware puts client address and user agent in the request context for the audit log and rate limits.
+func (server *Server) requestInfoMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		if forwarded := r.Header.Values("X-Forwarded-For"); server.config.TrustForwardedFor && len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if last := strings.TrimSpace(entries[len(entries)-1]); last != "" {
+				ip = last
+			}
+		}
+
+		ctx := audit.WithRequestInfo(r.Context(), audit.RequestInfo{
+			IP:        ip,
+			UserAgent: r.UserAgent(),
+		})
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// methodNotAllowed replies to requests with a method route doesn't support, the way router does by default.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusMethodNotAllowed)
+}
+
+// apiNotFound replies to api requests of unknown resources.
+func apiNotFound(w http.ResponseWriter, r *http.Request) {
+	response.Error(w, apperr.ErrNotFound.New("no resource at %s", r.URL.Path))
+}
+
+// apiMethodNotAllowed replies to api requests with a method resource doesn't support.
+func apiMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	response.ErrorStatus(w, http.StatusMethodNotAllowed, Error.New("method %s is not allowed at %s", r.Method, r.URL.Path))
+}
+
 // appHandler is web app http handler function.
 func (server *Server) appHandler(w http.ResponseWriter, r *http.Request) {
 	header := w.Header()
@@ -0,0 +1,681 @@
+package consoleserver_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/console/consoleserver"
+	apikeys_controller "kitchen_nerd/console/consoleserver/controllers/apikeys"
+	"kitchen_nerd/console/consoleserver/openapi"
+	"kitchen_nerd/console/consoleserver/response"
+	"kitchen_nerd/database/memory"
+	"kitchen_nerd/pkg/health"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/pkg/metrics"
+	"kitchen_nerd/ratelimit"
+	"kitchen_nerd/recipes"
+	"kitchen_nerd/tokens"
+	"kitchen_nerd/users"
+)
+
+// testServer is console server under test along with users service, which manages accounts directly.
+type testServer struct {
+	*httptest.Server
+	users *users.Service
+}
+
+// newTestServer runs console server backed by in-memory database, http metrics are registered in the registry.
+func newTestServer(t *testing.T, registry prometheus.Registerer) *testServer {
+	return newLimitedTestServer(t, registry, consoleserver.RateLimits{})
+}
+
+// newLimitedTestServer runs console server which limits rate of requests by given policies.
+func newLimitedTestServer(t *testing.T, registry prometheus.Registerer, limits consoleserver.RateLimits) *testServer {
+	server, usersService := newConsoleServer(t, registry, consoleserver.Config{StaticDir: "../../web", RateLimits: limits})
+
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+
+	return &testServer{Server: httpServer, users: usersService}
+}
+
+// newConsoleServer creates console server backed by in-memory database, which listens on a local port.
+func newConsoleServer(t *testing.T, registry prometheus.Registerer, config consoleserver.Config) (*consoleserver.Server, *users.Service) {
+	db := memory.New()
+
+	auditService := audit.NewService(logger.NewNop(), db.Audit())
+	tokensService := tokens.NewService(logger.NewNop(), tokens.Config{TokenExpirationTime: time.Hour, Mode: tokens.ModeOpaque}, db.Tokens())
+	usersService := users.NewService(logger.NewNop(), users.Config{
+		Argon2: users.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1},
+	}, db.Users(), tokensService, auditService)
+	recipesService := recipes.NewService(db.Recipes(), auditService)
+	apiKeysService := apikeys.NewService(db.APIKeys(), auditService)
+	rateLimitService := ratelimit.NewService(logger.NewNop(), db.RateLimits(), config.RateLimits.Auth, config.RateLimits.Read, config.RateLimits.Write)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server, err := consoleserver.NewServer(logger.NewNop(), registry, health.NewChecker(), config, listener, usersService, recipesService, tokensService, apiKeysService, auditService, rateLimitService)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, usersService
+}
+
+// do sends json request and decodes json response into out, returns response status.
+func do(t *testing.T, server *testServer, method, path, token string, body, out interface{}) int {
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request, err := http.NewRequest(method, server.URL+path, &reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+// login registers a user with such email and returns token of a new session.
+func login(t *testing.T, server *testServer, email string) string {
+	t.Helper()
+
+	register(t, server, email)
+	return createSession(t, server, email)
+}
+
+// loginAdmin registers an administrator with such email and returns token of a new session.
+func loginAdmin(t *testing.T, server *testServer, email string) string {
+	t.Helper()
+
+	register(t, server, email)
+	if _, err := server.users.SetStatus(context.Background(), email, users.StatusAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	return createSession(t, server, email)
+}
+
+// register registers a user with such email.
+func register(t *testing.T, server *testServer, email string) {
+	t.Helper()
+
+	registration := map[string]string{
+		"email":            email,
+		"username":         "Cook",
+		"password":         "Borsch-1234",
+		"repeatedPassword": "Borsch-1234",
+	}
+	expectStatus(t, "register", do(t, server, http.MethodPost, "/api/v1/users", "", registration, nil), http.StatusCreated)
+}
+
+// createSession logs in as the user with such email and returns token of a new session.
+func createSession(t *testing.T, server *testServer, email string) string {
+	t.Helper()
+
+	var session tokens.UserToken
+	credentials := map[string]string{"email": email, "password": "Borsch-1234"}
+	expectStatus(t, "login", do(t, server, http.MethodPost, "/api/v1/sessions", "", credentials, &session), http.StatusCreated)
+
+	return session.Token
+}
+
+func expectStatus(t *testing.T, name string, got, want int) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("%s: got status %d, want %d", name, got, want)
+	}
+}
+
+func TestAPIRecipes(t *testing.T) {
+	server := newTestServer(t, metrics.NewRegistry())
+
+	registration := map[string]string{
+		"email":            "cook@example.com",
+		"username":         "Cook",
+		"password":         "Borsch-1234",
+		"repeatedPassword": "Borsch-1234",
+	}
+	expectStatus(t, "register", do(t, server, http.MethodPost, "/api/v1/users", "", registration, nil), http.StatusCreated)
+
+	var envelope response.ErrorEnvelope
+	expectStatus(t, "register twice", do(t, server, http.MethodPost, "/api/v1/users", "", registration, &envelope), http.StatusConflict)
+	if envelope.Kind != "conflict" || envelope.Code != "email_in_use" || envelope.Error == "" {
+		t.Fatalf("unexpected error envelope %+v", envelope)
+	}
+
+	wrongCredentials := map[string]string{"email": "cook@example.com", "password": "Borsch-4321"}
+	expectStatus(t, "wrong password", do(t, server, http.MethodPost, "/api/v1/sessions", "", wrongCredentials, &envelope), http.StatusUnauthorized)
+	if envelope.Code != "wrong_credentials" {
+		t.Fatalf("unexpected error envelope %+v", envelope)
+	}
+
+	// only administrators write recipes.
+	if _, err := server.users.SetStatus(context.Background(), "cook@example.com", users.StatusAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	var session tokens.UserToken
+	credentials := map[string]string{"email": "cook@example.com", "password": "Borsch-1234"}
+	expectStatus(t, "login", do(t, server, http.MethodPost, "/api/v1/sessions", "", credentials, &session), http.StatusCreated)
+
+	recipe := map[string]interface{}{"title": "Borsch", "description": "Beet soup"}
+	expectStatus(t, "anonymous create", do(t, server, http.MethodPost, "/api/v1/recipes", "", recipe, &envelope), http.StatusUnauthorized)
+	reader := login(t, server, "reader@example.com")
+	expectStatus(t, "regular user create", do(t, server, http.MethodPost, "/api/v1/recipes", reader, recipe, &envelope), http.StatusForbidden)
+	if envelope.Kind != "forbidden" {
+		t.Fatalf("unexpected error envelope %+v", envelope)
+	}
+
+	var created recipes.Recipe
+	expectStatus(t, "create", do(t, server, http.MethodPost, "/api/v1/recipes", session.Token, recipe, &created), http.StatusCreated)
+
+	path := "/api/v1/recipes/" + created.ID.String()
+	var got recipes.Recipe
+	expectStatus(t, "get", do(t, server, http.MethodGet, path, "", nil, &got), http.StatusOK)
+	if got.Title != "Borsch" {
+		t.Fatalf("unexpected recipe %+v", got)
+	}
+
+	expectStatus(t, "patch", do(t, server, http.MethodPatch, path, session.Token, map[string]string{"title": "Green borsch"}, &got), http.StatusOK)
+	if got.Title != "Green borsch" || got.Description != "Beet soup" {
+		t.Fatalf("unexpected patched recipe %+v", got)
+	}
+
+	replacement := map[string]interface{}{
+		"title":       "Cold borsch",
+		"ingredients": []map[string]interface{}{{"name": "beet", "quantity": 2, "unit": "piece"}},
+	}
+	expectStatus(t, "replace", do(t, server, http.MethodPut, path, session.Token, replacement, &got), http.StatusOK)
+	if got.Title != "Cold borsch" || got.Description != "" || len(got.Ingredients) != 1 {
+		t.Fatalf("unexpected replaced recipe %+v", got)
+	}
+	got = recipes.Recipe{}
+	expectStatus(t, "get replaced", do(t, server, http.MethodGet, path, "", nil, &got), http.StatusOK)
+	if got.Title != "Cold borsch" || got.Description != "" || len(got.Ingredients) != 1 || got.Ingredients[0].Name != "beet" {
+		t.Fatalf("unexpected replaced recipe %+v", got)
+	}
+
+	var list struct {
+		Recipes []recipes.Recipe `json:"recipes"`
+	}
+	expectStatus(t, "list", do(t, server, http.MethodGet, "/api/v1/recipes?sort=title", "", nil, &list), http.StatusOK)
+	if len(list.Recipes) != 1 || len(list.Recipes[0].Ingredients) != 1 {
+		t.Fatalf("unexpected recipes %+v", list.Recipes)
+	}
+
+	expectStatus(t, "delete", do(t, server, http.MethodDelete, path, session.Token, nil, nil), http.StatusNoContent)
+	expectStatus(t, "get deleted", do(t, server, http.MethodGet, path, "", nil, &envelope), http.StatusNotFound)
+	if envelope.Kind != "not_found" || envelope.Code != "recipe_not_found" {
+		t.Fatalf("unexpected error envelope %+v", envelope)
+	}
+
+	expectStatus(t, "invalid id", do(t, server, http.MethodGet, "/api/v1/recipes/borsch", "", nil, &envelope), http.StatusBadRequest)
+	expectStatus(t, "unknown resource", do(t, server, http.MethodGet, "/api/v1/menus", "", nil, &envelope), http.StatusNotFound)
+	invalid := map[string]interface{}{
+		"ingredients": []map[string]interface{}{
+			{"name": "beet", "quantity": 2, "unit": "piece"},
+			{"name": "salt", "quantity": -1, "unit": "pinch"},
+		},
+	}
+	expectStatus(t, "invalid recipe", do(t, server, http.MethodPost, "/api/v1/recipes", session.Token, invalid, &envelope), http.StatusUnprocessableEntity)
+	fields := make(map[string]string)
+	for _, field := range envelope.Fields {
+		fields[field.Field] = field.Message
+	}
+	if envelope.Kind != "validation" || len(fields) != 3 || fields["title"] == "" || fields["ingredients[1].quantity"] == "" || fields["ingredients[1].unit"] == "" {
+		t.Fatalf("unexpected error envelope %+v", envelope)
+	}
+	expectStatus(t, "unsupported method", do(t, server, http.MethodPut, "/api/v1/recipes", session.Token, recipe, &envelope), http.StatusMethodNotAllowed)
+	if envelope.Code != "method_not_allowed" {
+		t.Fatalf("unexpected error envelope %+v", envelope)
+	}
+
+	// recipes carry photos, so their size limit is much higher than the one of credentials.
+	photo := map[string]interface{}{"title": "Borsch", "photoBase64": strings.Repeat("A", 1<<20)}
+	expectStatus(t, "create with photo", do(t, server, http.MethodPost, "/api/v1/recipes", session.Token, photo, &created), http.StatusCreated)
+	photo["photoBase64"] = strings.Repeat("A", 9<<20)
+	expectStatus(t, "too large recipe", do(t, server, http.MethodPost, "/api/v1/recipes", session.Token, photo, &envelope), http.StatusRequestEntityTooLarge)
+	if envelope.Kind != "too_large" || envelope.Code != "too_large" {
+		t.Fatalf("unexpected error envelope %+v", envelope)
+	}
+	expectStatus(t, "too large replacement", do(t, server, http.MethodPut, "/api/v1/recipes/"+created.ID.String(), session.Token, photo, &envelope), http.StatusRequestEntityTooLarge)
+	expectStatus(t, "too large patch", do(t, server, http.MethodPatch, "/api/v1/recipes/"+created.ID.String(), session.Token, photo, &envelope), http.StatusRequestEntityTooLarge)
+	longPassword := map[string]string{"email": "cook@example.com", "password": strings.Repeat("a", 8<<10)}
+	expectStatus(t, "too large credentials", do(t, server, http.MethodPost, "/api/v1/sessions", "", longPassword, &envelope), http.StatusRequestEntityTooLarge)
+
+	expectStatus(t, "logout", do(t, server, http.MethodDelete, "/api/v1/sessions/current", session.Token, nil, nil), http.StatusNoContent)
+	expectStatus(t, "create after logout", do(t, server, http.MethodPost, "/api/v1/recipes", session.Token, recipe, &envelope), http.StatusUnauthorized)
+}
+
+func TestAPIKeys(t *testing.T) {
+	server := newTestServer(t, metrics.NewRegistry())
+	session := loginAdmin(t, server, "cook@example.com")
+
+	var readKey, writeKey apikeys_controller.CreateResponse
+	expectStatus(t, "create read key", do(t, server, http.MethodPost, "/api/v1/api-keys", session,
+		apikeys_controller.CreateRequest{Name: "reader", Scope: apikeys.ScopeRead}, &readKey), http.StatusCreated)
+	expectStatus(t, "create write key", do(t, server, http.MethodPost, "/api/v1/api-keys", session,
+		apikeys_controller.CreateRequest{Name: "writer", Scope: apikeys.ScopeWrite}, &writeKey), http.StatusCreated)
+
+	recipe := map[string]interface{}{"title": "Borsch"}
+	var envelope response.ErrorEnvelope
+	expectStatus(t, "list with read key", do(t, server, http.MethodGet, "/api/v1/recipes", readKey.Key, nil, nil), http.StatusOK)
+	expectStatus(t, "create with read key", do(t, server, http.MethodPost, "/api/v1/recipes", readKey.Key, recipe, &envelope), http.StatusForbidden)
+	if envelope.Kind != "forbidden" {
+		t.Fatalf("unexpected error envelope %+v", envelope)
+	}
+	expectStatus(t, "create with write key", do(t, server, http.MethodPost, "/api/v1/recipes", writeKey.Key, recipe, nil), http.StatusCreated)
+
+	// X-API-Key header is an alternative to bearer token.
+	request, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/recipes", strings.NewReader(`{"title": "Pelmeni"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("X-API-Key", readKey.Key)
+	resp, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	expectStatus(t, "create with read key in header", resp.StatusCode, http.StatusForbidden)
+
+	// api keys can't be used to issue or revoke api keys, even with write scope.
+	expectStatus(t, "list keys with key", do(t, server, http.MethodGet, "/api/v1/api-keys", writeKey.Key, nil, nil), http.StatusForbidden)
+	expectStatus(t, "create key with key", do(t, server, http.MethodPost, "/api/v1/api-keys", writeKey.Key,
+		apikeys_controller.CreateRequest{Name: "escalated", Scope: apikeys.ScopeWrite}, nil), http.StatusForbidden)
+	expectStatus(t, "revoke key with key", do(t, server, http.MethodDelete, "/api/v1/api-keys/"+readKey.APIKey.ID.String(), writeKey.Key, nil, nil), http.StatusForbidden)
+
+	expectStatus(t, "revoke", do(t, server, http.MethodDelete, "/api/v1/api-keys/"+readKey.APIKey.ID.String(), session, nil, nil), http.StatusNoContent)
+	expectStatus(t, "list with revoked key", do(t, server, http.MethodGet, "/api/v1/recipes", readKey.Key, nil, &envelope), http.StatusUnauthorized)
+	expectStatus(t, "list with unknown key", do(t, server, http.MethodGet, "/api/v1/recipes", apikeys.KeyPrefix+"unknown", nil, nil), http.StatusUnauthorized)
+
+	var list apikeys_controller.ListResponse
+	expectStatus(t, "list keys", do(t, server, http.MethodGet, "/api/v1/api-keys", session, nil, &list), http.StatusOK)
+	if len(list.APIKeys) != 2 || list.APIKeys[0].Name != "writer" || list.APIKeys[0].LastUsedAt == nil || list.APIKeys[1].RevokedAt == nil {
+		t.Fatalf("unexpected api keys %+v", list.APIKeys)
+	}
+}
+
+func TestCookieSessions(t *testing.T) {
+	server := newTestServer(t, metrics.NewRegistry())
+	bearer := loginAdmin(t, server, "cook@example.com")
+
+	// send makes request with given cookies and headers, returns response status and cookies set by the server.
+	send := func(method, path string, cookies []*http.Cookie, header map[string]string) (int, []*http.Cookie) {
+		t.Helper()
+
+		request, err := http.NewRequest(method, server.URL+path, strings.NewReader(`{"email": "cook@example.com", "password": "Borsch-1234", "title": "Borsch"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+		for name, value := range header {
+			request.Header.Set(name, value)
+		}
+
+		resp, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+
+		return resp.StatusCode, resp.Cookies()
+	}
+
+	status, cookies := send(http.MethodPost, "/api/v1/sessions", nil, nil)
+	expectStatus(t, "login", status, http.StatusCreated)
+
+	var csrfToken string
+	for _, cookie := range cookies {
+		if cookie.Name == "csrf_token" {
+			csrfToken = cookie.Value
+		}
+	}
+	if len(cookies) != 2 || csrfToken == "" {
+		t.Fatalf("unexpected session cookies %v", cookies)
+	}
+
+	status, _ = send(http.MethodPost, "/api/v1/recipes", cookies, nil)
+	expectStatus(t, "cookie without csrf header", status, http.StatusForbidden)
+	status, _ = send(http.MethodPost, "/api/v1/recipes", cookies, map[string]string{"X-CSRF-Token": csrfToken + "x"})
+	expectStatus(t, "cookie with wrong csrf header", status, http.StatusForbidden)
+	status, _ = send(http.MethodPost, "/api/v1/recipes", cookies, map[string]string{"X-CSRF-Token": csrfToken})
+	expectStatus(t, "cookie with csrf header", status, http.StatusCreated)
+
+	// bearer token can't be sent by a browser on its own, so it needs no csrf token even along with cookies.
+	status, _ = send(http.MethodPost, "/api/v1/recipes", cookies, map[string]string{"Authorization": "Bearer " + bearer})
+	expectStatus(t, "bearer", status, http.StatusCreated)
+
+	stale := []*http.Cookie{{Name: "session", Value: "stale"}, {Name: "csrf_token", Value: csrfToken}}
+	status, cookies = send(http.MethodGet, "/api/v1/recipes", stale, nil)
+	expectStatus(t, "stale cookie", status, http.StatusOK)
+	if len(cookies) != 2 || cookies[0].MaxAge >= 0 || cookies[1].MaxAge >= 0 {
+		t.Fatalf("stale session cookies are not cleared %v", cookies)
+	}
+}
+
+func TestRecipePages(t *testing.T) {
+	server := newTestServer(t, metrics.NewRegistry())
+	register(t, server, "cook@example.com")
+	register(t, server, "chef@example.com")
+	if _, err := server.users.SetStatus(context.Background(), "chef@example.com", users.StatusAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	// send makes request with given cookies and csrf header, returns response status and cookies set by the server.
+	send := func(method, path, body string, cookies []*http.Cookie, csrfToken string) (int, []*http.Cookie) {
+		t.Helper()
+
+		request, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+		if csrfToken != "" {
+			request.Header.Set("X-CSRF-Token", csrfToken)
+		}
+
+		resp, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+
+		return resp.StatusCode, resp.Cookies()
+	}
+
+	// sessionCookies logs in and returns session cookies with csrf token.
+	sessionCookies := func(email string) ([]*http.Cookie, string) {
+		t.Helper()
+
+		status, cookies := send(http.MethodPost, "/api/v1/sessions", `{"email": "`+email+`", "password": "Borsch-1234"}`, nil, "")
+		expectStatus(t, "login "+email, status, http.StatusCreated)
+		for _, cookie := range cookies {
+			if cookie.Name == "csrf_token" {
+				return cookies, cookie.Value
+			}
+		}
+
+		t.Fatalf("csrf cookie is not set %v", cookies)
+		return nil, ""
+	}
+
+	recipe := `{"title": "Borsch"}`
+	status, _ := send(http.MethodGet, "/recipes/add", "", nil, "")
+	expectStatus(t, "form", status, http.StatusOK)
+
+	// requests without session cookie are not checked for csrf, so they must not change anything.
+	status, _ = send(http.MethodPost, "/recipes/add", recipe, nil, "")
+	expectStatus(t, "anonymous create", status, http.StatusUnauthorized)
+	status, _ = send(http.MethodPost, "/recipes/id/"+uuid.NewString()+"/delete", "", nil, "")
+	expectStatus(t, "anonymous delete", status, http.StatusUnauthorized)
+	status, _ = send(http.MethodPost, "/recipes/add", recipe, []*http.Cookie{{Name: "session", Value: "stale"}}, "")
+	expectStatus(t, "cookie create without csrf", status, http.StatusForbidden)
+
+	cookies, csrfToken := sessionCookies("cook@example.com")
+	status, _ = send(http.MethodPost, "/recipes/add", recipe, cookies, csrfToken)
+	expectStatus(t, "user create", status, http.StatusForbidden)
+
+	cookies, csrfToken = sessionCookies("chef@example.com")
+	status, _ = send(http.MethodPost, "/recipes/add", recipe, cookies, "")
+	expectStatus(t, "admin create without csrf", status, http.StatusForbidden)
+	status, _ = send(http.MethodPost, "/recipes/add", recipe, cookies, csrfToken)
+	expectStatus(t, "admin create", status, http.StatusOK)
+
+	var list struct {
+		Recipes []recipes.Recipe `json:"recipes"`
+	}
+	expectStatus(t, "list", do(t, server, http.MethodGet, "/api/v1/recipes", "", nil, &list), http.StatusOK)
+	if len(list.Recipes) != 1 {
+		t.Fatalf("unexpected recipes %+v", list.Recipes)
+	}
+
+	path := "/recipes/id/" + list.Recipes[0].ID.String() + "/delete"
+	status, _ = send(http.MethodPost, path, "", cookies, "")
+	expectStatus(t, "admin delete without csrf", status, http.StatusForbidden)
+	status, _ = send(http.MethodPost, path, "", cookies, csrfToken)
+	expectStatus(t, "admin delete", status, http.StatusOK)
+	expectStatus(t, "get deleted", do(t, server, http.MethodGet, "/api/v1/recipes/"+list.Recipes[0].ID.String(), "", nil, nil), http.StatusNotFound)
+}
+
+func TestRateLimits(t *testing.T) {
+	server := newLimitedTestServer(t, metrics.NewRegistry(), consoleserver.RateLimits{
+		Auth: &ratelimit.Policy{Name: "auth", Burst: 3, Period: time.Minute},
+		Read: &ratelimit.Policy{Name: "read", Burst: 1, Period: time.Minute},
+	})
+
+	registration := map[string]string{
+		"email":            "cook@example.com",
+		"username":         "Cook",
+		"password":         "Borsch-1234",
+		"repeatedPassword": "Borsch-1234",
+	}
+	expectStatus(t, "register", do(t, server, http.MethodPost, "/api/v1/users", "", registration, nil), http.StatusCreated)
+
+	var session tokens.UserToken
+	credentials := map[string]string{"email": "cook@example.com", "password": "Borsch-1234"}
+	expectStatus(t, "login", do(t, server, http.MethodPost, "/api/v1/sessions", "", credentials, &session), http.StatusCreated)
+
+	get := func(name, path, token string, status int, remaining, retryAfter string) {
+		t.Helper()
+
+		request, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		// address is not taken from the header unless server is configured to trust it.
+		request.Header.Set("X-Forwarded-For", uuid.NewString())
+
+		resp, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		expectStatus(t, name, resp.StatusCode, status)
+		if resp.Header.Get("X-RateLimit-Remaining") != remaining || resp.Header.Get("Retry-After") != retryAfter ||
+			resp.Header.Get("X-RateLimit-Limit") == "" || resp.Header.Get("X-RateLimit-Reset") == "" {
+			t.Fatalf("%s: unexpected headers %v", name, resp.Header)
+		}
+	}
+
+	get("anonymous list", "/api/v1/recipes", "", http.StatusOK, "0", "")
+	get("anonymous list again", "/api/v1/recipes", "", http.StatusTooManyRequests, "0", "60")
+	// authenticated clients are limited by user, not by address.
+	get("user list", "/api/v1/recipes", session.Token, http.StatusOK, "0", "")
+
+	// rendering auth forms doesn't spend auth budget.
+	for _, path := range []string{"/auth/login", "/auth/register", "/auth/login"} {
+		expectStatus(t, "form "+path, do(t, server, http.MethodGet, path, "", nil, nil), http.StatusOK)
+	}
+
+	var envelope response.ErrorEnvelope
+	expectStatus(t, "last login", do(t, server, http.MethodPost, "/api/v1/sessions", "", credentials, nil), http.StatusCreated)
+	expectStatus(t, "limited login", do(t, server, http.MethodPost, "/api/v1/sessions", "", credentials, &envelope), http.StatusTooManyRequests)
+	if envelope.Kind != "rate_limited" {
+		t.Fatalf("unexpected error envelope %+v", envelope)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	server := newTestServer(t, metrics.NewRegistry())
+
+	var spec openapi.Spec
+	expectStatus(t, "spec", do(t, server, http.MethodGet, "/api/openapi.json", "", nil, &spec), http.StatusOK)
+	if spec.OpenAPI == "" || len(spec.Paths) == 0 {
+		t.Fatalf("unexpected spec %+v", spec)
+	}
+
+	recipe := spec.Paths["/api/v1/recipes/{id}"]
+	for _, method := range []string{"get", "put", "patch", "delete"} {
+		if recipe[method] == nil {
+			t.Fatalf("%s /api/v1/recipes/{id} is not described", method)
+		}
+	}
+	if spec.Components.Schemas["recipes.Recipe"] == nil || spec.Components.Schemas["recipes.RecipeRequest"] == nil {
+		t.Fatal("recipe request schema is missing")
+	}
+
+	resp, err := server.Client().Get(server.URL + "/api/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	expectStatus(t, "docs", resp.StatusCode, http.StatusOK)
+}
+
+func TestRequestID(t *testing.T) {
+	server := newTestServer(t, metrics.NewRegistry())
+
+	requestID := func(id string) string {
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/recipes", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "" {
+			request.Header.Set("X-Request-ID", id)
+		}
+
+		resp, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		return resp.Header.Get("X-Request-ID")
+	}
+
+	if got := requestID("borsch-1"); got != "borsch-1" {
+		t.Fatalf("request id of the client is not propagated, got %q", got)
+	}
+	if got := requestID(""); got == "" {
+		t.Fatal("request id is not assigned")
+	}
+	tooLong := strings.Repeat("borsch", 30)
+	if got := requestID(tooLong); got == tooLong || got == "" {
+		t.Fatalf("invalid request id is not replaced, got %q", got)
+	}
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	server := newTestServer(t, registry)
+
+	expectStatus(t, "get", do(t, server, http.MethodGet, "/api/v1/recipes/"+uuid.NewString(), "", nil, nil), http.StatusNotFound)
+	expectStatus(t, "unknown", do(t, server, http.MethodGet, "/api/v1/menus/borsch", "", nil, nil), http.StatusNotFound)
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler(logger.NewNop(), registry).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	exposition := recorder.Body.String()
+
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="/api/v1/recipes/{id}",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/recipes/{id}"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(exposition, line) {
+			t.Fatalf("metrics don't contain %q:\n%s", line, exposition)
+		}
+	}
+	if strings.Contains(exposition, "menus") {
+		t.Fatalf("unmatched path is used as a label:\n%s", exposition)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	server := newTestServer(t, metrics.NewRegistry())
+
+	var report health.Report
+	expectStatus(t, "liveness", do(t, server, http.MethodGet, "/healthz", "", nil, &report), http.StatusOK)
+	if report.Status != health.StatusOK {
+		t.Fatalf("unexpected liveness report %+v", report)
+	}
+
+	expectStatus(t, "readiness", do(t, server, http.MethodGet, "/readyz", "", nil, &report), http.StatusOK)
+	if report.Status != health.StatusOK || report.Components["templates"].Status != health.StatusOK {
+		t.Fatalf("unexpected readiness report %+v", report)
+	}
+}
+
+func TestShutdownReadiness(t *testing.T) {
+	server, usersService := newConsoleServer(t, metrics.NewRegistry(), consoleserver.Config{StaticDir: "../../web", ShutdownDelay: time.Second})
+	httpServer := &testServer{Server: httptest.NewServer(server.Handler()), users: usersService}
+	t.Cleanup(httpServer.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- server.Run(ctx) }()
+
+	var report health.Report
+	expectStatus(t, "before shutdown", do(t, httpServer, http.MethodGet, "/readyz", "", nil, &report), http.StatusOK)
+	if report.Components["server"].Status != health.StatusOK {
+		t.Fatalf("unexpected readiness report %+v", report)
+	}
+
+	cancel()
+
+	// readiness fails during shutdown delay, while server still serves requests.
+	deadline := time.Now().Add(time.Second)
+	for do(t, httpServer, http.MethodGet, "/readyz", "", nil, &report) != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("readiness doesn't fail after shutdown is requested")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if report.Status != health.StatusFailing || report.Components["server"].Status != health.StatusFailing || report.Components["templates"].Status != health.StatusOK {
+		t.Fatalf("unexpected readiness report %+v", report)
+	}
+	expectStatus(t, "liveness", do(t, httpServer, http.MethodGet, "/healthz", "", nil, nil), http.StatusOK)
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server didn't stop after shutdown delay")
+	}
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/apikeys"
+)
+
+// ensures that apiKeysDB implements apikeys.DB.
+var _ apikeys.DB = (*apiKeysDB)(nil)
+
+// ErrAPIKeys indicates that there was an error in the database.
+var ErrAPIKeys = errs.Class("api keys repository error")
+
+const apiKeyFields = "id, user_id, name, prefix, key_hash, scope, last_used_at, revoked_at, created_at"
+
+// apiKeysDB provides access to api keys db.
+//
+// architecture: Database
+type apiKeysDB struct {
+	pool *pgxpool.Pool
+}
+
+// WithTx runs fn in a transaction.
+func (apiKeysDB *apiKeysDB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, apiKeysDB.pool, fn)
+}
+
+// Create inserts an api key in the database.
+func (apiKeysDB *apiKeysDB) Create(ctx context.Context, key *apikeys.APIKey) error {
+	query := `INSERT INTO api_keys (` + apiKeyFields + `)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := conn(ctx, apiKeysDB.pool).Exec(ctx, query, key.ID, key.UserID, key.Name, key.Prefix, key.Hash, key.Scope,
+		key.LastUsedAt, key.RevokedAt, key.CreatedAt)
+
+	return ErrAPIKeys.Wrap(err)
+}
+
+// GetByHash returns api key by hash of its value.
+func (apiKeysDB *apiKeysDB) GetByHash(ctx context.Context, hash []byte) (*apikeys.APIKey, error) {
+	query := `SELECT ` + apiKeyFields + `
+	          FROM api_keys
+	          WHERE key_hash = $1`
+
+	key, err := scanAPIKey(conn(ctx, apiKeysDB.pool).QueryRow(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apikeys.ErrNoAPIKey.Wrap(err)
+		}
+
+		return nil, ErrAPIKeys.Wrap(err)
+	}
+
+	return key, nil
+}
+
+// ListByUser returns all api keys of the user.
+func (apiKeysDB *apiKeysDB) ListByUser(ctx context.Context, userID uuid.UUID) ([]apikeys.APIKey, error) {
+	query := `SELECT ` + apiKeyFields + `
+	          FROM api_keys
+	          WHERE user_id = $1
+	          ORDER BY created_at DESC`
+
+	rows, err := conn(ctx, apiKeysDB.pool).Query(ctx, query, userID)
+	if err != nil {
+		return nil, ErrAPIKeys.Wrap(err)
+	}
+	defer rows.Close()
+
+	keys := make([]apikeys.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, ErrAPIKeys.Wrap(err)
+		}
+
+		keys = append(keys, *key)
+	}
+
+	return keys, ErrAPIKeys.Wrap(rows.Err())
+}
+
+// Revoke marks user's api key as revoked.
+func (apiKeysDB *apiKeysDB) Revoke(ctx context.Context, userID, id uuid.UUID, revokedAt time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = $3
+	          WHERE user_id = $1 AND id = $2 AND revoked_at IS NULL`
+
+	res, err := conn(ctx, apiKeysDB.pool).Exec(ctx, query, userID, id, revokedAt)
+	if err != nil {
+		return ErrAPIKeys.Wrap(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return apikeys.ErrNoAPIKey.New("")
+	}
+
+	return nil
+}
+
+// UpdateLastUsed updates time when api key was used last time.
+func (apiKeysDB *apiKeysDB) UpdateLastUsed(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+
+	_, err := conn(ctx, apiKeysDB.pool).Exec(ctx, query, id, lastUsedAt)
+
+	return ErrAPIKeys.Wrap(err)
+}
+
+// scanAPIKey reads api key from a single result row.
+func scanAPIKey(row pgx.Row) (*apikeys.APIKey, error) {
+	key := new(apikeys.APIKey)
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&key.Scope,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	)
+
+	return key, err
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/audit"
+	"kitchen_nerd/pkg/util"
+)
+
+// ensures that auditDB implements audit.DB.
+var _ audit.DB = (*auditDB)(nil)
+
+// ErrAudit indicates that there was an error in the database.
+var ErrAudit = errs.Class("audit repository error")
+
+const auditFields = "id, actor_id, action, target_type, target_id, ip, user_agent, before, after, created_at"
+
+// auditDB provides access to audit log db.
+//
+// architecture: Database
+type auditDB struct {
+	pool *pgxpool.Pool
+}
+
+// Create appends an event to the audit log.
+func (auditDB *auditDB) Create(ctx context.Context, event audit.Event) error {
+	query := `INSERT INTO audit_log (` + auditFields + `)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	var actorID *uuid.UUID
+	if event.ActorID != uuid.Nil {
+		actorID = &event.ActorID
+	}
+
+	_, err := conn(ctx, auditDB.pool).Exec(ctx, query, event.ID, actorID, event.Action, event.TargetType, event.TargetID,
+		event.IP, event.UserAgent, nullableJSON(event.Before), nullableJSON(event.After), event.CreatedAt)
+
+	return ErrAudit.Wrap(err)
+}
+
+// List returns a page of events matching the filter, newest first.
+func (auditDB *auditDB) List(ctx context.Context, filter audit.Filter, pagination *util.PaginationReq) ([]audit.Event, error) {
+	where, args := auditWhere(filter)
+	query := fmt.Sprintf(`SELECT %s FROM audit_log %s ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d`,
+		auditFields, where, len(args)+1, len(args)+2)
+
+	events := make([]audit.Event, 0)
+	err := auditDB.query(ctx, query, append(args, pagination.Size, pagination.GetDBOffset()), func(event audit.Event) error {
+		events = append(events, event)
+		return nil
+	})
+
+	return events, err
+}
+
+// Count returns number of events matching the filter.
+func (auditDB *auditDB) Count(ctx context.Context, filter audit.Filter) (uint64, error) {
+	where, args := auditWhere(filter)
+
+	var count uint64
+	err := conn(ctx, auditDB.pool).QueryRow(ctx, `SELECT COUNT(id) FROM audit_log `+where, args...).Scan(&count)
+
+	return count, ErrAudit.Wrap(err)
+}
+
+// Iterate calls fn for every event matching the filter, oldest first.
+func (auditDB *auditDB) Iterate(ctx context.Context, filter audit.Filter, fn func(audit.Event) error) error {
+	where, args := auditWhere(filter)
+	query := fmt.Sprintf(`SELECT %s FROM audit_log %s ORDER BY created_at, id`, auditFields, where)
+
+	return auditDB.query(ctx, query, args, fn)
+}
+
+// query runs a select query and calls fn for every returned event.
+func (auditDB *auditDB) query(ctx context.Context, query string, args []interface{}, fn func(audit.Event) error) error {
+	rows, err := conn(ctx, auditDB.pool).Query(ctx, query, args...)
+	if err != nil {
+		return ErrAudit.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return ErrAudit.Wrap(err)
+		}
+
+		if err = fn(event); err != nil {
+			return err
+		}
+	}
+
+	return ErrAudit.Wrap(rows.Err())
+}
+
+// auditWhere builds where clause and its arguments from the filter.
+func auditWhere(filter audit.Filter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorID != uuid.Nil {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("target_id = $%d", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// scanAuditEvent reads audit event from a single result row.
+func scanAuditEvent(row pgx.Row) (audit.Event, error) {
+	var event audit.Event
+	var actorID *uuid.UUID
+	var before, after []byte
+
+	err := row.Scan(
+		&event.ID,
+		&actorID,
+		&event.Action,
+		&event.TargetType,
+		&event.TargetID,
+		&event.IP,
+		&event.UserAgent,
+		&before,
+		&after,
+		&event.CreatedAt,
+	)
+	if actorID != nil {
+		event.ActorID = *actorID
+	}
+	event.Before, event.After = before, after
+
+	return event, err
+}
+
+// nullableJSON converts empty json to sql NULL.
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return string(data)
+}
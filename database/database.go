@@ -3,8 +3,15 @@ package database
 import (
 	"context"
 	"kitchen_nerd"
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/database/memory"
+	"kitchen_nerd/database/sqlite"
+	"kitchen_nerd/ratelimit"
 	"kitchen_nerd/recipes"
 	"kitchen_nerd/tokens"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/zeebo/errs"
@@ -32,68 +39,62 @@ type database struct {
 	pool *pgxpool.Pool
 }
 
+// Config contains settings of postgresql connection pool, zero values keep defaults of the driver
+// and pool parameters of the database url. Sqlite always uses a single connection.
+type Config struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+}
+
+// Open returns kitchen_nerd.DB implementation selected by scheme of the database url:
+// postgres:// or postgresql:// for postgresql, sqlite:// for sqlite and memory:// for in-memory one.
+func Open(ctx context.Context, databaseURL string, config Config) (kitchen_nerd.DB, error) {
+	scheme, _, _ := strings.Cut(databaseURL, ":")
+	switch scheme {
+	case "postgres", "postgresql":
+		return New(ctx, databaseURL, config)
+	case "sqlite":
+		return sqlite.New(ctx, databaseURL)
+	case "memory":
+		return memory.New(), nil
+	default:
+		return nil, Error.New("unsupported database url scheme %q", scheme)
+	}
+}
+
 // New returns kitchenNerd.DB postgresql implementation.
-func New(ctx context.Context, databaseURL string) (kitchen_nerd.DB, error) {
-	pool, err := pgxpool.Connect(ctx, databaseURL)
+func New(ctx context.Context, databaseURL string, config Config) (kitchen_nerd.DB, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, Error.Wrap(err)
 	}
 
-	return &database{pool: pool}, nil
-}
+	if config.MaxConns > 0 {
+		poolConfig.MaxConns = config.MaxConns
+	}
+	if config.MinConns > 0 {
+		poolConfig.MinConns = config.MinConns
+	}
+	if config.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.MaxConnLifetime
+	}
+	if config.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.MaxConnIdleTime
+	}
 
-// CreateSchema create schema for all tables and databases.
-func (db *database) CreateSchema(ctx context.Context) (err error) {
-	createTableQuery := `
-        CREATE TABLE IF NOT EXISTS users (
-			id               		UUID PRIMARY KEY         NOT NULL,
-			email            		VARCHAR                  NOT NULL,
-			status                  VARCHAR                  NOT NULL,
-			name 	         		VARCHAR                  NOT NULL,
-			password_hash    		BYTEA                    NOT NULL,
-			last_login              TIMESTAMP WITH TIME ZONE NOT NULL,
-			created_at       		TIMESTAMP WITH TIME ZONE NOT NULL
-        );
-		CREATE TABLE IF NOT EXISTS users_tokens (
-		    id              UUID      PRIMARY KEY    NOT NULL,
-		    user_id         UUID                     NOT NULL,
-		    token           VARCHAR   UNIQUE         NOT NULL,
-		    expired_at      TIMESTAMP WITH TIME ZONE NOT NULL,
-		    created_at      TIMESTAMP WITH TIME ZONE NOT NULL
-		);
-CREATE TABLE IF NOT EXISTS recipes (
-    id           UUID        PRIMARY KEY,
-    title        VARCHAR     NOT NULL,
-    photo        VARCHAR,
-    description  TEXT,
-    instructions TEXT,
-    created_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS ingredients (
-    id   UUID    PRIMARY KEY,
-    name VARCHAR NOT NULL,
-    unit VARCHAR NOT NULL,
-    UNIQUE (name, unit)
-);
-
-CREATE TABLE IF NOT EXISTS recipe_ingredients (
-    id            UUID            PRIMARY KEY,
-    name          VARCHAR         NOT NULL,
-    recipe_id     UUID REFERENCES recipes(id),
-    quantity      DOUBLE PRECISION,
-    unit          VARCHAR,
-    optional      BOOLEAN,
-    UNIQUE(name, recipe_id)
-);
-	`
-
-	_, err = db.pool.Exec(ctx, createTableQuery)
+	pool, err := pgxpool.ConnectConfig(ctx, poolConfig)
 	if err != nil {
-		return Error.Wrap(err)
+		return nil, Error.Wrap(err)
 	}
 
-	return nil
+	return &database{pool: pool}, nil
+}
+
+// WithTx runs fn in a transaction, repositories called with the context passed to fn take part in it.
+func (db *database) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, db.pool, fn)
 }
 
 // Close closes underlying db connection.
@@ -101,6 +102,23 @@ func (db *database) Close() {
 	db.pool.Close()
 }
 
+// Ping checks that database is reachable.
+func (db *database) Ping(ctx context.Context) error {
+	return Error.Wrap(db.pool.Ping(ctx))
+}
+
+// Stats returns statistics of the connection pool.
+func (db *database) Stats() kitchen_nerd.PoolStats {
+	stat := db.pool.Stat()
+	return kitchen_nerd.PoolStats{
+		Acquired:        int64(stat.AcquiredConns()),
+		Idle:            int64(stat.IdleConns()),
+		Max:             int64(stat.MaxConns()),
+		WaitCount:       stat.EmptyAcquireCount(),
+		AcquireDuration: stat.AcquireDuration(),
+	}
+}
+
 // Users provides access to users db.
 func (db *database) Users() users.DB {
 	return &usersDB{pool: db.pool}
@@ -113,3 +131,18 @@ func (db *database) Tokens() tokens.DB {
 func (db *database) Recipes() recipes.DB {
 	return &recipesDB{pool: db.pool}
 }
+
+// APIKeys provides access to api keys db.
+func (db *database) APIKeys() apikeys.DB {
+	return &apiKeysDB{pool: db.pool}
+}
+
+// Audit provides access to audit log db.
+func (db *database) Audit() audit.DB {
+	return &auditDB{pool: db.pool}
+}
+
+// RateLimits provides access to rate limits db.
+func (db *database) RateLimits() ratelimit.DB {
+	return &rateLimitsDB{pool: db.pool}
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"kitchen_nerd"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/database/dbtest"
+)
+
+// testDatabaseURLEnv names the variable with url of a postgres database used by database tests.
+const testDatabaseURLEnv = "KITCHEN_NERD_TEST_DATABASE_URL"
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) kitchen_nerd.DB {
+		return newTestDatabase(context.Background(), t)
+	})
+}
+
+func TestAuditAppendOnly(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(ctx, t)
+
+	event := audit.Event{ID: uuid.New(), Action: audit.ActionUserLogin, TargetType: audit.TargetUser, CreatedAt: time.Now()}
+	if err := db.Audit().Create(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{
+		`UPDATE audit_log SET action = 'user.logout' WHERE id = $1`,
+		`DELETE FROM audit_log WHERE id = $1`,
+	} {
+		_, err := db.pool.Exec(ctx, query, event.ID)
+		if err == nil || !strings.Contains(err.Error(), "audit_log is append-only") {
+			t.Fatalf("%s: expected append-only error, got %v", query, err)
+		}
+	}
+
+	count, err := db.Audit().Count(ctx, audit.Filter{Action: audit.ActionUserLogin})
+	if err != nil || count != 1 {
+		t.Fatalf("expected event to stay unchanged, got %d %v", count, err)
+	}
+}
+
+// newTestDatabase connects to the test database in a fresh schema with all migrations applied.
+// The schema is dropped when the test ends. Test is skipped if test database is not configured.
+func newTestDatabase(ctx context.Context, tb testing.TB) *database {
+	databaseURL := os.Getenv(testDatabaseURLEnv)
+	if databaseURL == "" {
+		tb.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	schema := "test_" + uuid.NewString()[:8]
+
+	admin, err := pgxpool.Connect(ctx, databaseURL)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(admin.Close)
+
+	if _, err = admin.Exec(ctx, `CREATE SCHEMA `+schema); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), `DROP SCHEMA `+schema+` CASCADE`)
+	})
+
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+
+	pool, err := pgxpool.ConnectConfig(ctx, config)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(pool.Close)
+
+	db := &database{pool: pool}
+	if err = db.MigrateUp(ctx); err != nil {
+		tb.Fatal(err)
+	}
+
+	return db
+}
@@ -0,0 +1,986 @@
+// Package dbtest contains conformance tests, which every implementation of users, recipes and tokens repositories must pass.
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"kitchen_nerd"
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/ratelimit"
+	"kitchen_nerd/recipes"
+	"kitchen_nerd/tokens"
+	"kitchen_nerd/users"
+)
+
+// errRollback is returned from transactions which must be rolled back.
+var errRollback = errors.New("rollback")
+
+// Run runs conformance tests of all repositories of databases created by newDB.
+// Every test gets a new empty database.
+func Run(t *testing.T, newDB func(t *testing.T) kitchen_nerd.DB) {
+	t.Run("Users", func(t *testing.T) {
+		Users(t, func(t *testing.T) users.DB { return newDB(t).Users() })
+	})
+	t.Run("Recipes", func(t *testing.T) {
+		Recipes(t, func(t *testing.T) recipes.DB { return newDB(t).Recipes() })
+	})
+	t.Run("Tokens", func(t *testing.T) {
+		Tokens(t, func(t *testing.T) tokens.DB { return newDB(t).Tokens() })
+	})
+	t.Run("APIKeys", func(t *testing.T) {
+		APIKeys(t, newDB)
+	})
+	t.Run("RateLimits", func(t *testing.T) {
+		RateLimits(t, func(t *testing.T) ratelimit.DB { return newDB(t).RateLimits() })
+	})
+	t.Run("Audit", func(t *testing.T) {
+		Audit(t, func(t *testing.T) audit.DB { return newDB(t).Audit() })
+	})
+}
+
+// Users runs conformance tests of users repositories created by newDB.
+func Users(t *testing.T, newDB func(t *testing.T) users.DB) {
+	ctx := context.Background()
+	base := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		db := newDB(t)
+		user := newUser("alice@example.com", "Alice", users.StatusUser, base)
+		mustNoError(t, db.Create(ctx, &user))
+
+		got, err := db.Get(ctx, user.ID)
+		mustNoError(t, err)
+		assertUser(t, user, *got)
+
+		got, err = db.GetByEmail(ctx, user.Email)
+		mustNoError(t, err)
+		assertUser(t, user, *got)
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		db := newDB(t)
+		user := newUser("alice@example.com", "Alice", users.StatusUser, base)
+		mustNoError(t, db.Create(ctx, &user))
+
+		// emails are unique regardless of case.
+		duplicate := newUser("Alice@Example.com", "Alice", users.StatusUser, base)
+		if err := db.Create(ctx, &duplicate); err == nil {
+			t.Fatal("expected error of duplicate email")
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		db := newDB(t)
+
+		_, err := db.Get(ctx, uuid.New())
+		mustBeClass(t, err, users.ErrNoUser.Has)
+
+		_, err = db.GetByEmail(ctx, "missing@example.com")
+		mustBeClass(t, err, users.ErrNoUser.Has)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		db := newDB(t)
+		user := newUser("alice@example.com", "Alice", users.StatusUser, base)
+		mustNoError(t, db.Create(ctx, &user))
+
+		mustNoError(t, db.UpdateLastLogin(ctx, user.ID))
+		mustNoError(t, db.UpdatePassword(ctx, user.ID, []byte("new hash")))
+		mustNoError(t, db.UpdateStatus(ctx, user.ID, users.StatusAdmin))
+
+		got, err := db.Get(ctx, user.ID)
+		mustNoError(t, err)
+		if !got.LastLogin.After(user.LastLogin) {
+			t.Fatalf("last login was not updated: %v", got.LastLogin)
+		}
+		if !bytes.Equal(got.PasswordHash, []byte("new hash")) {
+			t.Fatalf("password hash was not updated: %q", got.PasswordHash)
+		}
+		if got.Status != users.StatusAdmin {
+			t.Fatalf("status was not updated: %q", got.Status)
+		}
+
+		mustBeClass(t, db.UpdateLastLogin(ctx, uuid.New()), users.ErrNoUser.Has)
+		mustBeClass(t, db.UpdatePassword(ctx, uuid.New(), []byte("hash")), users.ErrNoUser.Has)
+		mustBeClass(t, db.UpdateStatus(ctx, uuid.New(), users.StatusUser), users.ErrNoUser.Has)
+	})
+
+	t.Run("ListAndCount", func(t *testing.T) {
+		db := newDB(t)
+		alice := newUser("alice@example.com", "Alice", users.StatusAdmin, base)
+		bob := newUser("bob@example.com", "Bob", users.StatusUser, base.Add(time.Hour))
+		carol := newUser("carol@example.com", "Carol", users.StatusUser, base.Add(2*time.Hour))
+		for _, user := range []*users.User{&bob, &alice, &carol} {
+			mustNoError(t, db.Create(ctx, user))
+		}
+
+		list, err := db.List(ctx, util.NewPaginationReq(2, 1), nil)
+		mustNoError(t, err)
+		assertUserIDs(t, list, carol, bob)
+
+		list, err = db.List(ctx, util.NewPaginationReq(2, 2), nil)
+		mustNoError(t, err)
+		assertUserIDs(t, list, alice)
+
+		sorted := &util.ListQuery{Sort: []util.Sort{{Field: "name"}}}
+		list, err = db.List(ctx, util.NewPaginationReq(10, 1), sorted)
+		mustNoError(t, err)
+		assertUserIDs(t, list, alice, bob, carol)
+
+		filtered := &util.ListQuery{Filters: []util.Condition{
+			{Field: "email", Operator: util.OperatorContains, Value: "B@EXAMPLE"},
+		}}
+		list, err = db.List(ctx, util.NewPaginationReq(10, 1), filtered)
+		mustNoError(t, err)
+		assertUserIDs(t, list, bob)
+
+		for _, test := range []struct {
+			query *util.ListQuery
+			count uint64
+		}{
+			{nil, 3},
+			{filtered, 1},
+			{&util.ListQuery{Filters: []util.Condition{{Field: "status", Operator: util.OperatorEq, Value: "user"}}}, 2},
+			{&util.ListQuery{Filters: []util.Condition{{Field: "createdAt", Operator: util.OperatorGte, Value: base.Add(time.Hour)}}}, 2},
+			{&util.ListQuery{Filters: []util.Condition{{Field: "name", Operator: util.OperatorContains, Value: "%"}}}, 0},
+		} {
+			count, err := db.Count(ctx, test.query)
+			mustNoError(t, err)
+			if count != test.count {
+				t.Fatalf("expected %d users, got %d", test.count, count)
+			}
+		}
+	})
+
+	t.Run("WithTx", func(t *testing.T) {
+		db := newDB(t)
+		rolledBack := newUser("alice@example.com", "Alice", users.StatusUser, base)
+		panicked := newUser("carol@example.com", "Carol", users.StatusUser, base)
+		committed := newUser("bob@example.com", "Bob", users.StatusUser, base)
+
+		err := db.WithTx(ctx, func(ctx context.Context) error {
+			mustNoError(t, db.Create(ctx, &rolledBack))
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("expected rollback error, got %v", err)
+		}
+
+		// panic is passed to the caller after rollback.
+		func() {
+			defer func() {
+				if p := recover(); p != errRollback {
+					t.Fatalf("expected panic to be rethrown, got %v", p)
+				}
+			}()
+
+			_ = db.WithTx(ctx, func(ctx context.Context) error {
+				mustNoError(t, db.Create(ctx, &panicked))
+				panic(errRollback)
+			})
+		}()
+
+		mustNoError(t, db.WithTx(ctx, func(ctx context.Context) error {
+			return db.Create(ctx, &committed)
+		}))
+
+		_, err = db.Get(ctx, rolledBack.ID)
+		mustBeClass(t, err, users.ErrNoUser.Has)
+
+		_, err = db.Get(ctx, panicked.ID)
+		mustBeClass(t, err, users.ErrNoUser.Has)
+
+		_, err = db.Get(ctx, committed.ID)
+		mustNoError(t, err)
+	})
+}
+
+// Recipes runs conformance tests of recipes repositories created by newDB.
+func Recipes(t *testing.T, newDB func(t *testing.T) recipes.DB) {
+	ctx := context.Background()
+
+	t.Run("CreateGetUpdateDelete", func(t *testing.T) {
+		db := newDB(t)
+		recipe := recipes.NewRecipe("Borsch", "data:image/png;base64,AA==", "Beet soup", "Boil")
+		mustNoError(t, db.CreateRecipe(ctx, recipe))
+
+		got, err := db.GetRecipe(ctx, recipe.ID)
+		mustNoError(t, err)
+		assertRecipe(t, *recipe, *got)
+
+		update := *recipe
+		update.Title, update.Instructions = "Green borsch", "Boil with sorrel"
+		mustNoError(t, db.UpdateRecipe(ctx, &update))
+
+		got, err = db.GetRecipe(ctx, recipe.ID)
+		mustNoError(t, err)
+		assertRecipe(t, update, *got)
+
+		// update writes the whole recipe, so optional fields can be cleared.
+		update.PhotoBase64, update.Description = "", ""
+		mustNoError(t, db.UpdateRecipe(ctx, &update))
+
+		got, err = db.GetRecipe(ctx, recipe.ID)
+		mustNoError(t, err)
+		assertRecipe(t, update, *got)
+
+		mustNoError(t, db.DeleteRecipe(ctx, recipe.ID))
+		_, err = db.GetRecipe(ctx, recipe.ID)
+		mustBeClass(t, err, recipes.ErrNoRecipe.Has)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		db := newDB(t)
+
+		_, err := db.GetRecipe(ctx, uuid.New())
+		mustBeClass(t, err, recipes.ErrNoRecipe.Has)
+
+		mustBeClass(t, db.UpdateRecipe(ctx, &recipes.Recipe{ID: uuid.New(), Title: "title"}), recipes.ErrNoRecipe.Has)
+	})
+
+	t.Run("Ingredients", func(t *testing.T) {
+		db := newDB(t)
+		recipe := recipes.NewRecipe("Pancakes", "", "", "")
+		mustNoError(t, db.CreateRecipe(ctx, recipe))
+
+		for _, name := range []string{"flour", "milk"} {
+			ingredient := recipes.RecipeIngredient{Name: name, RecipeID: recipe.ID, Quantity: 1.5, Unit: string(recipes.Gram), Optional: name == "milk"}
+			mustNoError(t, db.CreateIngredient(ctx, ingredient))
+		}
+
+		list, _, err := db.List(ctx, util.NewPaginationReq(10, 1), nil)
+		mustNoError(t, err)
+		if len(list) != 1 || len(list[0].Ingredients) != 2 {
+			t.Fatalf("expected recipe with 2 ingredients, got %+v", list)
+		}
+		got, err := db.GetRecipe(ctx, recipe.ID)
+		mustNoError(t, err)
+		if len(got.Ingredients) != 2 {
+			t.Fatalf("expected recipe with 2 ingredients, got %+v", got)
+		}
+		for _, ingredient := range append(list[0].Ingredients, got.Ingredients...) {
+			if ingredient.RecipeID != recipe.ID || ingredient.Quantity != 1.5 || ingredient.Optional != (ingredient.Name == "milk") {
+				t.Fatalf("unexpected ingredient %+v", ingredient)
+			}
+		}
+
+		mustNoError(t, db.DeleteIngredients(ctx, recipe.ID))
+		list, _, err = db.List(ctx, util.NewPaginationReq(10, 1), nil)
+		mustNoError(t, err)
+		if len(list[0].Ingredients) != 0 {
+			t.Fatalf("ingredients were not deleted: %+v", list[0].Ingredients)
+		}
+		got, err = db.GetRecipe(ctx, recipe.ID)
+		mustNoError(t, err)
+		if len(got.Ingredients) != 0 {
+			t.Fatalf("ingredients were not deleted: %+v", got.Ingredients)
+		}
+
+		mustNoError(t, db.DeleteRecipe(ctx, recipe.ID))
+	})
+
+	t.Run("PageIngredients", func(t *testing.T) {
+		db := newDB(t)
+
+		// recipes have different numbers of ingredients, the last one has none.
+		expected := make(map[uuid.UUID][]string)
+		for i, title := range []string{"borsch", "pancakes", "pelmeni", "okroshka"} {
+			recipe := recipes.NewRecipe(title, "", "", "")
+			mustNoError(t, db.CreateRecipe(ctx, recipe))
+
+			expected[recipe.ID] = []string{}
+			for j := 0; j < 3-i; j++ {
+				name := fmt.Sprintf("%s-%d", title, j)
+				mustNoError(t, db.CreateIngredient(ctx, recipes.RecipeIngredient{Name: name, RecipeID: recipe.ID, Quantity: 1, Unit: string(recipes.Gram)}))
+				expected[recipe.ID] = append(expected[recipe.ID], name)
+			}
+		}
+
+		seen := make(map[uuid.UUID]bool)
+		first, cursors, err := db.List(ctx, util.NewPaginationReq(2, 1), nil)
+		mustNoError(t, err)
+		second, _, err := db.List(ctx, withCursor(t, 2, cursors.Next), nil)
+		mustNoError(t, err)
+
+		for _, page := range [][]*recipes.Recipe{first, second} {
+			if len(page) != 2 {
+				t.Fatalf("expected 2 recipes on a page, got %d", len(page))
+			}
+
+			for _, recipe := range page {
+				seen[recipe.ID] = true
+
+				names := []string{}
+				for _, ingredient := range recipe.Ingredients {
+					if ingredient.RecipeID != recipe.ID {
+						t.Fatalf("recipe %s got ingredient of recipe %s", recipe.Title, ingredient.RecipeID)
+					}
+					names = append(names, ingredient.Name)
+				}
+				sort.Strings(names)
+				if !reflect.DeepEqual(names, expected[recipe.ID]) {
+					t.Fatalf("expected ingredients %v of recipe %s, got %v", expected[recipe.ID], recipe.Title, names)
+				}
+			}
+		}
+		if len(seen) != 4 {
+			t.Fatalf("expected pages to cover 4 recipes, got %d", len(seen))
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		db := newDB(t)
+		for i := 0; i < 5; i++ {
+			mustNoError(t, db.CreateRecipe(ctx, recipes.NewRecipe("recipe", "", "", "")))
+		}
+
+		all, _, err := db.List(ctx, util.NewPaginationReq(10, 1), nil)
+		mustNoError(t, err)
+		if len(all) != 5 {
+			t.Fatalf("expected 5 recipes, got %d", len(all))
+		}
+		for i := 1; i < len(all); i++ {
+			if all[i].CreatedAt.After(all[i-1].CreatedAt) {
+				t.Fatalf("recipes are not sorted newest first")
+			}
+		}
+
+		first, cursors, err := db.List(ctx, util.NewPaginationReq(2, 1), nil)
+		mustNoError(t, err)
+		assertRecipeIDs(t, first, all[0:2]...)
+		assertCursors(t, cursors, true, false)
+
+		second, cursors, err := db.List(ctx, withCursor(t, 2, cursors.Next), nil)
+		mustNoError(t, err)
+		assertRecipeIDs(t, second, all[2:4]...)
+		assertCursors(t, cursors, true, true)
+		prev := cursors.Prev
+
+		third, cursors, err := db.List(ctx, withCursor(t, 2, cursors.Next), nil)
+		mustNoError(t, err)
+		assertRecipeIDs(t, third, all[4])
+		assertCursors(t, cursors, false, true)
+
+		back, cursors, err := db.List(ctx, withCursor(t, 2, prev), nil)
+		mustNoError(t, err)
+		assertRecipeIDs(t, back, all[0:2]...)
+		assertCursors(t, cursors, true, false)
+
+		offset, cursors, err := db.List(ctx, util.NewPaginationReq(2, 2), nil)
+		mustNoError(t, err)
+		assertRecipeIDs(t, offset, all[2:4]...)
+		assertCursors(t, cursors, true, true)
+	})
+
+	t.Run("FilterAndSort", func(t *testing.T) {
+		db := newDB(t)
+		borsch := recipes.NewRecipe("Borsch", "", "soup", "")
+		pancakes := recipes.NewRecipe("Pancakes", "", "100% tasty", "")
+		green := recipes.NewRecipe("Green borsch", "", "soup", "")
+		for _, recipe := range []*recipes.Recipe{borsch, pancakes, green} {
+			mustNoError(t, db.CreateRecipe(ctx, recipe))
+		}
+
+		query := &util.ListQuery{
+			Sort:    []util.Sort{{Field: "title"}},
+			Filters: []util.Condition{{Field: "title", Operator: util.OperatorContains, Value: "BORSCH"}},
+		}
+		list, cursors, err := db.List(ctx, util.NewPaginationReq(10, 1), query)
+		mustNoError(t, err)
+		assertRecipeIDs(t, list, borsch, green)
+		assertCursors(t, cursors, false, false)
+
+		sorted := &util.ListQuery{Sort: []util.Sort{{Field: "title", Desc: true}}}
+		list, _, err = db.List(ctx, util.NewPaginationReq(2, 1), sorted)
+		mustNoError(t, err)
+		assertRecipeIDs(t, list, pancakes, green)
+
+		for _, test := range []struct {
+			query *util.ListQuery
+			count uint64
+		}{
+			{nil, 3},
+			{query, 2},
+			{&util.ListQuery{Filters: []util.Condition{{Field: "description", Operator: util.OperatorEq, Value: "soup"}}}, 2},
+			{&util.ListQuery{Filters: []util.Condition{{Field: "description", Operator: util.OperatorContains, Value: "0%"}}}, 1},
+			{&util.ListQuery{Filters: []util.Condition{{Field: "title", Operator: util.OperatorContains, Value: "_"}}}, 0},
+		} {
+			count, err := db.Count(ctx, test.query)
+			mustNoError(t, err)
+			if count != test.count {
+				t.Fatalf("expected %d recipes, got %d", test.count, count)
+			}
+		}
+	})
+
+	t.Run("WithTx", func(t *testing.T) {
+		db := newDB(t)
+		recipe := recipes.NewRecipe("Borsch", "", "", "")
+
+		err := db.WithTx(ctx, func(ctx context.Context) error {
+			mustNoError(t, db.CreateRecipe(ctx, recipe))
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("expected rollback error, got %v", err)
+		}
+
+		_, err = db.GetRecipe(ctx, recipe.ID)
+		mustBeClass(t, err, recipes.ErrNoRecipe.Has)
+	})
+}
+
+// Tokens runs conformance tests of tokens repositories created by newDB.
+func Tokens(t *testing.T, newDB func(t *testing.T) tokens.DB) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	newToken := func(userID uuid.UUID, token string) tokens.UserToken {
+		return tokens.UserToken{ID: uuid.New(), UserID: userID, Token: token, ExpiredAt: now.Add(time.Hour), CreatedAt: now}
+	}
+
+	t.Run("AddAndGet", func(t *testing.T) {
+		db := newDB(t)
+		token := newToken(uuid.New(), "token")
+		mustNoError(t, db.AddToken(ctx, &token))
+
+		got, err := db.GetToken(ctx, token.Token)
+		mustNoError(t, err)
+		assertToken(t, token, got)
+
+		got, err = db.GetTokenByID(ctx, token.UserID)
+		mustNoError(t, err)
+		assertToken(t, token, got)
+
+		_, err = db.GetToken(ctx, "missing")
+		mustBeClass(t, err, tokens.ErrNoToken.Has)
+
+		_, err = db.GetTokenByID(ctx, uuid.New())
+		mustBeClass(t, err, tokens.ErrNoToken.Has)
+	})
+
+	t.Run("Sessions", func(t *testing.T) {
+		db := newDB(t)
+		userID, otherID := uuid.New(), uuid.New()
+		first, second, other := newToken(userID, "first"), newToken(userID, "second"), newToken(otherID, "other")
+		for _, token := range []*tokens.UserToken{&first, &second, &other} {
+			mustNoError(t, db.AddToken(ctx, token))
+		}
+
+		sessions, err := db.ListActiveSessions(ctx, userID)
+		mustNoError(t, err)
+		if len(sessions) != 2 {
+			t.Fatalf("expected 2 sessions, got %d", len(sessions))
+		}
+
+		mustNoError(t, db.DeleteSessionToken(ctx, userID, first.ID))
+		mustBeClass(t, db.DeleteSessionToken(ctx, userID, first.ID), tokens.ErrNoToken.Has)
+
+		mustNoError(t, db.DeleteToken(ctx, second.Token))
+		mustBeClass(t, db.DeleteToken(ctx, second.Token), tokens.ErrNoToken.Has)
+
+		mustNoError(t, db.DeleteTokenByUserId(ctx, otherID))
+		mustBeClass(t, db.DeleteTokenByUserId(ctx, otherID), tokens.ErrNoToken.Has)
+
+		sessions, err = db.ListActiveSessions(ctx, userID)
+		mustNoError(t, err)
+		if len(sessions) != 0 {
+			t.Fatalf("expected no sessions, got %d", len(sessions))
+		}
+	})
+
+	t.Run("Revocations", func(t *testing.T) {
+		db := newDB(t)
+		userID := uuid.New()
+		active := tokens.Revocation{ID: uuid.New(), TokenID: "active", UserID: userID, ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+		anonymous := tokens.Revocation{ID: uuid.New(), TokenID: "anonymous", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+		expired := tokens.Revocation{ID: uuid.New(), TokenID: "expired", UserID: userID, ExpiresAt: now.Add(-time.Hour), CreatedAt: now}
+		for _, revocation := range []tokens.Revocation{active, anonymous, expired} {
+			mustNoError(t, db.AddRevocation(ctx, revocation))
+		}
+
+		list, err := db.ListRevocations(ctx, now)
+		mustNoError(t, err)
+		if len(list) != 2 {
+			t.Fatalf("expected 2 active revocations, got %d", len(list))
+		}
+		for _, revocation := range list {
+			expected := active
+			if revocation.ID == anonymous.ID {
+				expected = anonymous
+			}
+
+			if revocation.ID != expected.ID || revocation.TokenID != expected.TokenID || revocation.UserID != expected.UserID ||
+				!revocation.ExpiresAt.Equal(expected.ExpiresAt) {
+				t.Fatalf("expected revocation %+v, got %+v", expected, revocation)
+			}
+		}
+	})
+
+	t.Run("DeleteExpired", func(t *testing.T) {
+		db := newDB(t)
+		active, expired := newToken(uuid.New(), "active"), newToken(uuid.New(), "expired")
+		expired.ExpiredAt = now.Add(-time.Hour)
+		for _, token := range []*tokens.UserToken{&active, &expired} {
+			mustNoError(t, db.AddToken(ctx, token))
+		}
+
+		mustNoError(t, db.AddRevocation(ctx, tokens.Revocation{ID: uuid.New(), TokenID: "active", ExpiresAt: now.Add(time.Hour), CreatedAt: now}))
+		mustNoError(t, db.AddRevocation(ctx, tokens.Revocation{ID: uuid.New(), TokenID: "expired", ExpiresAt: now.Add(-time.Hour), CreatedAt: now}))
+
+		deleted, err := db.DeleteExpiredTokens(ctx, now)
+		mustNoError(t, err)
+		if deleted != 1 {
+			t.Fatalf("expected 1 deleted token, got %d", deleted)
+		}
+
+		_, err = db.GetToken(ctx, expired.Token)
+		mustBeClass(t, err, tokens.ErrNoToken.Has)
+		_, err = db.GetToken(ctx, active.Token)
+		mustNoError(t, err)
+
+		deleted, err = db.DeleteExpiredRevocations(ctx, now)
+		mustNoError(t, err)
+		if deleted != 1 {
+			t.Fatalf("expected 1 deleted revocation, got %d", deleted)
+		}
+
+		list, err := db.ListRevocations(ctx, now.Add(-2*time.Hour))
+		mustNoError(t, err)
+		if len(list) != 1 || list[0].TokenID != "active" {
+			t.Fatalf("expected only active revocation to be left, got %+v", list)
+		}
+	})
+}
+
+// APIKeys runs conformance tests of api keys repositories of databases created by newDB,
+// keys belong to users, so the whole database is needed to create them.
+func APIKeys(t *testing.T, newDB func(t *testing.T) kitchen_nerd.DB) {
+	ctx := context.Background()
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	// newUserID creates an owner of api keys.
+	newUserID := func(t *testing.T, db kitchen_nerd.DB) uuid.UUID {
+		t.Helper()
+
+		user := newUser(uuid.NewString()+"@example.com", "Owner", users.StatusUser, now)
+		mustNoError(t, db.Users().Create(ctx, &user))
+
+		return user.ID
+	}
+
+	newKey := func(userID uuid.UUID, name string, createdAt time.Time) apikeys.APIKey {
+		return apikeys.APIKey{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Name:      name,
+			Prefix:    "kn_" + name,
+			Hash:      apikeys.Hash("kn_" + name),
+			Scope:     apikeys.ScopeRead,
+			CreatedAt: createdAt,
+		}
+	}
+
+	t.Run("CreateAndGetByHash", func(t *testing.T) {
+		master := newDB(t)
+		db := master.APIKeys()
+		key := newKey(newUserID(t, master), "first", now)
+		mustNoError(t, db.Create(ctx, &key))
+
+		got, err := db.GetByHash(ctx, apikeys.Hash("kn_first"))
+		mustNoError(t, err)
+		if got.ID != key.ID || got.UserID != key.UserID || got.Name != key.Name || got.Prefix != key.Prefix ||
+			got.Scope != key.Scope || !bytes.Equal(got.Hash, key.Hash) || !got.CreatedAt.Equal(key.CreatedAt) ||
+			got.LastUsedAt != nil || got.RevokedAt != nil {
+			t.Fatalf("expected api key %+v, got %+v", key, got)
+		}
+
+		_, err = db.GetByHash(ctx, apikeys.Hash("kn_missing"))
+		mustBeClass(t, err, apikeys.ErrNoAPIKey.Has)
+	})
+
+	t.Run("ListByUser", func(t *testing.T) {
+		master := newDB(t)
+		db, userID := master.APIKeys(), newUserID(t, master)
+		older, newer, other := newKey(userID, "older", now), newKey(userID, "newer", now.Add(time.Minute)), newKey(newUserID(t, master), "other", now)
+		for _, key := range []*apikeys.APIKey{&older, &newer, &other} {
+			mustNoError(t, db.Create(ctx, key))
+		}
+
+		list, err := db.ListByUser(ctx, userID)
+		mustNoError(t, err)
+		if len(list) != 2 || list[0].ID != newer.ID || list[1].ID != older.ID {
+			t.Fatalf("expected keys of the user newest first, got %+v", list)
+		}
+
+		list, err = db.ListByUser(ctx, uuid.New())
+		mustNoError(t, err)
+		if len(list) != 0 {
+			t.Fatalf("expected no keys, got %+v", list)
+		}
+	})
+
+	t.Run("RevokeAndUpdateLastUsed", func(t *testing.T) {
+		master := newDB(t)
+		db := master.APIKeys()
+		key := newKey(newUserID(t, master), "key", now)
+		mustNoError(t, db.Create(ctx, &key))
+
+		mustNoError(t, db.UpdateLastUsed(ctx, key.ID, now.Add(time.Minute)))
+
+		// keys of other users can't be revoked.
+		mustBeClass(t, db.Revoke(ctx, uuid.New(), key.ID, now), apikeys.ErrNoAPIKey.Has)
+		mustBeClass(t, db.Revoke(ctx, key.UserID, uuid.New(), now), apikeys.ErrNoAPIKey.Has)
+
+		mustNoError(t, db.Revoke(ctx, key.UserID, key.ID, now.Add(time.Hour)))
+		mustBeClass(t, db.Revoke(ctx, key.UserID, key.ID, now.Add(time.Hour)), apikeys.ErrNoAPIKey.Has)
+
+		got, err := db.GetByHash(ctx, key.Hash)
+		mustNoError(t, err)
+		if got.LastUsedAt == nil || !got.LastUsedAt.Equal(now.Add(time.Minute)) ||
+			got.RevokedAt == nil || !got.RevokedAt.Equal(now.Add(time.Hour)) {
+			t.Fatalf("unexpected last used and revoked times %v %v", got.LastUsedAt, got.RevokedAt)
+		}
+	})
+}
+
+// RateLimits runs conformance tests of rate limits repositories created by newDB.
+func RateLimits(t *testing.T, newDB func(t *testing.T) ratelimit.DB) {
+	ctx := context.Background()
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	policy := ratelimit.Policy{Name: "test", Burst: 2, Period: time.Second}
+
+	take := func(t *testing.T, db ratelimit.DB, key string, at time.Time, allowed bool, remaining int) ratelimit.Result {
+		t.Helper()
+
+		result, err := db.Take(ctx, key, policy, at)
+		mustNoError(t, err)
+		if result.Allowed != allowed || result.Remaining != remaining || result.Limit != policy.Burst {
+			t.Fatalf("expected allowed %v with %d remaining, got %+v", allowed, remaining, result)
+		}
+
+		return result
+	}
+
+	t.Run("Take", func(t *testing.T) {
+		db := newDB(t)
+		take(t, db, "a", now, true, 1)
+		take(t, db, "a", now, true, 0)
+
+		result := take(t, db, "a", now, false, 0)
+		if result.RetryAfter != 500*time.Millisecond {
+			t.Fatalf("expected retry after 500ms, got %s", result.RetryAfter)
+		}
+
+		// buckets are independent.
+		take(t, db, "b", now, true, 1)
+
+		// half of the period refills one token.
+		take(t, db, "a", now.Add(500*time.Millisecond), true, 0)
+		// bucket never holds more than the burst.
+		take(t, db, "a", now.Add(time.Hour), true, 1)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		db := newDB(t)
+		burst := ratelimit.Policy{Name: "test", Burst: 5, Period: time.Hour}
+
+		var allowed atomic.Int64
+		var group errgroup.Group
+		for i := 0; i < 4*burst.Burst; i++ {
+			group.Go(func() error {
+				result, err := db.Take(ctx, "a", burst, now)
+				if result.Allowed {
+					allowed.Add(1)
+				}
+				return err
+			})
+		}
+		mustNoError(t, group.Wait())
+
+		if allowed.Load() != int64(burst.Burst) {
+			t.Fatalf("expected %d allowed requests, got %d", burst.Burst, allowed.Load())
+		}
+	})
+
+	t.Run("DeleteStale", func(t *testing.T) {
+		db := newDB(t)
+		take(t, db, "stale", now, true, 1)
+		take(t, db, "fresh", now.Add(time.Minute), true, 1)
+		take(t, db, "fresh", now.Add(time.Minute), true, 0)
+
+		deleted, err := db.DeleteStale(ctx, now.Add(time.Second))
+		mustNoError(t, err)
+		if deleted != 1 {
+			t.Fatalf("expected 1 deleted bucket, got %d", deleted)
+		}
+
+		take(t, db, "fresh", now.Add(time.Minute), false, 0)
+	})
+}
+
+// Audit runs conformance tests of audit log repositories created by newDB.
+func Audit(t *testing.T, newDB func(t *testing.T) audit.DB) {
+	ctx := context.Background()
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	alice, bob := uuid.New(), uuid.New()
+
+	newEvent := func(actorID uuid.UUID, action audit.Action, targetType, targetID string, createdAt time.Time) audit.Event {
+		return audit.Event{
+			ID:         uuid.New(),
+			ActorID:    actorID,
+			Action:     action,
+			TargetType: targetType,
+			TargetID:   targetID,
+			IP:         "192.0.2.1",
+			UserAgent:  "test",
+			CreatedAt:  createdAt,
+		}
+	}
+
+	login := newEvent(alice, audit.ActionUserLogin, audit.TargetUser, alice.String(), now)
+	create := newEvent(alice, audit.ActionRecipeCreate, audit.TargetRecipe, "1", now.Add(time.Minute))
+	update := newEvent(bob, audit.ActionRecipeUpdate, audit.TargetRecipe, "1", now.Add(2*time.Minute))
+	failed := newEvent(uuid.Nil, audit.ActionUserLoginFailed, audit.TargetUser, "eve@example.com", now.Add(3*time.Minute))
+	create.After = []byte(`{"title":"Borsch"}`)
+	update.Before, update.After = []byte(`{"title":"Borsch"}`), []byte(`{"title": "Green borsch"}`)
+
+	// fill returns database with all events created out of order.
+	fill := func(t *testing.T) audit.DB {
+		t.Helper()
+
+		db := newDB(t)
+		for _, event := range []audit.Event{update, login, failed, create} {
+			mustNoError(t, db.Create(ctx, event))
+		}
+
+		return db
+	}
+
+	t.Run("List", func(t *testing.T) {
+		db := fill(t)
+
+		list, err := db.List(ctx, audit.Filter{}, &util.PaginationReq{Page: 1, Size: 3})
+		mustNoError(t, err)
+		assertEventIDs(t, list, failed, update, create)
+		assertEvent(t, failed, list[0])
+		assertEvent(t, update, list[1])
+
+		list, err = db.List(ctx, audit.Filter{}, &util.PaginationReq{Page: 2, Size: 3})
+		mustNoError(t, err)
+		assertEventIDs(t, list, login)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		db := fill(t)
+
+		for _, test := range []struct {
+			name     string
+			filter   audit.Filter
+			expected []audit.Event
+		}{
+			{"actor", audit.Filter{ActorID: alice}, []audit.Event{create, login}},
+			{"action", audit.Filter{Action: audit.ActionRecipeUpdate}, []audit.Event{update}},
+			{"target", audit.Filter{TargetType: audit.TargetRecipe, TargetID: "1"}, []audit.Event{update, create}},
+			{"period", audit.Filter{From: now.Add(time.Minute), To: now.Add(3 * time.Minute)}, []audit.Event{update, create}},
+			{"nothing", audit.Filter{ActorID: alice, Action: audit.ActionRecipeUpdate}, nil},
+		} {
+			count, err := db.Count(ctx, test.filter)
+			mustNoError(t, err)
+			if count != uint64(len(test.expected)) {
+				t.Fatalf("%s: expected %d events, got %d", test.name, len(test.expected), count)
+			}
+
+			list, err := db.List(ctx, test.filter, &util.PaginationReq{Page: 1, Size: 10})
+			mustNoError(t, err)
+			assertEventIDs(t, list, test.expected...)
+		}
+	})
+
+	t.Run("Iterate", func(t *testing.T) {
+		db := fill(t)
+
+		var events []audit.Event
+		collect := func(event audit.Event) error {
+			events = append(events, event)
+			return nil
+		}
+
+		mustNoError(t, db.Iterate(ctx, audit.Filter{}, collect))
+		assertEventIDs(t, events, login, create, update, failed)
+
+		events = nil
+		mustNoError(t, db.Iterate(ctx, audit.Filter{TargetType: audit.TargetUser}, collect))
+		assertEventIDs(t, events, login, failed)
+
+		// error returned by fn stops iteration.
+		calls := 0
+		err := db.Iterate(ctx, audit.Filter{}, func(event audit.Event) error {
+			calls++
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) || calls != 1 {
+			t.Fatalf("expected iteration to stop with fn error after 1 call, got %v after %d calls", err, calls)
+		}
+	})
+}
+
+func newUser(email, name string, status users.Status, createdAt time.Time) users.User {
+	return users.User{
+		ID:           uuid.New(),
+		Email:        email,
+		Name:         name,
+		Status:       status,
+		PasswordHash: []byte("hash"),
+		LastLogin:    createdAt,
+		CreatedAt:    createdAt,
+	}
+}
+
+// withCursor returns pagination by the encoded cursor.
+func withCursor(t *testing.T, size uint64, encoded string) *util.PaginationReq {
+	t.Helper()
+
+	cursor, err := util.DecodeCursor(encoded)
+	mustNoError(t, err)
+
+	pagination := util.NewPaginationReq(size, 1)
+	pagination.Cursor = cursor
+
+	return pagination
+}
+
+func mustNoError(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func mustBeClass(t *testing.T, err error, has func(err error) bool) {
+	t.Helper()
+
+	if err == nil || !has(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func assertUser(t *testing.T, expected, actual users.User) {
+	t.Helper()
+
+	if actual.ID != expected.ID || actual.Email != expected.Email || actual.Name != expected.Name || actual.Status != expected.Status ||
+		!bytes.Equal(actual.PasswordHash, expected.PasswordHash) ||
+		!actual.LastLogin.Equal(expected.LastLogin) || !actual.CreatedAt.Equal(expected.CreatedAt) {
+		t.Fatalf("expected user %+v, got %+v", expected, actual)
+	}
+}
+
+func assertUserIDs(t *testing.T, list []users.User, expected ...users.User) {
+	t.Helper()
+
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d users, got %d", len(expected), len(list))
+	}
+	for i := range list {
+		if list[i].ID != expected[i].ID {
+			t.Fatalf("expected %s at %d, got %s", expected[i].Name, i, list[i].Name)
+		}
+	}
+}
+
+func assertRecipe(t *testing.T, expected, actual recipes.Recipe) {
+	t.Helper()
+
+	if actual.ID != expected.ID || actual.Title != expected.Title || actual.PhotoBase64 != expected.PhotoBase64 ||
+		actual.Description != expected.Description || actual.Instructions != expected.Instructions {
+		t.Fatalf("expected recipe %+v, got %+v", expected, actual)
+	}
+
+	// databases may store time with lower precision.
+	if diff := actual.CreatedAt.Sub(expected.CreatedAt); diff < -time.Millisecond || diff > time.Millisecond {
+		t.Fatalf("expected recipe created at %v, got %v", expected.CreatedAt, actual.CreatedAt)
+	}
+}
+
+func assertRecipeIDs(t *testing.T, list []*recipes.Recipe, expected ...*recipes.Recipe) {
+	t.Helper()
+
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d recipes, got %d", len(expected), len(list))
+	}
+	for i := range list {
+		if list[i].ID != expected[i].ID {
+			t.Fatalf("expected %s at %d, got %s", expected[i].ID, i, list[i].ID)
+		}
+	}
+}
+
+func assertEvent(t *testing.T, expected, actual audit.Event) {
+	t.Helper()
+
+	if expected.ID != actual.ID || expected.ActorID != actual.ActorID || expected.Action != actual.Action ||
+		expected.TargetType != actual.TargetType || expected.TargetID != actual.TargetID ||
+		expected.IP != actual.IP || expected.UserAgent != actual.UserAgent || !expected.CreatedAt.Equal(actual.CreatedAt) ||
+		!jsonEqual(expected.Before, actual.Before) || !jsonEqual(expected.After, actual.After) {
+		t.Fatalf("expected event %+v, got %+v", expected, actual)
+	}
+}
+
+func assertEventIDs(t *testing.T, list []audit.Event, expected ...audit.Event) {
+	t.Helper()
+
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(list))
+	}
+	for i := range expected {
+		if list[i].ID != expected[i].ID {
+			t.Fatalf("expected event %d to be %s %s, got %s %s", i, expected[i].Action, expected[i].ID, list[i].Action, list[i].ID)
+		}
+	}
+}
+
+// jsonEqual compares json documents ignoring formatting, which is changed by jsonb.
+func jsonEqual(expected, actual []byte) bool {
+	if len(expected) == 0 || len(actual) == 0 {
+		return len(expected) == len(actual)
+	}
+
+	var e, a interface{}
+	if json.Unmarshal(expected, &e) != nil || json.Unmarshal(actual, &a) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(e, a)
+}
+
+func assertCursors(t *testing.T, cursors util.Cursors, next, prev bool) {
+	t.Helper()
+
+	if (cursors.Next != "") != next || (cursors.Prev != "") != prev {
+		t.Fatalf("expected next cursor %t and previous cursor %t, got %+v", next, prev, cursors)
+	}
+}
+
+func assertToken(t *testing.T, expected, actual tokens.UserToken) {
+	t.Helper()
+
+	if actual.ID != expected.ID || actual.UserID != expected.UserID || actual.Token != expected.Token ||
+		!actual.ExpiredAt.Equal(expected.ExpiredAt) || !actual.CreatedAt.Equal(expected.CreatedAt) {
+		t.Fatalf("expected token %+v, got %+v", expected, actual)
+	}
+}
@@ -0,0 +1,90 @@
+// Package listquery translates list queries to sql shared by postgres and sqlite databases.
+package listquery
+
+import (
+	"fmt"
+	"strings"
+
+	"kitchen_nerd/pkg/util"
+)
+
+// operators maps filter operators to sql comparison operators.
+var operators = map[util.Operator]string{
+	util.OperatorEq:  "=",
+	util.OperatorNe:  "<>",
+	util.OperatorGt:  ">",
+	util.OperatorGte: ">=",
+	util.OperatorLt:  "<",
+	util.OperatorLte: "<=",
+}
+
+// likeEscaper escapes wildcard characters of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Columns maps api field names of a listed resource to table columns.
+// Only mapped fields get to sql, values are always passed as arguments.
+type Columns map[string]string
+
+// Where builds sql conditions of the query filters. Placeholders are numbered after existing args,
+// which are returned along with filter values.
+func (columns Columns) Where(query *util.ListQuery, args []interface{}) ([]string, []interface{}, error) {
+	if query == nil {
+		return nil, args, nil
+	}
+
+	var conditions []string
+	for _, filter := range query.Filters {
+		column, ok := columns[filter.Field]
+		if !ok {
+			return nil, nil, util.ErrInvalidListQuery.New("can't filter by %q", filter.Field)
+		}
+
+		if filter.Operator == util.OperatorContains {
+			args = append(args, likeEscaper.Replace(strings.ToLower(fmt.Sprint(filter.Value))))
+			conditions = append(conditions, fmt.Sprintf(`LOWER(%s) LIKE '%%' || $%d || '%%' ESCAPE '\'`, column, len(args)))
+			continue
+		}
+
+		operator, ok := operators[filter.Operator]
+		if !ok {
+			return nil, nil, util.ErrInvalidListQuery.New("unsupported operator %q", filter.Operator)
+		}
+
+		args = append(args, filter.Value)
+		conditions = append(conditions, fmt.Sprintf("%s %s $%d", column, operator, len(args)))
+	}
+
+	return conditions, args, nil
+}
+
+// OrderBy builds ORDER BY clause of the query sorting, falling back to defaultOrder.
+// Id is always the last sort key, so order is deterministic.
+func (columns Columns) OrderBy(query *util.ListQuery, defaultOrder string) (string, error) {
+	if !query.IsSorted() {
+		return "ORDER BY " + defaultOrder, nil
+	}
+
+	keys := make([]string, 0, len(query.Sort)+1)
+	for _, sort := range query.Sort {
+		column, ok := columns[sort.Field]
+		if !ok {
+			return "", util.ErrInvalidListQuery.New("can't sort by %q", sort.Field)
+		}
+
+		if sort.Desc {
+			column += " DESC"
+		}
+		keys = append(keys, column)
+	}
+
+	return "ORDER BY " + strings.Join(append(keys, "id"), ", "), nil
+}
+
+// WhereClause joins conditions to WHERE clause.
+func WhereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND ") + " "
+}
@@ -0,0 +1,77 @@
+package listquery_test
+
+import (
+	"reflect"
+	"testing"
+
+	"kitchen_nerd/database/listquery"
+	"kitchen_nerd/pkg/util"
+)
+
+var testColumns = listquery.Columns{
+	"title":     "title",
+	"createdAt": "created_at",
+}
+
+func TestWhere(t *testing.T) {
+	query := &util.ListQuery{Filters: []util.Condition{
+		{Field: "title", Operator: util.OperatorContains, Value: `50%_Off\`},
+		{Field: "createdAt", Operator: util.OperatorGte, Value: "2023-09-01"},
+		{Field: "title", Operator: util.OperatorNe, Value: "Borsch"},
+	}}
+
+	// placeholders are numbered after existing args.
+	conditions, args, err := testColumns.Where(query, []interface{}{"owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedConditions := []string{
+		`LOWER(title) LIKE '%' || $2 || '%' ESCAPE '\'`,
+		`created_at >= $3`,
+		`title <> $4`,
+	}
+	if !reflect.DeepEqual(conditions, expectedConditions) {
+		t.Fatalf("expected conditions %q, got %q", expectedConditions, conditions)
+	}
+
+	// wildcards and escape character of contains value match literally.
+	expectedArgs := []interface{}{"owner", `50\%\_off\\`, "2023-09-01", "Borsch"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Fatalf("expected args %q, got %q", expectedArgs, args)
+	}
+
+	if listquery.WhereClause(conditions) != "WHERE "+expectedConditions[0]+" AND "+expectedConditions[1]+" AND "+expectedConditions[2]+" " {
+		t.Fatalf("unexpected where clause %q", listquery.WhereClause(conditions))
+	}
+	if listquery.WhereClause(nil) != "" {
+		t.Fatal("expected empty where clause without conditions")
+	}
+
+	conditions, args, err = testColumns.Where(nil, []interface{}{"owner"})
+	if err != nil || conditions != nil || len(args) != 1 {
+		t.Fatalf("expected no conditions for empty query, got %q %v %v", conditions, args, err)
+	}
+
+	_, _, err = testColumns.Where(&util.ListQuery{Filters: []util.Condition{{Field: "author", Operator: util.OperatorEq}}}, nil)
+	if !util.ErrInvalidListQuery.Has(err) {
+		t.Fatalf("expected unmapped field to be rejected, got %v", err)
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	orderBy, err := testColumns.OrderBy(nil, "created_at DESC, id DESC")
+	if err != nil || orderBy != "ORDER BY created_at DESC, id DESC" {
+		t.Fatalf("expected default order, got %q %v", orderBy, err)
+	}
+
+	orderBy, err = testColumns.OrderBy(&util.ListQuery{Sort: []util.Sort{{Field: "title"}, {Field: "createdAt", Desc: true}}}, "id")
+	if err != nil || orderBy != "ORDER BY title, created_at DESC, id" {
+		t.Fatalf("unexpected order %q %v", orderBy, err)
+	}
+
+	_, err = testColumns.OrderBy(&util.ListQuery{Sort: []util.Sort{{Field: "author"}}}, "id")
+	if !util.ErrInvalidListQuery.Has(err) {
+		t.Fatalf("expected unmapped field to be rejected, got %v", err)
+	}
+}
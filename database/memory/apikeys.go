@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/apikeys"
+)
+
+// ensures that apiKeysDB implements apikeys.DB.
+var _ apikeys.DB = (*apiKeysDB)(nil)
+
+// ErrAPIKeys indicates that there was an error in the database.
+var ErrAPIKeys = errs.Class("api keys repository error")
+
+// apiKeysDB provides access to api keys db.
+//
+// architecture: Database
+type apiKeysDB struct {
+	db *database
+}
+
+// WithTx runs fn in a transaction.
+func (apiKeysDB *apiKeysDB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return apiKeysDB.db.WithTx(ctx, fn)
+}
+
+// Create inserts an api key in the database.
+func (apiKeysDB *apiKeysDB) Create(ctx context.Context, key *apikeys.APIKey) error {
+	return apiKeysDB.db.write(func(s *state) error {
+		if _, ok := s.users[key.UserID]; !ok {
+			return ErrAPIKeys.New("user %s does not exist", key.UserID)
+		}
+		for _, existing := range s.apiKeys {
+			if existing.ID == key.ID || bytes.Equal(existing.Hash, key.Hash) {
+				return ErrAPIKeys.New("api key already exists")
+			}
+		}
+
+		s.apiKeys[key.ID] = *key
+
+		return nil
+	})
+}
+
+// GetByHash returns api key by hash of its value.
+func (apiKeysDB *apiKeysDB) GetByHash(ctx context.Context, hash []byte) (*apikeys.APIKey, error) {
+	var found *apikeys.APIKey
+	apiKeysDB.db.read(func(s *state) {
+		for _, key := range s.apiKeys {
+			if bytes.Equal(key.Hash, hash) {
+				found = &key
+				return
+			}
+		}
+	})
+	if found == nil {
+		return nil, apikeys.ErrNoAPIKey.New("")
+	}
+
+	return found, nil
+}
+
+// ListByUser returns all api keys of the user.
+func (apiKeysDB *apiKeysDB) ListByUser(ctx context.Context, userID uuid.UUID) ([]apikeys.APIKey, error) {
+	keys := make([]apikeys.APIKey, 0)
+	apiKeysDB.db.read(func(s *state) {
+		for _, key := range s.apiKeys {
+			if key.UserID == userID {
+				keys = append(keys, key)
+			}
+		}
+	})
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+
+	return keys, nil
+}
+
+// Revoke marks user's api key as revoked.
+func (apiKeysDB *apiKeysDB) Revoke(ctx context.Context, userID, id uuid.UUID, revokedAt time.Time) error {
+	return apiKeysDB.db.write(func(s *state) error {
+		key, ok := s.apiKeys[id]
+		if !ok || key.UserID != userID || key.RevokedAt != nil {
+			return apikeys.ErrNoAPIKey.New("")
+		}
+
+		key.RevokedAt = &revokedAt
+		s.apiKeys[id] = key
+
+		return nil
+	})
+}
+
+// UpdateLastUsed updates time when api key was used last time.
+func (apiKeysDB *apiKeysDB) UpdateLastUsed(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	return apiKeysDB.db.write(func(s *state) error {
+		if key, ok := s.apiKeys[id]; ok {
+			key.LastUsedAt = &lastUsedAt
+			s.apiKeys[id] = key
+		}
+
+		return nil
+	})
+}
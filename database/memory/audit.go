@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/audit"
+	"kitchen_nerd/pkg/util"
+)
+
+// ensures that auditDB implements audit.DB.
+var _ audit.DB = (*auditDB)(nil)
+
+// auditDB provides access to audit log db.
+//
+// architecture: Database
+type auditDB struct {
+	db *database
+}
+
+// Create appends an event to the audit log.
+func (auditDB *auditDB) Create(ctx context.Context, event audit.Event) error {
+	return auditDB.db.write(func(s *state) error {
+		s.audit = append(s.audit, event)
+		return nil
+	})
+}
+
+// List returns a page of events matching the filter, newest first.
+func (auditDB *auditDB) List(ctx context.Context, filter audit.Filter, pagination *util.PaginationReq) ([]audit.Event, error) {
+	events := auditDB.filter(filter)
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+
+	return append(make([]audit.Event, 0), page(events, pagination)...), nil
+}
+
+// Count returns number of events matching the filter.
+func (auditDB *auditDB) Count(ctx context.Context, filter audit.Filter) (uint64, error) {
+	return uint64(len(auditDB.filter(filter))), nil
+}
+
+// Iterate calls fn for every event matching the filter, oldest first.
+func (auditDB *auditDB) Iterate(ctx context.Context, filter audit.Filter, fn func(audit.Event) error) error {
+	for _, event := range auditDB.filter(filter) {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filter returns events matching the filter, oldest first.
+func (auditDB *auditDB) filter(filter audit.Filter) []audit.Event {
+	var events []audit.Event
+	auditDB.db.read(func(s *state) {
+		for _, event := range s.audit {
+			switch {
+			case filter.ActorID != uuid.Nil && event.ActorID != filter.ActorID,
+				filter.Action != "" && event.Action != filter.Action,
+				filter.TargetType != "" && event.TargetType != filter.TargetType,
+				filter.TargetID != "" && event.TargetID != filter.TargetID,
+				!filter.From.IsZero() && event.CreatedAt.Before(filter.From),
+				!filter.To.IsZero() && !event.CreatedAt.Before(filter.To):
+				continue
+			}
+
+			events = append(events, event)
+		}
+	})
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+
+	return events
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/pkg/util"
+)
+
+// fieldGetter returns value of a listed field by its name in the api.
+type fieldGetter func(field string) interface{}
+
+// matches checks if a row with given fields matches all filters of the query.
+func matches(query *util.ListQuery, get fieldGetter) bool {
+	if query == nil {
+		return true
+	}
+
+	for _, filter := range query.Filters {
+		value := get(filter.Field)
+
+		if filter.Operator == util.OperatorContains {
+			text, _ := value.(string)
+			pattern, _ := filter.Value.(string)
+			if !strings.Contains(strings.ToLower(text), strings.ToLower(pattern)) {
+				return false
+			}
+			continue
+		}
+
+		result := compare(value, filter.Value)
+		var ok bool
+		switch filter.Operator {
+		case util.OperatorEq:
+			ok = result == 0
+		case util.OperatorNe:
+			ok = result != 0
+		case util.OperatorGt:
+			ok = result > 0
+		case util.OperatorGte:
+			ok = result >= 0
+		case util.OperatorLt:
+			ok = result < 0
+		case util.OperatorLte:
+			ok = result <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortRows sorts rows by the query sorting and then by id.
+func sortRows[T any](rows []T, query *util.ListQuery, get func(row T) fieldGetter, id func(row T) uuid.UUID) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := get(rows[i]), get(rows[j])
+		for _, order := range query.Sort {
+			result := compare(a(order.Field), b(order.Field))
+			if order.Desc {
+				result = -result
+			}
+			if result != 0 {
+				return result < 0
+			}
+		}
+
+		first, second := id(rows[i]), id(rows[j])
+		return bytes.Compare(first[:], second[:]) < 0
+	})
+}
+
+// compare returns -1, 0 or 1 when a is less, equal or greater than b. Values of different types are equal.
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b)
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b)
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+		}
+	case bool:
+		if b, ok := b.(bool); ok && a != b {
+			if b {
+				return -1
+			}
+			return 1
+		}
+	case uuid.UUID:
+		if b, ok := b.(uuid.UUID); ok {
+			return bytes.Compare(a[:], b[:])
+		}
+	}
+
+	return 0
+}
+
+// page returns rows of the offset page.
+func page[T any](rows []T, pagination *util.PaginationReq) []T {
+	offset := pagination.GetDBOffset()
+	if offset >= uint64(len(rows)) {
+		return rows[:0]
+	}
+
+	end := offset + pagination.Size
+	if end > uint64(len(rows)) {
+		end = uint64(len(rows))
+	}
+
+	return rows[offset:end]
+}
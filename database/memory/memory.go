@@ -0,0 +1,189 @@
+// Package memory implements kitchen_nerd.DB keeping all data in memory, it is meant for development and tests.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd"
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/database/migrations"
+	"kitchen_nerd/ratelimit"
+	"kitchen_nerd/recipes"
+	"kitchen_nerd/tokens"
+	"kitchen_nerd/users"
+)
+
+// ensures that database implements kitchen_nerd.DB.
+var _ kitchen_nerd.DB = (*database)(nil)
+
+// state holds all tables. Rows are stored by value, so copying maps is enough to snapshot the state.
+type state struct {
+	users       map[uuid.UUID]users.User
+	tokens      map[uuid.UUID]tokens.UserToken
+	revocations map[uuid.UUID]tokens.Revocation
+	recipes     map[uuid.UUID]recipes.Recipe
+	ingredients map[uuid.UUID]recipes.RecipeIngredient
+	apiKeys     map[uuid.UUID]apikeys.APIKey
+	audit       []audit.Event
+}
+
+func newState() *state {
+	return &state{
+		users:       make(map[uuid.UUID]users.User),
+		tokens:      make(map[uuid.UUID]tokens.UserToken),
+		revocations: make(map[uuid.UUID]tokens.Revocation),
+		recipes:     make(map[uuid.UUID]recipes.Recipe),
+		ingredients: make(map[uuid.UUID]recipes.RecipeIngredient),
+		apiKeys:     make(map[uuid.UUID]apikeys.APIKey),
+	}
+}
+
+// clone returns a copy of the state.
+func (s *state) clone() *state {
+	return &state{
+		users:       cloneMap(s.users),
+		tokens:      cloneMap(s.tokens),
+		revocations: cloneMap(s.revocations),
+		recipes:     cloneMap(s.recipes),
+		ingredients: cloneMap(s.ingredients),
+		apiKeys:     cloneMap(s.apiKeys),
+		audit:       append([]audit.Event(nil), s.audit...),
+	}
+}
+
+func cloneMap[V any](m map[uuid.UUID]V) map[uuid.UUID]V {
+	clone := make(map[uuid.UUID]V, len(m))
+	for key, value := range m {
+		clone[key] = value
+	}
+
+	return clone
+}
+
+// database keeps all tables in memory and is safe for concurrent use.
+//
+// architecture: Master Database
+type database struct {
+	mu    sync.RWMutex
+	state *state
+
+	// txMu allows one transaction at a time.
+	txMu sync.Mutex
+
+	rateLimits ratelimit.DB
+}
+
+// New returns kitchen_nerd.DB in-memory implementation, which is empty and needs no migrations.
+func New() kitchen_nerd.DB {
+	return &database{state: newState(), rateLimits: ratelimit.NewMemoryDB()}
+}
+
+// txKey is a context key, which marks that the context belongs to a transaction.
+type txKey struct{}
+
+// WithTx runs fn in a transaction. Transactions run one at a time and state is restored
+// when fn returns an error or panics. Writes made outside of the transaction meanwhile are lost on rollback.
+func (db *database) WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	db.txMu.Lock()
+	defer db.txMu.Unlock()
+
+	db.mu.RLock()
+	snapshot := db.state.clone()
+	db.mu.RUnlock()
+
+	defer func() {
+		p := recover()
+		if err != nil || p != nil {
+			db.mu.Lock()
+			db.state = snapshot
+			db.mu.Unlock()
+		}
+		if p != nil {
+			panic(p)
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+// read calls fn holding read lock of the state.
+func (db *database) read(fn func(s *state)) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	fn(db.state)
+}
+
+// write calls fn holding write lock of the state.
+func (db *database) write(fn func(s *state) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return fn(db.state)
+}
+
+// Close does nothing, data is kept until database is garbage collected.
+func (db *database) Close() {}
+
+// Ping does nothing, in-memory database is always reachable.
+func (db *database) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Stats returns empty statistics, in-memory database has no connections.
+func (db *database) Stats() kitchen_nerd.PoolStats {
+	return kitchen_nerd.PoolStats{}
+}
+
+// MigrateUp does nothing, in-memory database has no schema.
+func (db *database) MigrateUp(ctx context.Context) error {
+	return nil
+}
+
+// MigrateDown does nothing, in-memory database has no schema.
+func (db *database) MigrateDown(ctx context.Context, steps int) error {
+	return nil
+}
+
+// MigrationStatus returns no migrations, in-memory database has no schema.
+func (db *database) MigrationStatus(ctx context.Context) ([]migrations.Status, error) {
+	return []migrations.Status{}, nil
+}
+
+// Users provides access to users db.
+func (db *database) Users() users.DB {
+	return &usersDB{db: db}
+}
+
+// Tokens provides access to tokens db.
+func (db *database) Tokens() tokens.DB {
+	return &tokensDB{db: db}
+}
+
+// Recipes provides access to recipes db.
+func (db *database) Recipes() recipes.DB {
+	return &recipesDB{db: db}
+}
+
+// APIKeys provides access to api keys db.
+func (db *database) APIKeys() apikeys.DB {
+	return &apiKeysDB{db: db}
+}
+
+// Audit provides access to audit log db.
+func (db *database) Audit() audit.DB {
+	return &auditDB{db: db}
+}
+
+// RateLimits provides access to rate limits db, buckets are not part of the state and survive rollbacks.
+func (db *database) RateLimits() ratelimit.DB {
+	return db.rateLimits
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"kitchen_nerd"
+	"kitchen_nerd/database/dbtest"
+	"kitchen_nerd/database/memory"
+)
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) kitchen_nerd.DB {
+		return memory.New()
+	})
+}
@@ -0,0 +1,250 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/recipes"
+)
+
+// ensures that recipesDB implements recipes.DB.
+var _ recipes.DB = (*recipesDB)(nil)
+
+// ErrRecipes indicates that there was an error in the database.
+var ErrRecipes = errs.Class("recipes repository error")
+
+// recipesDB provides access to recipes db.
+//
+// architecture: Database
+type recipesDB struct {
+	db *database
+}
+
+// WithTx runs fn in a transaction.
+func (recipesDB *recipesDB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return recipesDB.db.WithTx(ctx, fn)
+}
+
+// CreateRecipe adds a new recipe to the database.
+func (recipesDB *recipesDB) CreateRecipe(ctx context.Context, recipe *recipes.Recipe) error {
+	recipe.CreatedAt = time.Now().UTC()
+
+	return recipesDB.db.write(func(s *state) error {
+		if _, ok := s.recipes[recipe.ID]; ok {
+			return ErrRecipes.New("recipe %s already exists", recipe.ID)
+		}
+
+		created := *recipe
+		created.Ingredients = nil
+		s.recipes[recipe.ID] = created
+
+		return nil
+	})
+}
+
+// GetRecipe returns a recipe with its ingredients by its ID from the database.
+func (recipesDB *recipesDB) GetRecipe(ctx context.Context, id uuid.UUID) (*recipes.Recipe, error) {
+	var recipe recipes.Recipe
+	var ok bool
+	recipesDB.db.read(func(s *state) {
+		recipe, ok = s.recipes[id]
+		for _, ingredient := range s.ingredients {
+			if ingredient.RecipeID == id {
+				recipe.Ingredients = append(recipe.Ingredients, ingredient)
+			}
+		}
+	})
+	if !ok {
+		return nil, recipes.ErrNoRecipe.New("")
+	}
+
+	return &recipe, nil
+}
+
+// UpdateRecipe writes all fields of a recipe to the database, empty fields are cleared.
+func (recipesDB *recipesDB) UpdateRecipe(ctx context.Context, updatedRecipe *recipes.Recipe) error {
+	return recipesDB.db.write(func(s *state) error {
+		recipe, ok := s.recipes[updatedRecipe.ID]
+		if !ok {
+			return recipes.ErrNoRecipe.New("")
+		}
+
+		recipe.Title = updatedRecipe.Title
+		recipe.PhotoBase64 = updatedRecipe.PhotoBase64
+		recipe.Description = updatedRecipe.Description
+		recipe.Instructions = updatedRecipe.Instructions
+		s.recipes[recipe.ID] = recipe
+
+		return nil
+	})
+}
+
+// DeleteRecipe deletes a recipe from the database.
+func (recipesDB *recipesDB) DeleteRecipe(ctx context.Context, id uuid.UUID) error {
+	return recipesDB.db.write(func(s *state) error {
+		for _, ingredient := range s.ingredients {
+			if ingredient.RecipeID == id {
+				return ErrRecipes.New("recipe %s has ingredients", id)
+			}
+		}
+
+		delete(s.recipes, id)
+
+		return nil
+	})
+}
+
+// CreateIngredient adds an ingredient of a recipe to the database.
+func (recipesDB *recipesDB) CreateIngredient(ctx context.Context, ingredient recipes.RecipeIngredient) error {
+	return recipesDB.db.write(func(s *state) error {
+		if _, ok := s.recipes[ingredient.RecipeID]; !ok {
+			return ErrRecipes.New("recipe %s does not exist", ingredient.RecipeID)
+		}
+		for _, existing := range s.ingredients {
+			if existing.RecipeID == ingredient.RecipeID && existing.Name == ingredient.Name {
+				return ErrRecipes.New("recipe already has ingredient %q", ingredient.Name)
+			}
+		}
+
+		ingredient.ID = uuid.New()
+		s.ingredients[ingredient.ID] = ingredient
+
+		return nil
+	})
+}
+
+// DeleteIngredients deletes all ingredients of a recipe from the database.
+func (recipesDB *recipesDB) DeleteIngredients(ctx context.Context, recipeID uuid.UUID) error {
+	return recipesDB.db.write(func(s *state) error {
+		for id, ingredient := range s.ingredients {
+			if ingredient.RecipeID == recipeID {
+				delete(s.ingredients, id)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetIngredients returns a list of ingredients by recipe ID.
+func (recipesDB *recipesDB) GetIngredients(ctx context.Context, recipeID uuid.UUID) (ingredients []recipes.RecipeIngredient, err error) {
+	recipesDB.db.read(func(s *state) {
+		for _, ingredient := range s.ingredients {
+			if ingredient.RecipeID == recipeID {
+				ingredients = append(ingredients, ingredient)
+			}
+		}
+	})
+
+	return ingredients, nil
+}
+
+// Count returns number of recipes matching the query.
+func (recipesDB *recipesDB) Count(ctx context.Context, query *util.ListQuery) (uint64, error) {
+	return uint64(len(recipesDB.filter(query, nil))), nil
+}
+
+// List returns a page of recipes matching the query, newest first unless query sorts them,
+// along with cursors of neighbouring pages. Cursors are not returned for custom sorting.
+func (recipesDB *recipesDB) List(ctx context.Context, pagination *util.PaginationReq, query *util.ListQuery) ([]*recipes.Recipe, util.Cursors, error) {
+	var cursors util.Cursors
+
+	cursor := pagination.Cursor
+	list := recipesDB.filter(query, cursor)
+
+	sorting := query
+	if !query.IsSorted() {
+		sorting = &util.ListQuery{Sort: []util.Sort{{Field: "createdAt", Desc: true}, {Field: "id", Desc: true}}}
+	}
+	sortRows(list, sorting, recipeFields, func(recipe recipes.Recipe) uuid.UUID { return recipe.ID })
+
+	var hasMore bool
+	var rows []recipes.Recipe
+	switch {
+	case cursor == nil:
+		rows = page(list, pagination)
+		hasMore = pagination.GetDBOffset()+uint64(len(rows)) < uint64(len(list))
+	case cursor.Backward:
+		// rows before the cursor are the last ones of the newest first list.
+		start := 0
+		if uint64(len(list)) > pagination.Size {
+			start = len(list) - int(pagination.Size)
+		}
+		rows, hasMore = list[start:], start > 0
+	default:
+		rows = list
+		if uint64(len(rows)) > pagination.Size {
+			rows, hasMore = rows[:pagination.Size], true
+		}
+	}
+
+	result := make([]*recipes.Recipe, 0, len(rows))
+	recipesDB.db.read(func(s *state) {
+		for _, row := range rows {
+			recipe := row
+			for _, ingredient := range s.ingredients {
+				if ingredient.RecipeID == recipe.ID {
+					recipe.Ingredients = append(recipe.Ingredients, ingredient)
+				}
+			}
+			result = append(result, &recipe)
+		}
+	})
+
+	if len(result) > 0 && !query.IsSorted() {
+		first, last := result[0], result[len(result)-1]
+		cursors = util.PageCursors(pagination, hasMore,
+			util.Cursor{CreatedAt: first.CreatedAt, ID: first.ID}, util.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	return result, cursors, nil
+}
+
+// filter returns all recipes matching the query, which are after the cursor if it is set.
+func (recipesDB *recipesDB) filter(query *util.ListQuery, cursor *util.Cursor) []recipes.Recipe {
+	var list []recipes.Recipe
+	recipesDB.db.read(func(s *state) {
+		for _, recipe := range s.recipes {
+			if !matches(query, recipeFields(recipe)) {
+				continue
+			}
+
+			if cursor != nil {
+				order := recipe.CreatedAt.Compare(cursor.CreatedAt)
+				if order == 0 {
+					order = bytes.Compare(recipe.ID[:], cursor.ID[:])
+				}
+				if (cursor.Backward && order <= 0) || (!cursor.Backward && order >= 0) {
+					continue
+				}
+			}
+
+			list = append(list, recipe)
+		}
+	})
+
+	return list
+}
+
+// recipeFields returns values of recipes list fields and id, which keyset pagination sorts by.
+func recipeFields(recipe recipes.Recipe) fieldGetter {
+	return func(field string) interface{} {
+		switch field {
+		case "id":
+			return recipe.ID
+		case "title":
+			return recipe.Title
+		case "description":
+			return recipe.Description
+		case "createdAt":
+			return recipe.CreatedAt
+		default:
+			return nil
+		}
+	}
+}
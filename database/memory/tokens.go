@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/tokens"
+)
+
+// ensures that tokensDB implements tokens.DB.
+var _ tokens.DB = (*tokensDB)(nil)
+
+// ErrTokens indicates that there was an error in the database.
+var ErrTokens = errs.Class("tokens repository error")
+
+// tokensDB provides access to tokens db.
+//
+// architecture: Database
+type tokensDB struct {
+	db *database
+}
+
+// GetToken returns user's token from the database.
+func (tokensDB *tokensDB) GetToken(ctx context.Context, token string) (tokens.UserToken, error) {
+	return tokensDB.find(func(userToken tokens.UserToken) bool { return userToken.Token == token })
+}
+
+// GetTokenByID returns user's token from the database.
+func (tokensDB *tokensDB) GetTokenByID(ctx context.Context, id uuid.UUID) (tokens.UserToken, error) {
+	return tokensDB.find(func(userToken tokens.UserToken) bool { return userToken.UserID == id })
+}
+
+// find returns the first token matching the condition.
+func (tokensDB *tokensDB) find(condition func(userToken tokens.UserToken) bool) (found tokens.UserToken, err error) {
+	err = tokens.ErrNoToken.New("")
+	tokensDB.db.read(func(s *state) {
+		for _, userToken := range s.tokens {
+			if condition(userToken) {
+				found, err = userToken, nil
+				return
+			}
+		}
+	})
+
+	return found, err
+}
+
+// AddToken inserts a token in the database.
+func (tokensDB *tokensDB) AddToken(ctx context.Context, token *tokens.UserToken) error {
+	return tokensDB.db.write(func(s *state) error {
+		for _, existing := range s.tokens {
+			if existing.ID == token.ID || existing.Token == token.Token {
+				return ErrTokens.New("token already exists")
+			}
+		}
+
+		stored := *token
+		stored.Username, stored.Status = "", ""
+		s.tokens[token.ID] = stored
+
+		return nil
+	})
+}
+
+// DeleteToken removes a token from the database.
+func (tokensDB *tokensDB) DeleteToken(ctx context.Context, token string) error {
+	return tokensDB.delete(func(userToken tokens.UserToken) bool { return userToken.Token == token })
+}
+
+// DeleteTokenByUserId removes a token from the database by user id.
+func (tokensDB *tokensDB) DeleteTokenByUserId(ctx context.Context, id uuid.UUID) error {
+	return tokensDB.delete(func(userToken tokens.UserToken) bool { return userToken.UserID == id })
+}
+
+// DeleteSessionToken removes a token from the database by session id.
+func (tokensDB *tokensDB) DeleteSessionToken(ctx context.Context, userId, sessionId uuid.UUID) error {
+	return tokensDB.delete(func(userToken tokens.UserToken) bool {
+		return userToken.UserID == userId && userToken.ID == sessionId
+	})
+}
+
+// delete removes all tokens matching the condition and returns ErrNoToken if nothing was deleted.
+func (tokensDB *tokensDB) delete(condition func(userToken tokens.UserToken) bool) error {
+	return tokensDB.db.write(func(s *state) error {
+		deleted := false
+		for id, userToken := range s.tokens {
+			if condition(userToken) {
+				delete(s.tokens, id)
+				deleted = true
+			}
+		}
+
+		if !deleted {
+			return tokens.ErrNoToken.New("")
+		}
+
+		return nil
+	})
+}
+
+// ListActiveSessions gets all sessions by user id form the database.
+func (tokensDB *tokensDB) ListActiveSessions(ctx context.Context, userID uuid.UUID) (sessions []tokens.UserToken, err error) {
+	tokensDB.db.read(func(s *state) {
+		for _, userToken := range s.tokens {
+			if userToken.UserID == userID {
+				sessions = append(sessions, userToken)
+			}
+		}
+	})
+
+	return sessions, nil
+}
+
+// AddRevocation inserts a signed tokens revocation in the database.
+func (tokensDB *tokensDB) AddRevocation(ctx context.Context, revocation tokens.Revocation) error {
+	return tokensDB.db.write(func(s *state) error {
+		if _, ok := s.revocations[revocation.ID]; ok {
+			return ErrTokens.New("revocation %s already exists", revocation.ID)
+		}
+
+		s.revocations[revocation.ID] = revocation
+
+		return nil
+	})
+}
+
+// ListRevocations returns revocations which are not expired at the moment.
+func (tokensDB *tokensDB) ListRevocations(ctx context.Context, now time.Time) (revocations []tokens.Revocation, err error) {
+	tokensDB.db.read(func(s *state) {
+		for _, revocation := range s.revocations {
+			if revocation.ExpiresAt.After(now) {
+				revocations = append(revocations, revocation)
+			}
+		}
+	})
+
+	return revocations, nil
+}
+
+// DeleteExpiredTokens removes tokens expired at the moment and returns their number.
+func (tokensDB *tokensDB) DeleteExpiredTokens(ctx context.Context, now time.Time) (deleted int64, err error) {
+	err = tokensDB.db.write(func(s *state) error {
+		for id, userToken := range s.tokens {
+			if !userToken.ExpiredAt.After(now) {
+				delete(s.tokens, id)
+				deleted++
+			}
+		}
+
+		return nil
+	})
+
+	return deleted, err
+}
+
+// DeleteExpiredRevocations removes revocations expired at the moment and returns their number.
+func (tokensDB *tokensDB) DeleteExpiredRevocations(ctx context.Context, now time.Time) (deleted int64, err error) {
+	err = tokensDB.db.write(func(s *state) error {
+		for id, revocation := range s.revocations {
+			if !revocation.ExpiresAt.After(now) {
+				delete(s.revocations, id)
+				deleted++
+			}
+		}
+
+		return nil
+	})
+
+	return deleted, err
+}
@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/users"
+)
+
+// ensures that usersDB implements users.DB.
+var _ users.DB = (*usersDB)(nil)
+
+// ErrUsers indicates that there was an error in the database.
+var ErrUsers = errs.Class("users repository error")
+
+// usersDB provides access to users db.
+//
+// architecture: Database
+type usersDB struct {
+	db *database
+}
+
+// WithTx runs fn in a transaction.
+func (usersDB *usersDB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return usersDB.db.WithTx(ctx, fn)
+}
+
+// Create creates a user and writes to the database.
+func (usersDB *usersDB) Create(ctx context.Context, user *users.User) error {
+	return usersDB.db.write(func(s *state) error {
+		if _, ok := s.users[user.ID]; ok {
+			return ErrUsers.New("user %s already exists", user.ID)
+		}
+		for _, existing := range s.users {
+			if strings.EqualFold(existing.Email, user.Email) {
+				return ErrUsers.New("user with email %s already exists", user.Email)
+			}
+		}
+
+		created := *user
+		created.PasswordHash = append([]byte(nil), user.PasswordHash...)
+		s.users[user.ID] = created
+
+		return nil
+	})
+}
+
+// Get returns user by id.
+func (usersDB *usersDB) Get(ctx context.Context, id uuid.UUID) (*users.User, error) {
+	var user users.User
+	var ok bool
+	usersDB.db.read(func(s *state) {
+		user, ok = s.users[id]
+	})
+	if !ok {
+		return &user, users.ErrNoUser.New("")
+	}
+
+	return &user, nil
+}
+
+// GetByEmail returns user by email.
+func (usersDB *usersDB) GetByEmail(ctx context.Context, email string) (*users.User, error) {
+	var found *users.User
+	usersDB.db.read(func(s *state) {
+		for _, user := range s.users {
+			if user.Email == email {
+				found = &user
+				return
+			}
+		}
+	})
+	if found == nil {
+		return new(users.User), users.ErrNoUser.New("")
+	}
+
+	return found, nil
+}
+
+// UpdateLastLogin updates last login time.
+func (usersDB *usersDB) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return usersDB.update(id, func(user *users.User) {
+		user.LastLogin = time.Now().UTC()
+	})
+}
+
+// UpdatePassword replaces password hash of the user.
+func (usersDB *usersDB) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash []byte) error {
+	return usersDB.update(id, func(user *users.User) {
+		user.PasswordHash = append([]byte(nil), passwordHash...)
+	})
+}
+
+// UpdateStatus replaces status of the user.
+func (usersDB *usersDB) UpdateStatus(ctx context.Context, id uuid.UUID, status users.Status) error {
+	return usersDB.update(id, func(user *users.User) {
+		user.Status = status
+	})
+}
+
+// update applies fn to the user with such id.
+func (usersDB *usersDB) update(id uuid.UUID, fn func(user *users.User)) error {
+	return usersDB.db.write(func(s *state) error {
+		user, ok := s.users[id]
+		if !ok {
+			return users.ErrNoUser.New("")
+		}
+
+		fn(&user)
+		s.users[id] = user
+
+		return nil
+	})
+}
+
+// List returns a page of users matching the query, newest first unless query sorts them.
+func (usersDB *usersDB) List(ctx context.Context, pagination *util.PaginationReq, query *util.ListQuery) ([]users.User, error) {
+	list := usersDB.filter(query)
+
+	sorting := query
+	if !query.IsSorted() {
+		sorting = &util.ListQuery{Sort: []util.Sort{{Field: "createdAt", Desc: true}}}
+	}
+	sortRows(list, sorting, userFields, func(user users.User) uuid.UUID { return user.ID })
+
+	return append(make([]users.User, 0), page(list, pagination)...), nil
+}
+
+// Count returns number of users matching the query.
+func (usersDB *usersDB) Count(ctx context.Context, query *util.ListQuery) (uint64, error) {
+	return uint64(len(usersDB.filter(query))), nil
+}
+
+// filter returns all users matching the query.
+func (usersDB *usersDB) filter(query *util.ListQuery) []users.User {
+	var list []users.User
+	usersDB.db.read(func(s *state) {
+		for _, user := range s.users {
+			if matches(query, userFields(user)) {
+				list = append(list, user)
+			}
+		}
+	})
+
+	return list
+}
+
+// userFields returns values of users list fields.
+func userFields(user users.User) fieldGetter {
+	return func(field string) interface{} {
+		switch field {
+		case "email":
+			return user.Email
+		case "name":
+			return user.Name
+		case "status":
+			return string(user.Status)
+		case "lastLogin":
+			return user.LastLogin
+		case "createdAt":
+			return user.CreatedAt
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/database/migrations"
+)
+
+// ErrMigrate indicates that there was an error while migrating the database.
+var ErrMigrate = errs.Class("migrate error")
+
+// migrationsLockID is a key of postgres advisory lock, which prevents concurrent instances from migrating at once.
+const migrationsLockID = 7305813129
+
+const createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER                  PRIMARY KEY NOT NULL,
+    name       VARCHAR                  NOT NULL,
+    checksum   VARCHAR                  NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL
+)`
+
+// appliedMigration describes a row of schema_migrations table.
+type appliedMigration struct {
+	version   int
+	checksum  string
+	appliedAt time.Time
+}
+
+// MigrateUp applies all pending migrations in order.
+func (db *database) MigrateUp(ctx context.Context) error {
+	return db.withMigrationLock(ctx, func(conn *pgxpool.Conn, list []migrations.Migration, applied map[int]appliedMigration) error {
+		for _, migration := range list {
+			if existing, ok := applied[migration.Version]; ok {
+				if existing.checksum != migration.Checksum {
+					return ErrMigrate.New("applied migration %d_%s was modified", migration.Version, migration.Name)
+				}
+				continue
+			}
+
+			err := inTx(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+					migration.Version, migration.Name, migration.Checksum, time.Now().UTC())
+				return err
+			})
+			if err != nil {
+				return ErrMigrate.New("could not apply migration %d_%s: %v", migration.Version, migration.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// MigrateDown rolls back given number of latest applied migrations.
+func (db *database) MigrateDown(ctx context.Context, steps int) error {
+	return db.withMigrationLock(ctx, func(conn *pgxpool.Conn, list []migrations.Migration, applied map[int]appliedMigration) error {
+		for i := len(list) - 1; i >= 0 && steps > 0; i-- {
+			migration := list[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			if migration.Down == "" {
+				return ErrMigrate.New("migration %d_%s can't be rolled back", migration.Version, migration.Name)
+			}
+
+			err := inTx(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Down); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return ErrMigrate.New("could not roll back migration %d_%s: %v", migration.Version, migration.Name, err)
+			}
+
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// MigrationStatus returns state of all known migrations.
+func (db *database) MigrationStatus(ctx context.Context) ([]migrations.Status, error) {
+	list, err := migrations.Load()
+	if err != nil {
+		return nil, ErrMigrate.Wrap(err)
+	}
+
+	conn, err := db.pool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrMigrate.Wrap(err)
+	}
+	defer conn.Release()
+
+	applied, err := listAppliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]migrations.Status, 0, len(list))
+	for _, migration := range list {
+		status := migrations.Status{Version: migration.Version, Name: migration.Name}
+		if existing, ok := applied[migration.Version]; ok {
+			appliedAt := existing.appliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Modified = existing.checksum != migration.Checksum
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// withMigrationLock runs fn holding migrations advisory lock on a dedicated connection.
+func (db *database) withMigrationLock(ctx context.Context, fn func(conn *pgxpool.Conn, list []migrations.Migration, applied map[int]appliedMigration) error) (err error) {
+	list, err := migrations.Load()
+	if err != nil {
+		return ErrMigrate.Wrap(err)
+	}
+
+	conn, err := db.pool.Acquire(ctx)
+	if err != nil {
+		return ErrMigrate.Wrap(err)
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockID); err != nil {
+		return ErrMigrate.Wrap(err)
+	}
+	defer func() {
+		_, unlockErr := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationsLockID)
+		err = errs.Combine(err, ErrMigrate.Wrap(unlockErr))
+	}()
+
+	if _, err = conn.Exec(ctx, createMigrationsTable); err != nil {
+		return ErrMigrate.Wrap(err)
+	}
+
+	applied, err := listAppliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, list, applied)
+}
+
+// listAppliedMigrations returns applied migrations by their versions.
+func listAppliedMigrations(ctx context.Context, conn *pgxpool.Conn) (map[int]appliedMigration, error) {
+	applied := make(map[int]appliedMigration)
+
+	var exists bool
+	err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil || !exists {
+		return applied, ErrMigrate.Wrap(err)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, ErrMigrate.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var migration appliedMigration
+		if err = rows.Scan(&migration.version, &migration.checksum, &migration.appliedAt); err != nil {
+			return nil, ErrMigrate.Wrap(err)
+		}
+
+		applied[migration.version] = migration
+	}
+
+	return applied, ErrMigrate.Wrap(rows.Err())
+}
+
+// inTx runs fn in a transaction on the connection.
+func inTx(ctx context.Context, conn *pgxpool.Conn, fn func(tx pgx.Tx) error) (err error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		err = tx.Commit(ctx)
+	}()
+
+	return fn(tx)
+}
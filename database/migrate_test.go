@@ -0,0 +1,249 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"kitchen_nerd/database/migrations"
+)
+
+// assertApplied checks which of the migrations are applied, versions are listed in order.
+func assertApplied(ctx context.Context, t *testing.T, db *database, versions ...int) {
+	t.Helper()
+
+	statuses, err := db.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var applied []int
+	for _, status := range statuses {
+		if status.Modified {
+			t.Fatalf("unexpected modified migration %+v", status)
+		}
+		if status.Applied != (status.AppliedAt != nil) {
+			t.Fatalf("unexpected applied time of migration %+v", status)
+		}
+		if status.Applied {
+			applied = append(applied, status.Version)
+		}
+	}
+
+	if len(applied) != len(versions) {
+		t.Fatalf("expected applied migrations %v, got %v", versions, applied)
+	}
+	for i := range versions {
+		if applied[i] != versions[i] {
+			t.Fatalf("expected applied migrations %v, got %v", versions, applied)
+		}
+	}
+}
+
+// migrationVersions returns versions of all known migrations in order.
+func migrationVersions(t *testing.T) []int {
+	t.Helper()
+
+	list, err := migrations.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions := make([]int, 0, len(list))
+	for _, migration := range list {
+		versions = append(versions, migration.Version)
+	}
+
+	return versions
+}
+
+// tableExists checks if table exists in the test schema.
+func tableExists(ctx context.Context, t *testing.T, db *database, table string) bool {
+	t.Helper()
+
+	var exists bool
+	if err := db.pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+
+	return exists
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(ctx, t)
+
+	all := migrationVersions(t)
+	last := len(all) - 1
+
+	assertApplied(ctx, t, db, all...)
+
+	// applying migrations again does nothing.
+	if err := db.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertApplied(ctx, t, db, all...)
+
+	if err := db.MigrateDown(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertApplied(ctx, t, db, all[:last]...)
+	if tableExists(ctx, t, db, "rate_limits") {
+		t.Fatal("expected rate_limits table to be dropped")
+	}
+
+	if err := db.MigrateDown(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	assertApplied(ctx, t, db, all[:last-2]...)
+
+	if err := db.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertApplied(ctx, t, db, all...)
+	if !tableExists(ctx, t, db, "rate_limits") {
+		t.Fatal("expected rate_limits table to be created")
+	}
+
+	// rolling back more steps than applied stops at the first migration.
+	if err := db.MigrateDown(ctx, len(all)+1); err != nil {
+		t.Fatal(err)
+	}
+	assertApplied(ctx, t, db)
+	if tableExists(ctx, t, db, "users") {
+		t.Fatal("expected users table to be dropped")
+	}
+}
+
+func TestMigrateModified(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(ctx, t)
+
+	if _, err := db.pool.Exec(ctx, `UPDATE schema_migrations SET checksum = 'changed' WHERE version = 1`); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := db.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Modified != (status.Version == 1) {
+			t.Fatalf("unexpected modified status %+v", status)
+		}
+	}
+
+	if err = db.MigrateDown(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.MigrateUp(ctx); !ErrMigrate.Has(err) {
+		t.Fatalf("expected modified migration to stop migrating up, got %v", err)
+	}
+	if tableExists(ctx, t, db, "rate_limits") {
+		t.Fatal("expected pending migration not to be applied")
+	}
+}
+
+func TestMigrateFailedMigrationIsRolledBack(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(ctx, t)
+
+	versions := migrationVersions(t)
+
+	// 0007 drops not null constraint of recipes.created_at and fails on the following update.
+	if err := db.MigrateDown(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	_, err := db.pool.Exec(ctx, `
+		CREATE FUNCTION fail_recipes_update() RETURNS TRIGGER AS $$
+		BEGIN
+			RAISE EXCEPTION 'recipes are read-only';
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER fail_recipes_update BEFORE UPDATE ON recipes
+			FOR EACH STATEMENT EXECUTE FUNCTION fail_recipes_update();`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = db.MigrateUp(ctx); !ErrMigrate.Has(err) {
+		t.Fatalf("expected failed migration error, got %v", err)
+	}
+	assertApplied(ctx, t, db, versions[:len(versions)-2]...)
+
+	var nullable string
+	err = db.pool.QueryRow(ctx, `SELECT is_nullable FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'recipes' AND column_name = 'created_at'`).Scan(&nullable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nullable != "NO" {
+		t.Fatal("expected statements of failed migration to be rolled back")
+	}
+
+	if _, err = db.pool.Exec(ctx, `DROP TRIGGER fail_recipes_update ON recipes`); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertApplied(ctx, t, db, versions...)
+}
+
+func TestMigrateLock(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(ctx, t)
+
+	conn, err := db.pool.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockID); err != nil {
+		t.Fatal(err)
+	}
+
+	// migrations wait while other instance holds the lock.
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if err = db.MigrateDown(waitCtx, 1); err == nil {
+		t.Fatal("expected migrations to wait for the lock")
+	}
+	assertApplied(ctx, t, db, migrationVersions(t)...)
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, migrationsLockID); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.MigrateDown(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// concurrent instances apply every migration exactly once.
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = db.MigrateUp(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count int
+	if err = db.pool.QueryRow(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(migrationVersions(t)) {
+		t.Fatalf("expected every migration to be applied once, got %d applied", count)
+	}
+}
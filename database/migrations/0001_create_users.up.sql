@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS users (
+    id            UUID                     PRIMARY KEY NOT NULL,
+    email         VARCHAR                  NOT NULL,
+    status        VARCHAR                  NOT NULL,
+    name          VARCHAR                  NOT NULL,
+    password_hash BYTEA                    NOT NULL,
+    last_login    TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS users_tokens (
+    id         UUID                     PRIMARY KEY NOT NULL,
+    user_id    UUID                     NOT NULL,
+    token      VARCHAR                  UNIQUE NOT NULL,
+    expired_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
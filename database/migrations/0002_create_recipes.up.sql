@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS recipes (
+    id           UUID        PRIMARY KEY,
+    title        VARCHAR     NOT NULL,
+    photo        VARCHAR,
+    description  TEXT,
+    instructions TEXT,
+    created_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ingredients (
+    id   UUID    PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    unit VARCHAR NOT NULL,
+    UNIQUE (name, unit)
+);
+
+CREATE TABLE IF NOT EXISTS recipe_ingredients (
+    id        UUID    PRIMARY KEY,
+    name      VARCHAR NOT NULL,
+    recipe_id UUID REFERENCES recipes(id),
+    quantity  DOUBLE PRECISION,
+    unit      VARCHAR,
+    optional  BOOLEAN,
+    UNIQUE (name, recipe_id)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS tokens_revocations (
+    id         UUID                     PRIMARY KEY NOT NULL,
+    token_id   VARCHAR                  NOT NULL,
+    user_id    UUID,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
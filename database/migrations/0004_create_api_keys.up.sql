@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           UUID                     PRIMARY KEY NOT NULL,
+    user_id      UUID                     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         VARCHAR                  NOT NULL,
+    prefix       VARCHAR                  NOT NULL,
+    key_hash     BYTEA                    UNIQUE NOT NULL,
+    scope        VARCHAR                  NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at   TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL
+);
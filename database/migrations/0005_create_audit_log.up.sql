@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id          UUID                     PRIMARY KEY NOT NULL,
+    actor_id    UUID,
+    action      VARCHAR                  NOT NULL,
+    target_type VARCHAR                  NOT NULL,
+    target_id   VARCHAR                  NOT NULL,
+    ip          VARCHAR                  NOT NULL,
+    user_agent  VARCHAR                  NOT NULL,
+    before      JSONB,
+    after       JSONB,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
+
+-- audit log is append-only.
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
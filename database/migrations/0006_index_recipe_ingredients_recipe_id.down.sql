@@ -0,0 +1 @@
+DROP INDEX IF EXISTS recipe_ingredients_recipe_id_idx;
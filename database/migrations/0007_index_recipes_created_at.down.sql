@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS recipes_created_at_id_idx;
+
+ALTER TABLE recipes ALTER COLUMN created_at DROP NOT NULL;
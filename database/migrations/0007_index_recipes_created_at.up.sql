@@ -0,0 +1,4 @@
+UPDATE recipes SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+ALTER TABLE recipes ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS recipes_created_at_id_idx ON recipes (created_at DESC, id DESC);
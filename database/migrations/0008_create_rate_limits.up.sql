@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key        VARCHAR                  PRIMARY KEY NOT NULL,
+    tokens     DOUBLE PRECISION         NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/zeebo/errs"
+)
+
+// ErrMigrations indicates that migrations are malformed.
+var ErrMigrations = errs.Class("migrations error")
+
+//go:embed *.sql
+var files embed.FS
+
+// fileName matches migration file names like 0001_create_users.up.sql.
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration describes a single schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	// Checksum is a hash of up script, it is used to detect changes of applied migrations.
+	Checksum string
+}
+
+// Status describes state of a migration in the database.
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt"`
+	// Modified reports that migration script was changed after it had been applied.
+	Modified bool `json:"modified"`
+}
+
+// Load returns all embedded postgres migrations ordered by version.
+func Load() ([]Migration, error) {
+	return LoadFS(files)
+}
+
+// LoadFS returns all migrations from root of fsys ordered by version.
+func LoadFS(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, ErrMigrations.Wrap(err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, ErrMigrations.New("unexpected migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, ErrMigrations.Wrap(err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, ErrMigrations.Wrap(err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, ErrMigrations.New("migration %d has different names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			checksum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(checksum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, ErrMigrations.New("migration %d has no up script", migration.Version)
+		}
+		list = append(list, *migration)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+
+	return list, nil
+}
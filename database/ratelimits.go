@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/ratelimit"
+)
+
+// ensures that rateLimitsDB implements ratelimit.DB.
+var _ ratelimit.DB = (*rateLimitsDB)(nil)
+
+// ErrRateLimits indicates that there was an error in the database.
+var ErrRateLimits = errs.Class("rate limits repository error")
+
+// rateLimitsDB provides access to rate limits db, which is shared by all instances.
+//
+// architecture: Database
+type rateLimitsDB struct {
+	pool *pgxpool.Pool
+}
+
+// Take takes a token from the bucket stored by key. Bucket row is locked while the decision is made,
+// so concurrent requests of different instances can't take the same token.
+func (rateLimitsDB *rateLimitsDB) Take(
+	ctx context.Context, key string, policy ratelimit.Policy, now time.Time,
+) (result ratelimit.Result, err error) {
+	err = withTx(ctx, rateLimitsDB.pool, func(ctx context.Context) error {
+		// new bucket is full, the first request takes from it the same way as the following ones.
+		query := `INSERT INTO rate_limits (key, tokens, updated_at)
+		          VALUES ($1, $2, $3)
+		          ON CONFLICT (key) DO NOTHING`
+
+		_, err := conn(ctx, rateLimitsDB.pool).Exec(ctx, query, key, float64(policy.Burst), now)
+		if err != nil {
+			return err
+		}
+
+		// bucket is refilled up to the burst for the time passed, see ratelimit.Policy.Take.
+		query = `WITH bucket AS (
+		             SELECT LEAST($2::float8, tokens +
+		                 GREATEST(0, EXTRACT(EPOCH FROM ($4::timestamptz - updated_at))::float8) * $3::float8) AS tokens
+		             FROM rate_limits
+		             WHERE key = $1
+		             FOR UPDATE
+		         )
+		         UPDATE rate_limits SET
+		             tokens = bucket.tokens - CASE WHEN bucket.tokens >= 1 THEN 1 ELSE 0 END,
+		             updated_at = GREATEST(rate_limits.updated_at, $4::timestamptz)
+		         FROM bucket
+		         WHERE rate_limits.key = $1
+		         RETURNING rate_limits.tokens, bucket.tokens >= 1`
+
+		var (
+			tokens  float64
+			allowed bool
+		)
+		err = conn(ctx, rateLimitsDB.pool).
+			QueryRow(ctx, query, key, float64(policy.Burst), policy.Rate(), now).
+			Scan(&tokens, &allowed)
+		if err != nil {
+			return err
+		}
+
+		result = policy.Result(allowed, tokens)
+		return nil
+	})
+
+	return result, ErrRateLimits.Wrap(err)
+}
+
+// DeleteStale removes buckets which were not updated since given time and returns their number.
+func (rateLimitsDB *rateLimitsDB) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM rate_limits
+	          WHERE updated_at < $1`
+
+	res, err := conn(ctx, rateLimitsDB.pool).Exec(ctx, query, before)
+	if err != nil {
+		return 0, ErrRateLimits.Wrap(err)
+	}
+
+	return res.RowsAffected(), nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/zeebo/errs"
+	"kitchen_nerd/database/listquery"
 	"kitchen_nerd/pkg/util"
 	"time"
 
@@ -24,17 +27,35 @@ type recipesDB struct {
 	pool *pgxpool.Pool
 }
 
+// WithTx runs fn in a transaction.
+func (s *recipesDB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, s.pool, fn)
+}
+
 func (s *recipesDB) CreateIngredient(ctx context.Context, ingredient recipes.RecipeIngredient) error {
 	query := `INSERT INTO recipe_ingredients (id, name, recipe_id, quantity, unit, optional) 
 	          VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := s.pool.Exec(ctx, query, uuid.New(), ingredient.Name, ingredient.RecipeID, ingredient.Quantity, ingredient.Unit, ingredient.Optional)
+	_, err := conn(ctx, s.pool).Exec(ctx, query, uuid.New(), ingredient.Name, ingredient.RecipeID, ingredient.Quantity, ingredient.Unit, ingredient.Optional)
 	return err
 }
 
-func (s *recipesDB) Count(ctx context.Context) (uint64, error) {
-	query := `SELECT COUNT(id) FROM recipes`
+// recipeColumns maps recipes list fields to columns.
+var recipeColumns = listquery.Columns{
+	"title":       "title",
+	"description": "description",
+	"createdAt":   "created_at",
+}
+
+// Count returns number of recipes matching the query.
+func (s *recipesDB) Count(ctx context.Context, listQuery *util.ListQuery) (uint64, error) {
+	conditions, args, err := recipeColumns.Where(listQuery, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `SELECT COUNT(id) FROM recipes ` + listquery.WhereClause(conditions)
 	var count uint64
-	if err := s.pool.QueryRow(ctx, query).Scan(&count); err != nil {
+	if err := conn(ctx, s.pool).QueryRow(ctx, query, args...).Scan(&count); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, recipes.ErrNoRecipe.New("")
 		}
@@ -44,36 +65,119 @@ func (s *recipesDB) Count(ctx context.Context) (uint64, error) {
 	return count, nil
 }
 
-// List returns all recipes from the database.
-func (s *recipesDB) List(ctx context.Context, pagination *util.PaginationReq) ([]*recipes.Recipe, error) {
+// List returns a page of recipes matching the query from the database, newest first unless query sorts them,
+// along with cursors of neighbouring pages. Pages are selected either by offset or by keyset, when pagination has a cursor.
+// Keyset pagination relies on default order, so cursors are not returned for custom sorting.
+func (s *recipesDB) List(ctx context.Context, pagination *util.PaginationReq, listQuery *util.ListQuery) ([]*recipes.Recipe, util.Cursors, error) {
+	var cursors util.Cursors
+
+	// one extra row is selected to find out if there is a further page.
+	conditions, args, err := recipeColumns.Where(listQuery, []interface{}{pagination.Size + 1})
+	if err != nil {
+		return nil, cursors, err
+	}
+
+	var orderBy, offset string
+	cursor := pagination.Cursor
+	switch {
+	case cursor == nil:
+		if orderBy, err = recipeColumns.OrderBy(listQuery, "created_at DESC, id DESC"); err != nil {
+			return nil, cursors, err
+		}
+		args = append(args, pagination.GetDBOffset())
+		offset = fmt.Sprintf(" OFFSET $%d", len(args))
+	case cursor.Backward:
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) > ($%d, $%d)", len(args)-1, len(args)))
+		orderBy = "ORDER BY created_at, id"
+	default:
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+		orderBy = "ORDER BY created_at DESC, id DESC"
+	}
+
 	query := `SELECT id, title, photo, description, instructions, created_at 
-	          FROM recipes 
-	          LIMIT $1 
-	          OFFSET $2`
-	rows, err := s.pool.Query(ctx, query, pagination.Size, pagination.GetDBOffset())
+	          FROM recipes ` + listquery.WhereClause(conditions) + orderBy + ` LIMIT $1` + offset
+
+	rows, err := conn(ctx, s.pool).Query(ctx, query, args...)
 	if err != nil {
-		return nil, ErrRecipes.Wrap(err)
+		return nil, cursors, ErrRecipes.Wrap(err)
 	}
 	defer rows.Close()
 
 	var list []*recipes.Recipe
+	var ids []uuid.UUID
 	for rows.Next() {
 
 		recipe := new(recipes.Recipe)
 		if err = rows.Scan(&recipe.ID, &recipe.Title, &recipe.PhotoBase64, &recipe.Description, &recipe.Instructions, &recipe.CreatedAt); err != nil {
-			return nil, ErrRecipes.Wrap(err)
+			return nil, cursors, ErrRecipes.Wrap(err)
 		}
 
-		ingredients, err := s.GetIngredients(ctx, recipe.ID)
-		if err != nil {
+		list = append(list, recipe)
+		ids = append(ids, recipe.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, cursors, ErrRecipes.Wrap(err)
+	}
+	rows.Close()
+
+	hasMore := uint64(len(list)) > pagination.Size
+	if hasMore {
+		list, ids = list[:pagination.Size], ids[:pagination.Size]
+	}
+
+	if cursor != nil && cursor.Backward {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+
+	if len(list) > 0 && !listQuery.IsSorted() {
+		first, last := list[0], list[len(list)-1]
+		cursors = util.PageCursors(pagination, hasMore,
+			util.Cursor{CreatedAt: first.CreatedAt, ID: first.ID}, util.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	// ingredients of the whole page are fetched at once, after recipes cursor is closed.
+	ingredients, err := s.getIngredientsByRecipes(ctx, ids)
+	if err != nil {
+		return nil, cursors, err
+	}
+
+	for _, recipe := range list {
+		recipe.Ingredients = ingredients[recipe.ID]
+	}
+
+	return list, cursors, nil
+}
+
+// getIngredientsByRecipes returns ingredients of all given recipes grouped by recipe ID.
+func (s *recipesDB) getIngredientsByRecipes(ctx context.Context, recipeIDs []uuid.UUID) (map[uuid.UUID][]recipes.RecipeIngredient, error) {
+	ingredients := make(map[uuid.UUID][]recipes.RecipeIngredient, len(recipeIDs))
+	if len(recipeIDs) == 0 {
+		return ingredients, nil
+	}
+
+	query := `SELECT id, name, recipe_id, quantity, unit, optional
+	          FROM recipe_ingredients
+	          WHERE recipe_id = ANY($1)`
+	rows, err := conn(ctx, s.pool).Query(ctx, query, recipeIDs)
+	if err != nil {
+		return nil, ErrRecipes.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ingredient recipes.RecipeIngredient
+		if err = rows.Scan(&ingredient.ID, &ingredient.Name, &ingredient.RecipeID, &ingredient.Quantity, &ingredient.Unit, &ingredient.Optional); err != nil {
 			return nil, ErrRecipes.Wrap(err)
 		}
 
-		recipe.Ingredients = ingredients
-		list = append(list, recipe)
+		ingredients[ingredient.RecipeID] = append(ingredients[ingredient.RecipeID], ingredient)
 	}
 
-	return list, nil
+	return ingredients, ErrRecipes.Wrap(rows.Err())
 }
 
 // GetIngredients returns a list of ingredients by recipe ID.
@@ -81,7 +185,7 @@ func (s *recipesDB) GetIngredients(ctx context.Context, recipeID uuid.UUID) ([]r
 	query := `SELECT id, name, recipe_id, quantity, unit, optional
 	          FROM recipe_ingredients
 	          WHERE recipe_id = $1`
-	rows, err := s.pool.Query(ctx, query, recipeID)
+	rows, err := conn(ctx, s.pool).Query(ctx, query, recipeID)
 	if err != nil {
 		return nil, ErrRecipes.Wrap(err)
 	}
@@ -101,14 +205,23 @@ func (s *recipesDB) GetIngredients(ctx context.Context, recipeID uuid.UUID) ([]r
 	return ingredients, nil
 }
 
-// GetRecipe returns a recipe by its ID from the database.
+// GetRecipe returns a recipe with its ingredients by its ID from the database.
 func (s *recipesDB) GetRecipe(ctx context.Context, id uuid.UUID) (*recipes.Recipe, error) {
-	var recipe *recipes.Recipe
-	err := s.pool.QueryRow(ctx, "SELECT * FROM recipes WHERE id = $1", id).Scan(&recipe.ID, &recipe.Title, &recipe.PhotoBase64, &recipe.Description, &recipe.Instructions, &recipe.CreatedAt)
+	recipe := new(recipes.Recipe)
+	err := conn(ctx, s.pool).QueryRow(ctx, "SELECT id, title, photo, description, instructions, created_at FROM recipes WHERE id = $1", id).Scan(&recipe.ID, &recipe.Title, &recipe.PhotoBase64, &recipe.Description, &recipe.Instructions, &recipe.CreatedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, recipes.ErrNoRecipe.Wrap(err)
+		}
 		return nil, ErrRecipes.Wrap(err)
 	}
 
+	ingredients, err := s.getIngredientsByRecipes(ctx, []uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	recipe.Ingredients = ingredients[id]
+
 	return recipe, nil
 }
 
@@ -116,7 +229,7 @@ func (s *recipesDB) GetRecipe(ctx context.Context, id uuid.UUID) (*recipes.Recip
 func (s *recipesDB) CreateRecipe(ctx context.Context, recipe *recipes.Recipe) error {
 	recipe.CreatedAt = time.Now()
 
-	_, err := s.pool.Exec(ctx, "INSERT INTO recipes (id, title, photo, description, instructions, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+	_, err := conn(ctx, s.pool).Exec(ctx, "INSERT INTO recipes (id, title, photo, description, instructions, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
 		recipe.ID, recipe.Title, recipe.PhotoBase64, recipe.Description, recipe.Instructions, recipe.CreatedAt)
 	if err != nil {
 		return ErrRecipes.Wrap(err)
@@ -125,30 +238,23 @@ func (s *recipesDB) CreateRecipe(ctx context.Context, recipe *recipes.Recipe) er
 	return nil
 }
 
-// UpdateRecipe updates a recipe in the database.
+// UpdateRecipe writes all fields of a recipe to the database, empty fields are cleared.
 func (s *recipesDB) UpdateRecipe(ctx context.Context, updatedRecipe *recipes.Recipe) error {
-	var existingRecipe *recipes.Recipe
-	err := s.pool.QueryRow(ctx, "SELECT * FROM recipes WHERE id = $1", updatedRecipe.ID).Scan(&existingRecipe.ID, &existingRecipe.Title, &existingRecipe.PhotoBase64, &existingRecipe.Description, &existingRecipe.Instructions, &existingRecipe.CreatedAt)
+	res, err := conn(ctx, s.pool).Exec(ctx, "UPDATE recipes SET title = $2, photo = $3, description = $4, instructions = $5 WHERE id = $1",
+		updatedRecipe.ID, updatedRecipe.Title, updatedRecipe.PhotoBase64, updatedRecipe.Description, updatedRecipe.Instructions)
 	if err != nil {
 		return ErrRecipes.Wrap(err)
 	}
-
-	// Update only non-empty fields
-	if updatedRecipe.Title != "" {
-		existingRecipe.Title = updatedRecipe.Title
-	}
-	if updatedRecipe.PhotoBase64 != "" {
-		existingRecipe.PhotoBase64 = updatedRecipe.PhotoBase64
-	}
-	if updatedRecipe.Description != "" {
-		existingRecipe.Description = updatedRecipe.Description
-	}
-	if updatedRecipe.Instructions != "" {
-		existingRecipe.Instructions = updatedRecipe.Instructions
+	if res.RowsAffected() == 0 {
+		return recipes.ErrNoRecipe.New("")
 	}
 
-	_, err = s.pool.Exec(context.TODO(), "UPDATE recipes SET title = $2, photo = $3, description = $4, instructions = $5 WHERE id = $1",
-		existingRecipe.ID, existingRecipe.Title, existingRecipe.PhotoBase64, existingRecipe.Description, existingRecipe.Instructions)
+	return nil
+}
+
+// DeleteIngredients deletes all ingredients of a recipe from the database.
+func (s *recipesDB) DeleteIngredients(ctx context.Context, recipeID uuid.UUID) error {
+	_, err := conn(ctx, s.pool).Exec(ctx, "DELETE FROM recipe_ingredients WHERE recipe_id = $1", recipeID)
 	if err != nil {
 		return ErrRecipes.Wrap(err)
 	}
@@ -158,7 +264,7 @@ func (s *recipesDB) UpdateRecipe(ctx context.Context, updatedRecipe *recipes.Rec
 
 // DeleteRecipe deletes a recipe from the database.
 func (s *recipesDB) DeleteRecipe(ctx context.Context, id uuid.UUID) error {
-	_, err := s.pool.Exec(ctx, "DELETE FROM recipes WHERE id = $1", id)
+	_, err := conn(ctx, s.pool).Exec(ctx, "DELETE FROM recipes WHERE id = $1", id)
 	if err != nil {
 		return ErrRecipes.Wrap(err)
 	}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/recipes"
+)
+
+// ingredientsPerRecipe is the number of ingredients of every benchmark recipe.
+const ingredientsPerRecipe = 8
+
+// BenchmarkRecipesList measures listing latency for pages of 10, 50 and 100 recipes with ingredients.
+func BenchmarkRecipesList(b *testing.B) {
+	ctx := context.Background()
+	db := newTestDatabase(ctx, b)
+
+	recipesDB := db.Recipes()
+	for i := 0; i < 100; i++ {
+		recipe := recipes.NewRecipe(fmt.Sprintf("recipe %d", i), "", "description", "instructions")
+		if err := recipesDB.CreateRecipe(ctx, recipe); err != nil {
+			b.Fatal(err)
+		}
+
+		for j := 0; j < ingredientsPerRecipe; j++ {
+			ingredient := recipes.RecipeIngredient{
+				Name:     fmt.Sprintf("ingredient %d", j),
+				RecipeID: recipe.ID,
+				Quantity: float64(j + 1),
+				Unit:     string(recipes.Gram),
+			}
+			if err := recipesDB.CreateIngredient(ctx, ingredient); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	for _, size := range []uint64{10, 50, 100} {
+		b.Run(fmt.Sprintf("page=%d", size), func(b *testing.B) {
+			pagination := util.NewPaginationReq(size, 1)
+			for i := 0; i < b.N; i++ {
+				list, _, err := recipesDB.List(ctx, pagination, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if uint64(len(list)) != size {
+					b.Fatalf("expected %d recipes, got %d", size, len(list))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/database/migrations"
+)
+
+// ErrMigrate indicates that there was an error while migrating the database.
+var ErrMigrate = errs.Class("migrate error")
+
+// migrationFiles contains sqlite flavour of the schema, postgres migrations can't be applied to sqlite as is.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER   PRIMARY KEY NOT NULL,
+    name       TEXT      NOT NULL,
+    checksum   TEXT      NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`
+
+// appliedMigration describes a row of schema_migrations table.
+type appliedMigration struct {
+	version   int
+	checksum  string
+	appliedAt time.Time
+}
+
+// MigrateUp applies all pending migrations in order.
+func (db *database) MigrateUp(ctx context.Context) error {
+	list, applied, err := db.loadMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range list {
+		if existing, ok := applied[migration.Version]; ok {
+			if existing.checksum != migration.Checksum {
+				return ErrMigrate.New("applied migration %d_%s was modified", migration.Version, migration.Name)
+			}
+			continue
+		}
+
+		err = db.WithTx(ctx, func(ctx context.Context) error {
+			tx := conn(ctx, db.db)
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+				migration.Version, migration.Name, migration.Checksum, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return ErrMigrate.New("could not apply migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// MigrateDown rolls back given number of latest applied migrations.
+func (db *database) MigrateDown(ctx context.Context, steps int) error {
+	list, applied, err := db.loadMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := len(list) - 1; i >= 0 && steps > 0; i-- {
+		migration := list[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if migration.Down == "" {
+			return ErrMigrate.New("migration %d_%s can't be rolled back", migration.Version, migration.Name)
+		}
+
+		err = db.WithTx(ctx, func(ctx context.Context) error {
+			tx := conn(ctx, db.db)
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			return err
+		})
+		if err != nil {
+			return ErrMigrate.New("could not roll back migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+
+		steps--
+	}
+
+	return nil
+}
+
+// MigrationStatus returns state of all known migrations.
+func (db *database) MigrationStatus(ctx context.Context) ([]migrations.Status, error) {
+	list, applied, err := db.loadMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]migrations.Status, 0, len(list))
+	for _, migration := range list {
+		status := migrations.Status{Version: migration.Version, Name: migration.Name}
+		if existing, ok := applied[migration.Version]; ok {
+			appliedAt := existing.appliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Modified = existing.checksum != migration.Checksum
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// loadMigrations returns all known migrations and applied ones by their versions.
+// Sqlite has a single writer, so unlike postgres no lock is needed.
+func (db *database) loadMigrations(ctx context.Context) ([]migrations.Migration, map[int]appliedMigration, error) {
+	root, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, nil, ErrMigrate.Wrap(err)
+	}
+
+	list, err := migrations.LoadFS(root)
+	if err != nil {
+		return nil, nil, ErrMigrate.Wrap(err)
+	}
+
+	if _, err = db.db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return nil, nil, ErrMigrate.Wrap(err)
+	}
+
+	rows, err := db.db.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, nil, ErrMigrate.Wrap(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var migration appliedMigration
+		if err = rows.Scan(&migration.version, &migration.checksum, &migration.appliedAt); err != nil {
+			return nil, nil, ErrMigrate.Wrap(err)
+		}
+
+		applied[migration.version] = migration
+	}
+
+	return list, applied, ErrMigrate.Wrap(rows.Err())
+}
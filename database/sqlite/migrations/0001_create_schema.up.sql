@@ -0,0 +1,83 @@
+CREATE TABLE IF NOT EXISTS users (
+    id            TEXT      PRIMARY KEY NOT NULL,
+    email         TEXT      NOT NULL,
+    status        TEXT      NOT NULL,
+    name          TEXT      NOT NULL,
+    password_hash BLOB      NOT NULL,
+    last_login    TIMESTAMP NOT NULL,
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS users_tokens (
+    id         TEXT      PRIMARY KEY NOT NULL,
+    user_id    TEXT      NOT NULL,
+    token      TEXT      UNIQUE NOT NULL,
+    expired_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recipes (
+    id           TEXT      PRIMARY KEY NOT NULL,
+    title        TEXT      NOT NULL,
+    photo        TEXT,
+    description  TEXT,
+    instructions TEXT,
+    created_at   TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS recipes_created_at_id_idx ON recipes (created_at DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS recipe_ingredients (
+    id        TEXT    PRIMARY KEY NOT NULL,
+    name      TEXT    NOT NULL,
+    recipe_id TEXT    REFERENCES recipes(id),
+    quantity  DOUBLE PRECISION,
+    unit      TEXT,
+    optional  BOOLEAN,
+    UNIQUE (name, recipe_id)
+);
+CREATE INDEX IF NOT EXISTS recipe_ingredients_recipe_id_idx ON recipe_ingredients (recipe_id);
+
+CREATE TABLE IF NOT EXISTS tokens_revocations (
+    id         TEXT      PRIMARY KEY NOT NULL,
+    token_id   TEXT      NOT NULL,
+    user_id    TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           TEXT      PRIMARY KEY NOT NULL,
+    user_id      TEXT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         TEXT      NOT NULL,
+    prefix       TEXT      NOT NULL,
+    key_hash     BLOB      UNIQUE NOT NULL,
+    scope        TEXT      NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id          TEXT      PRIMARY KEY NOT NULL,
+    actor_id    TEXT,
+    action      TEXT      NOT NULL,
+    target_type TEXT      NOT NULL,
+    target_id   TEXT      NOT NULL,
+    ip          TEXT      NOT NULL,
+    user_agent  TEXT      NOT NULL,
+    before      TEXT,
+    after       TEXT,
+    created_at  TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
+
+-- audit log is append-only.
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
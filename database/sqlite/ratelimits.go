@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/ratelimit"
+)
+
+// ensures that rateLimitsDB implements ratelimit.DB.
+var _ ratelimit.DB = (*rateLimitsDB)(nil)
+
+// ErrRateLimits indicates that there was an error in the database.
+var ErrRateLimits = errs.Class("rate limits repository error")
+
+// rateLimitsDB provides access to rate limits db.
+//
+// architecture: Database
+type rateLimitsDB struct {
+	db *sql.DB
+}
+
+// Take takes a token from the bucket stored by key, sqlite has a single writer, so a transaction is enough.
+func (rateLimitsDB *rateLimitsDB) Take(
+	ctx context.Context, key string, policy ratelimit.Policy, now time.Time,
+) (result ratelimit.Result, err error) {
+	err = withTx(ctx, rateLimitsDB.db, func(ctx context.Context) error {
+		var existing *ratelimit.Bucket
+
+		var bucket ratelimit.Bucket
+		err := conn(ctx, rateLimitsDB.db).
+			QueryRowContext(ctx, `SELECT tokens, updated_at FROM rate_limits WHERE key = $1`, key).
+			Scan(&bucket.Tokens, &bucket.UpdatedAt)
+		switch {
+		case err == nil:
+			existing = &bucket
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+
+		bucket, result = policy.Take(existing, now)
+
+		_, err = conn(ctx, rateLimitsDB.db).ExecContext(ctx,
+			`INSERT INTO rate_limits (key, tokens, updated_at) VALUES ($1, $2, $3)
+			 ON CONFLICT (key) DO UPDATE SET tokens = excluded.tokens, updated_at = excluded.updated_at`,
+			key, bucket.Tokens, bucket.UpdatedAt.UTC())
+		return err
+	})
+
+	return result, ErrRateLimits.Wrap(err)
+}
+
+// DeleteStale removes buckets which were not updated since given time and returns their number.
+func (rateLimitsDB *rateLimitsDB) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	result, err := conn(ctx, rateLimitsDB.db).ExecContext(ctx, `DELETE FROM rate_limits WHERE updated_at < $1`, before.UTC())
+	if err != nil {
+		return 0, ErrRateLimits.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	return affected, ErrRateLimits.Wrap(err)
+}
@@ -0,0 +1,278 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/database/listquery"
+	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/recipes"
+)
+
+// ensures that recipesDB implements recipes.DB.
+var _ recipes.DB = (*recipesDB)(nil)
+
+// ErrRecipes indicates that there was an error in the database.
+var ErrRecipes = errs.Class("recipes repository error")
+
+const (
+	recipeFields     = "id, title, photo, description, instructions, created_at"
+	ingredientFields = "id, name, recipe_id, quantity, unit, optional"
+)
+
+// recipeColumns maps recipes list fields to columns.
+var recipeColumns = listquery.Columns{
+	"title":       "title",
+	"description": "description",
+	"createdAt":   "created_at",
+}
+
+// recipesDB provides access to recipes db.
+//
+// architecture: Database
+type recipesDB struct {
+	db *sql.DB
+}
+
+// WithTx runs fn in a transaction.
+func (recipesDB *recipesDB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, recipesDB.db, fn)
+}
+
+// CreateRecipe adds a new recipe to the database.
+func (recipesDB *recipesDB) CreateRecipe(ctx context.Context, recipe *recipes.Recipe) error {
+	recipe.CreatedAt = time.Now().UTC()
+
+	_, err := conn(ctx, recipesDB.db).ExecContext(ctx, `INSERT INTO recipes (`+recipeFields+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		recipe.ID, recipe.Title, recipe.PhotoBase64, recipe.Description, recipe.Instructions, recipe.CreatedAt)
+
+	return ErrRecipes.Wrap(err)
+}
+
+// GetRecipe returns a recipe with its ingredients by its ID from the database.
+func (recipesDB *recipesDB) GetRecipe(ctx context.Context, id uuid.UUID) (*recipes.Recipe, error) {
+	recipe, err := scanRecipe(conn(ctx, recipesDB.db).QueryRowContext(ctx, `SELECT `+recipeFields+` FROM recipes WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, recipes.ErrNoRecipe.Wrap(err)
+		}
+		return nil, ErrRecipes.Wrap(err)
+	}
+
+	if err = recipesDB.loadIngredients(ctx, []*recipes.Recipe{recipe}); err != nil {
+		return nil, err
+	}
+
+	return recipe, nil
+}
+
+// UpdateRecipe writes all fields of a recipe to the database, empty fields are cleared.
+func (recipesDB *recipesDB) UpdateRecipe(ctx context.Context, updatedRecipe *recipes.Recipe) error {
+	query := `UPDATE recipes SET title = $2, photo = $3, description = $4, instructions = $5
+	          WHERE id = $1`
+
+	result, err := conn(ctx, recipesDB.db).ExecContext(ctx, query, updatedRecipe.ID, updatedRecipe.Title, updatedRecipe.PhotoBase64,
+		updatedRecipe.Description, updatedRecipe.Instructions)
+	if err != nil {
+		return ErrRecipes.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return ErrRecipes.Wrap(err)
+	}
+	if affected == 0 {
+		return recipes.ErrNoRecipe.New("")
+	}
+
+	return nil
+}
+
+// DeleteRecipe deletes a recipe from the database.
+func (recipesDB *recipesDB) DeleteRecipe(ctx context.Context, id uuid.UUID) error {
+	_, err := conn(ctx, recipesDB.db).ExecContext(ctx, `DELETE FROM recipes WHERE id = $1`, id)
+
+	return ErrRecipes.Wrap(err)
+}
+
+// CreateIngredient adds an ingredient of a recipe to the database.
+func (recipesDB *recipesDB) CreateIngredient(ctx context.Context, ingredient recipes.RecipeIngredient) error {
+	_, err := conn(ctx, recipesDB.db).ExecContext(ctx, `INSERT INTO recipe_ingredients (`+ingredientFields+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), ingredient.Name, ingredient.RecipeID, ingredient.Quantity, ingredient.Unit, ingredient.Optional)
+
+	return ErrRecipes.Wrap(err)
+}
+
+// DeleteIngredients deletes all ingredients of a recipe from the database.
+func (recipesDB *recipesDB) DeleteIngredients(ctx context.Context, recipeID uuid.UUID) error {
+	_, err := conn(ctx, recipesDB.db).ExecContext(ctx, `DELETE FROM recipe_ingredients WHERE recipe_id = $1`, recipeID)
+
+	return ErrRecipes.Wrap(err)
+}
+
+// Count returns number of recipes matching the query.
+func (recipesDB *recipesDB) Count(ctx context.Context, listQuery *util.ListQuery) (uint64, error) {
+	conditions, args, err := recipeColumns.Where(listQuery, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	err = conn(ctx, recipesDB.db).QueryRowContext(ctx, `SELECT COUNT(id) FROM recipes `+listquery.WhereClause(conditions), args...).Scan(&count)
+
+	return count, ErrRecipes.Wrap(err)
+}
+
+// List returns a page of recipes matching the query, newest first unless query sorts them,
+// along with cursors of neighbouring pages. Cursors are not returned for custom sorting.
+func (recipesDB *recipesDB) List(ctx context.Context, pagination *util.PaginationReq, listQuery *util.ListQuery) ([]*recipes.Recipe, util.Cursors, error) {
+	var cursors util.Cursors
+
+	// one extra row is selected to find out if there is a further page.
+	conditions, args, err := recipeColumns.Where(listQuery, []interface{}{pagination.Size + 1})
+	if err != nil {
+		return nil, cursors, err
+	}
+
+	var orderBy, offset string
+	cursor := pagination.Cursor
+	switch {
+	case cursor == nil:
+		if orderBy, err = recipeColumns.OrderBy(listQuery, "created_at DESC, id DESC"); err != nil {
+			return nil, cursors, err
+		}
+		args = append(args, pagination.GetDBOffset())
+		offset = fmt.Sprintf(" OFFSET $%d", len(args))
+	case cursor.Backward:
+		args = append(args, cursor.CreatedAt.UTC(), cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) > ($%d, $%d)", len(args)-1, len(args)))
+		orderBy = "ORDER BY created_at, id"
+	default:
+		args = append(args, cursor.CreatedAt.UTC(), cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+		orderBy = "ORDER BY created_at DESC, id DESC"
+	}
+
+	query := `SELECT ` + recipeFields + ` FROM recipes ` + listquery.WhereClause(conditions) + orderBy + ` LIMIT $1` + offset
+
+	list, err := recipesDB.query(ctx, query, args...)
+	if err != nil {
+		return nil, cursors, err
+	}
+
+	hasMore := uint64(len(list)) > pagination.Size
+	if hasMore {
+		list = list[:pagination.Size]
+	}
+
+	if cursor != nil && cursor.Backward {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+
+	if len(list) > 0 && !listQuery.IsSorted() {
+		first, last := list[0], list[len(list)-1]
+		cursors = util.PageCursors(pagination, hasMore,
+			util.Cursor{CreatedAt: first.CreatedAt, ID: first.ID}, util.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	if err = recipesDB.loadIngredients(ctx, list); err != nil {
+		return nil, cursors, err
+	}
+
+	return list, cursors, nil
+}
+
+// query returns recipes selected by the query.
+func (recipesDB *recipesDB) query(ctx context.Context, query string, args ...interface{}) ([]*recipes.Recipe, error) {
+	rows, err := conn(ctx, recipesDB.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, ErrRecipes.Wrap(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []*recipes.Recipe
+	for rows.Next() {
+		recipe, err := scanRecipe(rows)
+		if err != nil {
+			return nil, ErrRecipes.Wrap(err)
+		}
+
+		list = append(list, recipe)
+	}
+
+	return list, ErrRecipes.Wrap(rows.Err())
+}
+
+// loadIngredients fetches ingredients of all recipes at once.
+func (recipesDB *recipesDB) loadIngredients(ctx context.Context, list []*recipes.Recipe) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	byRecipe := make(map[uuid.UUID]*recipes.Recipe, len(list))
+	placeholders := make([]string, 0, len(list))
+	args := make([]interface{}, 0, len(list))
+	for _, recipe := range list {
+		byRecipe[recipe.ID] = recipe
+		args = append(args, recipe.ID)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	ingredients, err := recipesDB.queryIngredients(ctx,
+		`SELECT `+ingredientFields+` FROM recipe_ingredients WHERE recipe_id IN (`+strings.Join(placeholders, ", ")+`)`, args...)
+	if err != nil {
+		return err
+	}
+
+	for _, ingredient := range ingredients {
+		recipe := byRecipe[ingredient.RecipeID]
+		recipe.Ingredients = append(recipe.Ingredients, ingredient)
+	}
+
+	return nil
+}
+
+// GetIngredients returns a list of ingredients by recipe ID.
+func (recipesDB *recipesDB) GetIngredients(ctx context.Context, recipeID uuid.UUID) ([]recipes.RecipeIngredient, error) {
+	return recipesDB.queryIngredients(ctx, `SELECT `+ingredientFields+` FROM recipe_ingredients WHERE recipe_id = $1`, recipeID)
+}
+
+// queryIngredients returns ingredients selected by the query.
+func (recipesDB *recipesDB) queryIngredients(ctx context.Context, query string, args ...interface{}) ([]recipes.RecipeIngredient, error) {
+	rows, err := conn(ctx, recipesDB.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, ErrRecipes.Wrap(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ingredients []recipes.RecipeIngredient
+	for rows.Next() {
+		var ingredient recipes.RecipeIngredient
+		if err = rows.Scan(&ingredient.ID, &ingredient.Name, &ingredient.RecipeID, &ingredient.Quantity, &ingredient.Unit, &ingredient.Optional); err != nil {
+			return nil, ErrRecipes.Wrap(err)
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+
+	return ingredients, ErrRecipes.Wrap(rows.Err())
+}
+
+// scanRecipe reads recipe from a single result row.
+func scanRecipe(row scanner) (*recipes.Recipe, error) {
+	recipe := new(recipes.Recipe)
+	var photo, description, instructions sql.NullString
+	err := row.Scan(&recipe.ID, &recipe.Title, &photo, &description, &instructions, &recipe.CreatedAt)
+	recipe.PhotoBase64, recipe.Description, recipe.Instructions = photo.String, description.String, instructions.String
+
+	return recipe, err
+}
@@ -0,0 +1,126 @@
+// Package sqlite implements kitchen_nerd.DB on top of sqlite, so the project runs without a postgres server.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/zeebo/errs"
+	// registers pure go sqlite driver.
+	_ "modernc.org/sqlite"
+
+	"kitchen_nerd"
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/ratelimit"
+	"kitchen_nerd/recipes"
+	"kitchen_nerd/tokens"
+	"kitchen_nerd/users"
+)
+
+// Error is the default sqlite db error class.
+var Error = errs.Class("kitchen nerd sqlite db error")
+
+// ensures that database implements kitchen_nerd.DB.
+var _ kitchen_nerd.DB = (*database)(nil)
+
+// dsnParams are appended to every data source name: foreign keys are enforced,
+// concurrent writers wait for each other and times are stored in sortable text format.
+const dsnParams = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite"
+
+// database combines access to different database tables.
+//
+// architecture: Master Database
+type database struct {
+	db *sql.DB
+}
+
+// New returns kitchen_nerd.DB sqlite implementation. Database url is either
+// sqlite://path/to/file.db or sqlite://:memory: for a database living until it is closed.
+func New(ctx context.Context, databaseURL string) (kitchen_nerd.DB, error) {
+	path := strings.TrimPrefix(strings.TrimPrefix(databaseURL, "sqlite:"), "//")
+	if path == "" {
+		return nil, Error.New("database path is not set")
+	}
+
+	dsn := "file:" + path
+	if strings.Contains(dsn, "?") {
+		dsn += "&" + dsnParams
+	} else {
+		dsn += "?" + dsnParams
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+
+	// sqlite allows a single writer, and every connection to :memory: opens a separate database.
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+
+	if err = db.PingContext(ctx); err != nil {
+		return nil, errs.Combine(Error.Wrap(err), db.Close())
+	}
+
+	return &database{db: db}, nil
+}
+
+// WithTx runs fn in a transaction, repositories called with the context passed to fn take part in it.
+func (db *database) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, db.db, fn)
+}
+
+// Close closes underlying db connection.
+func (db *database) Close() {
+	_ = db.db.Close()
+}
+
+// Ping checks that database is reachable.
+func (db *database) Ping(ctx context.Context) error {
+	return Error.Wrap(db.db.PingContext(ctx))
+}
+
+// Stats returns statistics of the connection pool.
+func (db *database) Stats() kitchen_nerd.PoolStats {
+	stats := db.db.Stats()
+	return kitchen_nerd.PoolStats{
+		Acquired:  int64(stats.InUse),
+		Idle:      int64(stats.Idle),
+		Max:       int64(stats.MaxOpenConnections),
+		WaitCount: stats.WaitCount,
+		// database/sql measures only acquires which waited, others take no time.
+		AcquireDuration: stats.WaitDuration,
+	}
+}
+
+// Users provides access to users db.
+func (db *database) Users() users.DB {
+	return &usersDB{db: db.db}
+}
+
+// Tokens provides access to tokens db.
+func (db *database) Tokens() tokens.DB {
+	return &tokensDB{db: db.db}
+}
+
+// Recipes provides access to recipes db.
+func (db *database) Recipes() recipes.DB {
+	return &recipesDB{db: db.db}
+}
+
+// APIKeys provides access to api keys db.
+func (db *database) APIKeys() apikeys.DB {
+	return &apiKeysDB{db: db.db}
+}
+
+// Audit provides access to audit log db.
+func (db *database) Audit() audit.DB {
+	return &auditDB{db: db.db}
+}
+
+// RateLimits provides access to rate limits db.
+func (db *database) RateLimits() ratelimit.DB {
+	return &rateLimitsDB{db: db.db}
+}
@@ -0,0 +1,107 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/database/dbtest"
+	"kitchen_nerd/database/sqlite"
+)
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) kitchen_nerd.DB {
+		ctx := context.Background()
+
+		db, err := sqlite.New(ctx, "sqlite://"+filepath.Join(t.TempDir(), "kitchen_nerd.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(db.Close)
+
+		if err = db.MigrateUp(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		return db
+	})
+}
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlite.New(ctx, "sqlite://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, migrate := range []func(ctx context.Context) error{
+		db.MigrateUp,
+		func(ctx context.Context) error { return db.MigrateDown(ctx, 1) },
+		db.MigrateUp,
+	} {
+		if err = migrate(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statuses, err := db.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.Modified {
+			t.Fatalf("unexpected migration status %+v", status)
+		}
+	}
+}
+
+func TestAuditAppendOnly(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "kitchen_nerd.db")
+
+	db, err := sqlite.New(ctx, "sqlite://"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err = db.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	event := audit.Event{ID: uuid.New(), Action: audit.ActionUserLogin, TargetType: audit.TargetUser, CreatedAt: time.Now()}
+	if err = db.Audit().Create(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	// triggers reject changes made bypassing the repository as well.
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = raw.Close() }()
+
+	for _, query := range []string{
+		`UPDATE audit_log SET action = 'user.logout' WHERE id = $1`,
+		`DELETE FROM audit_log WHERE id = $1`,
+	} {
+		_, err = raw.ExecContext(ctx, query, event.ID)
+		if err == nil || !strings.Contains(err.Error(), "audit_log is append-only") {
+			t.Fatalf("%s: expected append-only error, got %v", query, err)
+		}
+	}
+
+	count, err := db.Audit().Count(ctx, audit.Filter{Action: audit.ActionUserLogin})
+	if err != nil || count != 1 {
+		t.Fatalf("expected event to stay unchanged, got %d %v", count, err)
+	}
+}
@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/tokens"
+)
+
+// ensures that tokensDB implements tokens.DB.
+var _ tokens.DB = (*tokensDB)(nil)
+
+// ErrTokens indicates that there was an error in the database.
+var ErrTokens = errs.Class("tokens repository error")
+
+const tokenFields = "id, user_id, token, expired_at, created_at"
+
+// tokensDB provides access to tokens db.
+//
+// architecture: Database
+type tokensDB struct {
+	db *sql.DB
+}
+
+// GetToken returns user's token from the database.
+func (tokensDB *tokensDB) GetToken(ctx context.Context, token string) (tokens.UserToken, error) {
+	return tokensDB.get(ctx, `SELECT `+tokenFields+` FROM users_tokens WHERE token = $1`, token)
+}
+
+// GetTokenByID returns user's token from the database.
+func (tokensDB *tokensDB) GetTokenByID(ctx context.Context, id uuid.UUID) (tokens.UserToken, error) {
+	return tokensDB.get(ctx, `SELECT `+tokenFields+` FROM users_tokens WHERE user_id = $1`, id)
+}
+
+// get returns token selected by the query.
+func (tokensDB *tokensDB) get(ctx context.Context, query string, args ...interface{}) (tokens.UserToken, error) {
+	userToken, err := scanToken(conn(ctx, tokensDB.db).QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return userToken, tokens.ErrNoToken.Wrap(err)
+		}
+
+		return userToken, ErrTokens.Wrap(err)
+	}
+
+	return userToken, nil
+}
+
+// AddToken inserts a token in the database.
+func (tokensDB *tokensDB) AddToken(ctx context.Context, token *tokens.UserToken) error {
+	query := `INSERT INTO users_tokens (` + tokenFields + `) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := conn(ctx, tokensDB.db).ExecContext(ctx, query, token.ID, token.UserID, token.Token, token.ExpiredAt.UTC(), token.CreatedAt.UTC())
+
+	return ErrTokens.Wrap(err)
+}
+
+// DeleteToken removes a token from the database.
+func (tokensDB *tokensDB) DeleteToken(ctx context.Context, token string) error {
+	return tokensDB.delete(ctx, `DELETE FROM users_tokens WHERE token = $1`, token)
+}
+
+// DeleteTokenByUserId removes a token from the database by user id.
+func (tokensDB *tokensDB) DeleteTokenByUserId(ctx context.Context, id uuid.UUID) error {
+	return tokensDB.delete(ctx, `DELETE FROM users_tokens WHERE user_id = $1`, id)
+}
+
+// DeleteSessionToken removes a token from the database by session id.
+func (tokensDB *tokensDB) DeleteSessionToken(ctx context.Context, userId, sessionId uuid.UUID) error {
+	return tokensDB.delete(ctx, `DELETE FROM users_tokens WHERE user_id = $1 AND id = $2`, userId, sessionId)
+}
+
+// delete runs delete query and returns ErrNoToken if nothing was deleted.
+func (tokensDB *tokensDB) delete(ctx context.Context, query string, args ...interface{}) error {
+	result, err := conn(ctx, tokensDB.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return ErrTokens.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return ErrTokens.Wrap(err)
+	}
+	if affected == 0 {
+		return tokens.ErrNoToken.New("")
+	}
+
+	return nil
+}
+
+// DeleteExpiredTokens removes tokens expired at the moment and returns their number.
+func (tokensDB *tokensDB) DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	return tokensDB.deleteExpired(ctx, `DELETE FROM users_tokens WHERE expired_at <= $1`, now)
+}
+
+// DeleteExpiredRevocations removes revocations expired at the moment and returns their number.
+func (tokensDB *tokensDB) DeleteExpiredRevocations(ctx context.Context, now time.Time) (int64, error) {
+	return tokensDB.deleteExpired(ctx, `DELETE FROM tokens_revocations WHERE expires_at <= $1`, now)
+}
+
+// deleteExpired runs delete query of expired rows and returns number of deleted ones.
+func (tokensDB *tokensDB) deleteExpired(ctx context.Context, query string, now time.Time) (int64, error) {
+	result, err := conn(ctx, tokensDB.db).ExecContext(ctx, query, now.UTC())
+	if err != nil {
+		return 0, ErrTokens.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	return affected, ErrTokens.Wrap(err)
+}
+
+// ListActiveSessions gets all sessions by user id form the database.
+func (tokensDB *tokensDB) ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]tokens.UserToken, error) {
+	rows, err := conn(ctx, tokensDB.db).QueryContext(ctx, `SELECT `+tokenFields+` FROM users_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, ErrTokens.Wrap(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var sessions []tokens.UserToken
+	for rows.Next() {
+		session, err := scanToken(rows)
+		if err != nil {
+			return nil, ErrTokens.Wrap(err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, ErrTokens.Wrap(rows.Err())
+}
+
+// AddRevocation inserts a signed tokens revocation in the database.
+func (tokensDB *tokensDB) AddRevocation(ctx context.Context, revocation tokens.Revocation) error {
+	query := `INSERT INTO tokens_revocations (id, token_id, user_id, expires_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5)`
+
+	var userID *uuid.UUID
+	if revocation.UserID != uuid.Nil {
+		userID = &revocation.UserID
+	}
+
+	_, err := conn(ctx, tokensDB.db).ExecContext(ctx, query, revocation.ID, revocation.TokenID, userID,
+		revocation.ExpiresAt.UTC(), revocation.CreatedAt.UTC())
+
+	return ErrTokens.Wrap(err)
+}
+
+// ListRevocations returns revocations which are not expired at the moment.
+func (tokensDB *tokensDB) ListRevocations(ctx context.Context, now time.Time) ([]tokens.Revocation, error) {
+	query := `SELECT id, token_id, user_id, expires_at, created_at
+	          FROM tokens_revocations
+	          WHERE expires_at > $1`
+
+	rows, err := conn(ctx, tokensDB.db).QueryContext(ctx, query, now.UTC())
+	if err != nil {
+		return nil, ErrTokens.Wrap(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var revocations []tokens.Revocation
+	for rows.Next() {
+		var revocation tokens.Revocation
+		var userID *uuid.UUID
+		if err = rows.Scan(&revocation.ID, &revocation.TokenID, &userID, &revocation.ExpiresAt, &revocation.CreatedAt); err != nil {
+			return nil, ErrTokens.Wrap(err)
+		}
+		if userID != nil {
+			revocation.UserID = *userID
+		}
+
+		revocations = append(revocations, revocation)
+	}
+
+	return revocations, ErrTokens.Wrap(rows.Err())
+}
+
+// scanToken reads user's token from a single result row.
+func scanToken(row scanner) (tokens.UserToken, error) {
+	var token tokens.UserToken
+	err := row.Scan(&token.ID, &token.UserID, &token.Token, &token.ExpiredAt, &token.CreatedAt)
+
+	return token, err
+}
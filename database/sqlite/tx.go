@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/zeebo/errs"
+)
+
+// ErrTx indicates that there was an error in a database transaction.
+var ErrTx = errs.Class("transaction error")
+
+// querier is implemented by both database and transaction, so repositories work the same way in either.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txKey is a context key of current transaction.
+type txKey struct{}
+
+// withTx runs fn in a transaction carried by the context passed to fn. Repositories called with
+// that context take part in the transaction. Nested calls join the outer transaction.
+// Transaction is committed when fn returns nil and rolled back otherwise, including when fn panics.
+func withTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return ErrTx.Wrap(err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rollbackErr := tx.Rollback(); !errors.Is(rollbackErr, sql.ErrTxDone) {
+				err = errs.Combine(err, ErrTx.Wrap(rollbackErr))
+			}
+			return
+		}
+		err = ErrTx.Wrap(tx.Commit())
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, tx))
+}
+
+// conn returns transaction carried by the context or the database if there is none.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/database/listquery"
+	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/users"
+)
+
+// ensures that usersDB implements users.DB.
+var _ users.DB = (*usersDB)(nil)
+
+// ErrUsers indicates that there was an error in the database.
+var ErrUsers = errs.Class("users repository error")
+
+const userFields = "id, email, name, status, password_hash, last_login, created_at"
+
+// userColumns maps users list fields to columns.
+var userColumns = listquery.Columns{
+	"email":     "email",
+	"name":      "name",
+	"status":    "status",
+	"lastLogin": "last_login",
+	"createdAt": "created_at",
+}
+
+// usersDB provides access to users db.
+//
+// architecture: Database
+type usersDB struct {
+	db *sql.DB
+}
+
+// WithTx runs fn in a transaction.
+func (usersDB *usersDB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, usersDB.db, fn)
+}
+
+// Create creates a user and writes to the database.
+func (usersDB *usersDB) Create(ctx context.Context, user *users.User) error {
+	query := `INSERT INTO users (` + userFields + `) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := conn(ctx, usersDB.db).ExecContext(ctx, query, user.ID, user.Email, user.Name, user.Status, user.PasswordHash,
+		user.LastLogin.UTC(), user.CreatedAt.UTC())
+
+	return ErrUsers.Wrap(err)
+}
+
+// Get returns user by id.
+func (usersDB *usersDB) Get(ctx context.Context, id uuid.UUID) (*users.User, error) {
+	return usersDB.get(ctx, `SELECT `+userFields+` FROM users WHERE id = $1`, id)
+}
+
+// GetByEmail returns user by email.
+func (usersDB *usersDB) GetByEmail(ctx context.Context, email string) (*users.User, error) {
+	return usersDB.get(ctx, `SELECT `+userFields+` FROM users WHERE email = $1`, email)
+}
+
+// get returns user selected by the query.
+func (usersDB *usersDB) get(ctx context.Context, query string, args ...interface{}) (*users.User, error) {
+	user, err := scanUser(conn(ctx, usersDB.db).QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, users.ErrNoUser.Wrap(err)
+		}
+
+		return user, ErrUsers.Wrap(err)
+	}
+
+	return user, nil
+}
+
+// UpdateLastLogin updates last login time.
+func (usersDB *usersDB) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, usersDB.db).ExecContext(ctx, `UPDATE users SET last_login = $1 WHERE id = $2`, time.Now().UTC(), id)
+
+	return usersDB.checkUpdated(result, err)
+}
+
+// UpdatePassword replaces password hash of the user.
+func (usersDB *usersDB) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash []byte) error {
+	result, err := conn(ctx, usersDB.db).ExecContext(ctx, `UPDATE users SET password_hash = $1 WHERE id = $2`, passwordHash, id)
+
+	return usersDB.checkUpdated(result, err)
+}
+
+// UpdateStatus replaces status of the user.
+func (usersDB *usersDB) UpdateStatus(ctx context.Context, id uuid.UUID, status users.Status) error {
+	result, err := conn(ctx, usersDB.db).ExecContext(ctx, `UPDATE users SET status = $1 WHERE id = $2`, status, id)
+
+	return usersDB.checkUpdated(result, err)
+}
+
+// checkUpdated returns ErrNoUser if update did not affect any user.
+func (usersDB *usersDB) checkUpdated(result sql.Result, err error) error {
+	if err != nil {
+		return ErrUsers.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return ErrUsers.Wrap(err)
+	}
+	if affected == 0 {
+		return users.ErrNoUser.New("")
+	}
+
+	return nil
+}
+
+// List returns a page of users matching the query, newest first unless query sorts them.
+func (usersDB *usersDB) List(ctx context.Context, pagination *util.PaginationReq, listQuery *util.ListQuery) ([]users.User, error) {
+	conditions, args, err := userColumns.Where(listQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := userColumns.OrderBy(listQuery, "created_at DESC, id")
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM users %s%s LIMIT $%d OFFSET $%d`,
+		userFields, listquery.WhereClause(conditions), orderBy, len(args)+1, len(args)+2)
+
+	rows, err := conn(ctx, usersDB.db).QueryContext(ctx, query, append(args, pagination.Size, pagination.GetDBOffset())...)
+	if err != nil {
+		return nil, ErrUsers.Wrap(err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	list := make([]users.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, ErrUsers.Wrap(err)
+		}
+
+		list = append(list, *user)
+	}
+
+	return list, ErrUsers.Wrap(rows.Err())
+}
+
+// Count returns number of users matching the query.
+func (usersDB *usersDB) Count(ctx context.Context, listQuery *util.ListQuery) (uint64, error) {
+	conditions, args, err := userColumns.Where(listQuery, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	err = conn(ctx, usersDB.db).QueryRowContext(ctx, `SELECT COUNT(id) FROM users `+listquery.WhereClause(conditions), args...).Scan(&count)
+
+	return count, ErrUsers.Wrap(err)
+}
+
+// scanner is implemented by both sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser reads user from a single result row.
+func scanUser(row scanner) (*users.User, error) {
+	user := new(users.User)
+	err := row.Scan(&user.ID, &user.Email, &user.Name, &user.Status, &user.PasswordHash, &user.LastLogin, &user.CreatedAt)
+
+	return user, err
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4"
@@ -59,7 +60,7 @@ func (tokensDB *tokensDB) GetTokenByFields(
 	}
 
 	var userToken tokens.UserToken
-	row := tokensDB.pool.QueryRow(ctx, query, qParams...)
+	row := conn(ctx, tokensDB.pool).QueryRow(ctx, query, qParams...)
 
 	err := row.Scan(
 		&userToken.ID,
@@ -93,7 +94,7 @@ func (tokensDB *tokensDB) AddToken(ctx context.Context, token *tokens.UserToken)
 	query := `INSERT INTO users_tokens (id, user_id, token, expired_at, created_at)
 			  VALUES($1, $2, $3, $4, $5)`
 
-	_, err := tokensDB.pool.Exec(ctx, query, token.ID, token.UserID, token.Token, token.ExpiredAt, token.CreatedAt)
+	_, err := conn(ctx, tokensDB.pool).Exec(ctx, query, token.ID, token.UserID, token.Token, token.ExpiredAt, token.CreatedAt)
 
 	return ErrTokens.Wrap(err)
 }
@@ -103,7 +104,7 @@ func (tokensDB *tokensDB) DeleteToken(ctx context.Context, token string) error {
 	query := `DELETE FROM users_tokens
 	          WHERE token = $1`
 
-	res, err := tokensDB.pool.Exec(ctx, query, token)
+	res, err := conn(ctx, tokensDB.pool).Exec(ctx, query, token)
 	if err != nil {
 		return ErrTokens.Wrap(err)
 	}
@@ -121,7 +122,7 @@ func (tokensDB *tokensDB) DeleteTokenByUserId(ctx context.Context, id uuid.UUID)
 	query := `DELETE FROM users_tokens
 	          WHERE user_id = $1`
 
-	res, err := tokensDB.pool.Exec(ctx, query, id)
+	res, err := conn(ctx, tokensDB.pool).Exec(ctx, query, id)
 	if err != nil {
 		return ErrTokens.Wrap(err)
 	}
@@ -140,7 +141,7 @@ func (tokensDB *tokensDB) ListActiveSessions(ctx context.Context, userID uuid.UU
 			  FROM users_tokens
 			  WHERE user_id = $1`
 
-	rows, err := tokensDB.pool.Query(ctx, query, userID)
+	rows, err := conn(ctx, tokensDB.pool).Query(ctx, query, userID)
 	if err != nil {
 		return nil, ErrTokens.Wrap(err)
 	}
@@ -164,7 +165,7 @@ func (tokensDB *tokensDB) DeleteSessionToken(ctx context.Context, userId, sessio
 	query := `DELETE FROM users_tokens
            WHERE user_id = $1 AND id = $2`
 
-	res, err := tokensDB.pool.Exec(ctx, query, userId, sessionId)
+	res, err := conn(ctx, tokensDB.pool).Exec(ctx, query, userId, sessionId)
 	if err != nil {
 		return ErrTokens.Wrap(err)
 	}
@@ -176,3 +177,74 @@ func (tokensDB *tokensDB) DeleteSessionToken(ctx context.Context, userId, sessio
 
 	return nil
 }
+
+// AddRevocation inserts a signed tokens revocation in the database.
+func (tokensDB *tokensDB) AddRevocation(ctx context.Context, revocation tokens.Revocation) error {
+	query := `INSERT INTO tokens_revocations (id, token_id, user_id, expires_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5)`
+
+	var userID *uuid.UUID
+	if revocation.UserID != uuid.Nil {
+		userID = &revocation.UserID
+	}
+
+	_, err := conn(ctx, tokensDB.pool).Exec(ctx, query, revocation.ID, revocation.TokenID, userID, revocation.ExpiresAt, revocation.CreatedAt)
+
+	return ErrTokens.Wrap(err)
+}
+
+// ListRevocations returns revocations which are not expired at the moment.
+func (tokensDB *tokensDB) ListRevocations(ctx context.Context, now time.Time) ([]tokens.Revocation, error) {
+	query := `SELECT id, token_id, user_id, expires_at, created_at
+	          FROM tokens_revocations
+	          WHERE expires_at > $1`
+
+	rows, err := conn(ctx, tokensDB.pool).Query(ctx, query, now)
+	if err != nil {
+		return nil, ErrTokens.Wrap(err)
+	}
+	defer rows.Close()
+
+	var revocations []tokens.Revocation
+	for rows.Next() {
+		var revocation tokens.Revocation
+		var userID *uuid.UUID
+		err := rows.Scan(&revocation.ID, &revocation.TokenID, &userID, &revocation.ExpiresAt, &revocation.CreatedAt)
+		if err != nil {
+			return nil, ErrTokens.Wrap(err)
+		}
+		if userID != nil {
+			revocation.UserID = *userID
+		}
+
+		revocations = append(revocations, revocation)
+	}
+
+	return revocations, ErrTokens.Wrap(rows.Err())
+}
+
+// DeleteExpiredTokens removes tokens expired at the moment and returns their number.
+func (tokensDB *tokensDB) DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM users_tokens
+	          WHERE expired_at <= $1`
+
+	res, err := conn(ctx, tokensDB.pool).Exec(ctx, query, now)
+	if err != nil {
+		return 0, ErrTokens.Wrap(err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// DeleteExpiredRevocations removes revocations expired at the moment and returns their number.
+func (tokensDB *tokensDB) DeleteExpiredRevocations(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM tokens_revocations
+	          WHERE expires_at <= $1`
+
+	res, err := conn(ctx, tokensDB.pool).Exec(ctx, query, now)
+	if err != nil {
+		return 0, ErrTokens.Wrap(err)
+	}
+
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/zeebo/errs"
+)
+
+// ErrTx indicates that there was an error in a database transaction.
+var ErrTx = errs.Class("transaction error")
+
+// querier is implemented by both connection pool and transaction, so repositories work the same way in either.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// txKey is a context key of current transaction.
+type txKey struct{}
+
+// withTx runs fn in a transaction carried by the context passed to fn. Repositories called with
+// that context take part in the transaction. Nested calls join the outer transaction.
+// Transaction is committed when fn returns nil and rolled back otherwise, including when fn panics.
+func withTx(ctx context.Context, pool *pgxpool.Pool, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return ErrTx.Wrap(err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+		if err != nil {
+			err = errs.Combine(err, ErrTx.Wrap(ignoreTxClosed(tx.Rollback(ctx))))
+			return
+		}
+		err = ErrTx.Wrap(tx.Commit(ctx))
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, tx))
+}
+
+// conn returns transaction carried by the context or the pool if there is none.
+func conn(ctx context.Context, pool *pgxpool.Pool) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return pool
+}
+
+// ignoreTxClosed ignores error of rolling back already finished transaction.
+func ignoreTxClosed(err error) error {
+	if errs.Is(err, pgx.ErrTxClosed) {
+		return nil
+	}
+
+	return err
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/zeebo/errs"
 
+	"kitchen_nerd/database/listquery"
+	"kitchen_nerd/pkg/util"
 	"kitchen_nerd/users"
 )
 
@@ -22,6 +25,15 @@ const (
 			VALUES ($1, $2, $3, $4, $5, $6, $7)`
 )
 
+// userColumns maps users list fields to columns.
+var userColumns = listquery.Columns{
+	"email":     "email",
+	"name":      "name",
+	"status":    "status",
+	"lastLogin": "last_login",
+	"createdAt": "created_at",
+}
+
 // ErrUsers indicates that there was an error in the database.
 var ErrUsers = errs.Class("users repository error")
 
@@ -32,22 +44,29 @@ type usersDB struct {
 	pool *pgxpool.Pool
 }
 
+// WithTx runs fn in a transaction.
+func (usersDB *usersDB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, usersDB.pool, fn)
+}
+
 func (usersDB *usersDB) Get(ctx context.Context, id uuid.UUID) (*users.User, error) {
 	query := `
 SELECT 
 	id, 
 	email, 
 	name,
+	status,
 	password_hash,
 	last_login,
 	created_at
-FROM users WHERE email=$1`
+FROM users WHERE id=$1`
 
 	user := new(users.User)
-	err := usersDB.pool.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, usersDB.pool).QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Name,
+		&user.Status,
 		&user.PasswordHash,
 		&user.LastLogin,
 		&user.CreatedAt,
@@ -68,16 +87,18 @@ SELECT
 	id, 
 	email, 
 	name,
+	status,
 	password_hash,
 	last_login,
 	created_at
 FROM users WHERE email=$1`
 
 	user := new(users.User)
-	err := usersDB.pool.QueryRow(ctx, query, email).Scan(
+	err := conn(ctx, usersDB.pool).QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Name,
+		&user.Status,
 		&user.PasswordHash,
 		&user.LastLogin,
 		&user.CreatedAt,
@@ -94,7 +115,7 @@ FROM users WHERE email=$1`
 
 // Create creates a user and writes to the database.
 func (usersDB *usersDB) Create(ctx context.Context, user *users.User) error {
-	_, err := usersDB.pool.Exec(ctx, createUser, user.ID, user.Email, user.Name, user.Status, user.PasswordHash, user.LastLogin, user.CreatedAt)
+	_, err := conn(ctx, usersDB.pool).Exec(ctx, createUser, user.ID, user.Email, user.Name, user.Status, user.PasswordHash, user.LastLogin, user.CreatedAt)
 
 	return ErrUsers.Wrap(err)
 }
@@ -102,7 +123,7 @@ func (usersDB *usersDB) Create(ctx context.Context, user *users.User) error {
 // UpdateLastLogin updates last login time.
 func (usersDB *usersDB) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE users SET last_login=$1 WHERE id=$2`
-	result, err := usersDB.pool.Exec(ctx, query, time.Now().UTC(), id)
+	result, err := conn(ctx, usersDB.pool).Exec(ctx, query, time.Now().UTC(), id)
 	if err != nil {
 		return ErrUsers.Wrap(err)
 	}
@@ -114,3 +135,80 @@ func (usersDB *usersDB) UpdateLastLogin(ctx context.Context, id uuid.UUID) error
 
 	return nil
 }
+
+// UpdatePassword replaces password hash of the user.
+func (usersDB *usersDB) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash []byte) error {
+	query := `UPDATE users SET password_hash=$1 WHERE id=$2`
+	result, err := conn(ctx, usersDB.pool).Exec(ctx, query, passwordHash, id)
+	if err != nil {
+		return ErrUsers.Wrap(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return users.ErrNoUser.New("")
+	}
+
+	return nil
+}
+
+// UpdateStatus replaces status of the user.
+func (usersDB *usersDB) UpdateStatus(ctx context.Context, id uuid.UUID, status users.Status) error {
+	query := `UPDATE users SET status=$1 WHERE id=$2`
+	result, err := conn(ctx, usersDB.pool).Exec(ctx, query, status, id)
+	if err != nil {
+		return ErrUsers.Wrap(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return users.ErrNoUser.New("")
+	}
+
+	return nil
+}
+
+// List returns a page of users matching the query, newest first unless query sorts them.
+func (usersDB *usersDB) List(ctx context.Context, pagination *util.PaginationReq, listQuery *util.ListQuery) ([]users.User, error) {
+	conditions, args, err := userColumns.Where(listQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := userColumns.OrderBy(listQuery, "created_at DESC, id")
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM users %s%s LIMIT $%d OFFSET $%d`,
+		fields, listquery.WhereClause(conditions), orderBy, len(args)+1, len(args)+2)
+
+	rows, err := conn(ctx, usersDB.pool).Query(ctx, query, append(args, pagination.Size, pagination.GetDBOffset())...)
+	if err != nil {
+		return nil, ErrUsers.Wrap(err)
+	}
+	defer rows.Close()
+
+	list := make([]users.User, 0)
+	for rows.Next() {
+		var user users.User
+		if err = rows.Scan(&user.ID, &user.Email, &user.Name, &user.Status, &user.PasswordHash, &user.LastLogin, &user.CreatedAt); err != nil {
+			return nil, ErrUsers.Wrap(err)
+		}
+
+		list = append(list, user)
+	}
+
+	return list, ErrUsers.Wrap(rows.Err())
+}
+
+// Count returns number of users matching the query.
+func (usersDB *usersDB) Count(ctx context.Context, listQuery *util.ListQuery) (uint64, error) {
+	conditions, args, err := userColumns.Where(listQuery, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	err = conn(ctx, usersDB.pool).QueryRow(ctx, `SELECT COUNT(id) FROM users `+listquery.WhereClause(conditions), args...).Scan(&count)
+
+	return count, ErrUsers.Wrap(err)
+}
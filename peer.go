@@ -3,14 +3,22 @@ package kitchen_nerd
 import (
 	"context"
 	"errors"
+	"kitchen_nerd/apikeys"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/pkg/health"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/pkg/metrics"
+	"kitchen_nerd/ratelimit"
 	"kitchen_nerd/recipes"
-	"log"
 	"net"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/zeebo/errs"
 	"golang.org/x/sync/errgroup"
 
 	"kitchen_nerd/console/consoleserver"
+	"kitchen_nerd/database/migrations"
 	"kitchen_nerd/tokens"
 	"kitchen_nerd/users"
 )
@@ -28,22 +36,269 @@ type DB interface {
 	// Tokens provides access to tokens db.
 	Tokens() tokens.DB
 
+	// APIKeys provides access to api keys db.
+	APIKeys() apikeys.DB
+
+	// Audit provides access to audit log db.
+	Audit() audit.DB
+
+	// RateLimits provides access to rate limits db.
+	RateLimits() ratelimit.DB
+
+	// WithTx runs fn in a transaction. All repositories called with the context
+	// passed to fn take part in the transaction, which is rolled back if fn returns an error.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+
 	// Close closes underlying db connection.
 	Close()
 
-	// CreateSchema create tables.
-	CreateSchema(ctx context.Context) error
+	// Stats returns statistics of the connection pool.
+	Stats() PoolStats
+
+	// Ping checks that database is reachable.
+	Ping(ctx context.Context) error
+
+	// MigrateUp applies all pending schema migrations.
+	MigrateUp(ctx context.Context) error
+
+	// MigrateDown rolls back given number of latest applied schema migrations.
+	MigrateDown(ctx context.Context, steps int) error
+
+	// MigrationStatus returns state of all known schema migrations.
+	MigrationStatus(ctx context.Context) ([]migrations.Status, error)
+}
+
+// PoolStats contains statistics of database connection pool.
+type PoolStats struct {
+	// Acquired is a number of connections currently in use.
+	Acquired int64
+	// Idle is a number of open connections waiting to be used.
+	Idle int64
+	// Max is the maximum number of connections, zero means unlimited.
+	Max int64
+	// WaitCount is a total number of acquires which had to wait for a connection.
+	WaitCount int64
+	// AcquireDuration is a total time spent acquiring connections, including waiting for them.
+	AcquireDuration time.Duration
 }
 
-// Config is the global configuration for kitchen nerd.
+// ErrConfig indicates invalid configuration.
+var ErrConfig = errs.Class("invalid config")
+
+// Config is the global configuration for kitchen nerd. Settings are loaded by pkg/config
+// from defaults, config file, env and flags, see its documentation for precedence and names.
 type Config struct {
-	DatabaseURL    string `env:"DATABASE_URL,notEmpty"`
-	ServerAddress  string `env:"SERVER_ADDRESS,notEmpty"`
-	StaticDir      string `env:"STATIC_DIR,notEmpty"`
-	ExportDataPath string `env:"EXPORT_DATA_PATH,notEmpty"`
+	// DatabaseURL selects database by its scheme: postgres://, sqlite://path/to/file.db or memory://.
+	DatabaseURL   string `env:"DATABASE_URL,notEmpty" secret:"true"`
+	ServerAddress string `env:"SERVER_ADDRESS,notEmpty" envDefault:"127.0.0.1:8088"`
+	StaticDir     string `env:"STATIC_DIR,notEmpty" envDefault:"web"`
+	// AutoMigrate applies pending schema migrations on start, convenient for development.
+	AutoMigrate bool `env:"AUTO_MIGRATE" envDefault:"false"`
+
+	// DatabaseMaxConns limits number of postgres connections, zero keeps default of the driver as well as for other pool settings.
+	DatabaseMaxConns        int32         `env:"DATABASE_MAX_CONNS" envDefault:"0"`
+	DatabaseMinConns        int32         `env:"DATABASE_MIN_CONNS" envDefault:"0"`
+	DatabaseMaxConnLifetime time.Duration `env:"DATABASE_MAX_CONN_LIFETIME" envDefault:"0s"`
+	DatabaseMaxConnIdleTime time.Duration `env:"DATABASE_MAX_CONN_IDLE_TIME" envDefault:"0s"`
+
+	// ServerReadHeaderTimeout limits time of reading request headers, zero means no limit as well as for other timeouts.
+	ServerReadHeaderTimeout time.Duration `env:"SERVER_READ_HEADER_TIMEOUT" envDefault:"10s"`
+	ServerReadTimeout       time.Duration `env:"SERVER_READ_TIMEOUT" envDefault:"30s"`
+	ServerWriteTimeout      time.Duration `env:"SERVER_WRITE_TIMEOUT" envDefault:"30s"`
+	ServerIdleTimeout       time.Duration `env:"SERVER_IDLE_TIMEOUT" envDefault:"2m"`
+	// ServerTrustForwardedFor takes client address from X-Forwarded-For, enable it only behind a reverse proxy.
+	ServerTrustForwardedFor bool `env:"SERVER_TRUST_FORWARDED_FOR" envDefault:"false"`
+
+	// RateLimitBackend keeps buckets of clients "memory" of the instance, in the shared "database" when
+	// there are several instances, or "none" to disable rate limits.
+	RateLimitBackend string `env:"RATE_LIMIT_BACKEND" envDefault:"memory"`
+	// RateLimitAuth, RateLimitRead and RateLimitWrite are "requests/period" policies of login and registration,
+	// reading recipes and changing them, empty or "0" disables the policy.
+	RateLimitAuth  string `env:"RATE_LIMIT_AUTH" envDefault:"20/1m"`
+	RateLimitRead  string `env:"RATE_LIMIT_READ" envDefault:"300/1m"`
+	RateLimitWrite string `env:"RATE_LIMIT_WRITE" envDefault:"60/1m"`
+
+	// TokenMode is either "opaque" for database tokens or "jwt" for signed ones.
+	TokenMode           string        `env:"TOKEN_MODE" envDefault:"opaque"`
+	TokenExpirationTime time.Duration `env:"TOKEN_EXPIRATION_TIME" envDefault:"120h"`
+	// TokenDenylistRefreshInterval defines how often jwt revocations made by other instances are loaded.
+	TokenDenylistRefreshInterval time.Duration `env:"TOKEN_DENYLIST_REFRESH_INTERVAL" envDefault:"1m"`
+	// JWTKeys is a comma separated list of "kid:algorithm:base64 key" entries, e.g. "k1:HS256:c2VjcmV0...".
+	JWTKeys         string `env:"JWT_KEYS" secret:"true"`
+	JWTSigningKeyID string `env:"JWT_SIGNING_KEY_ID"`
+
+	// Argon2Memory is amount of memory in KiB used to hash passwords.
+	Argon2Memory      uint32 `env:"ARGON2_MEMORY" envDefault:"65536"`
+	Argon2Iterations  uint32 `env:"ARGON2_ITERATIONS" envDefault:"3"`
+	Argon2Parallelism uint8  `env:"ARGON2_PARALLELISM" envDefault:"2"`
+
+	PasswordMinLength            int  `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+	PasswordMaxLength            int  `env:"PASSWORD_MAX_LENGTH" envDefault:"128"`
+	PasswordRequireLower         bool `env:"PASSWORD_REQUIRE_LOWER" envDefault:"true"`
+	PasswordRequireUpper         bool `env:"PASSWORD_REQUIRE_UPPER" envDefault:"true"`
+	PasswordRequireDigit         bool `env:"PASSWORD_REQUIRE_DIGIT" envDefault:"true"`
+	PasswordRequireSpecial       bool `env:"PASSWORD_REQUIRE_SPECIAL" envDefault:"false"`
+	PasswordDisallowPersonalInfo bool `env:"PASSWORD_DISALLOW_PERSONAL_INFO" envDefault:"true"`
+	// BreachedPasswordsPath is an optional file or directory of SHA-1 hashes of breached passwords.
+	BreachedPasswordsPath string `env:"BREACHED_PASSWORDS_PATH"`
+
+	// ShutdownDelay is time console server keeps serving with failing readiness once shutdown is requested.
+	ShutdownDelay time.Duration `env:"SHUTDOWN_DELAY" envDefault:"0s"`
+	// ShutdownTimeout limits time of draining in-flight requests on shutdown.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+
+	// MetricsAddress is an internal address serving /metrics and detailed /readyz, they are not served when it's empty.
+	MetricsAddress string `env:"METRICS_ADDRESS"`
+
+	// LogLevel is the minimal level of logged messages: debug, info, warn or error.
+	LogLevel string `env:"LOG_LEVEL" envDefault:"info"`
+
+	// RecipesCacheSize is the maximum number of cached recipes and list pages, zero disables the cache.
+	RecipesCacheSize      int           `env:"RECIPES_CACHE_SIZE" envDefault:"1000"`
+	RecipesCacheTTL       time.Duration `env:"RECIPES_CACHE_TTL" envDefault:"1m"`
+	RecipesCacheListPages uint64        `env:"RECIPES_CACHE_LIST_PAGES" envDefault:"3"`
+}
+
+// Validate checks that settings are consistent, returned error lists all the problems.
+func (config Config) Validate() error {
+	var group errs.Group
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			group.Add(ErrConfig.New(format, args...))
+		}
+	}
+
+	switch tokens.Mode(config.TokenMode) {
+	case tokens.ModeOpaque:
+	case tokens.ModeJWT:
+		check(config.JWTKeys != "", "jwt-keys are required when token-mode is %q", tokens.ModeJWT)
+	default:
+		check(false, "token-mode must be %q or %q, got %q", tokens.ModeOpaque, tokens.ModeJWT, config.TokenMode)
+	}
+	check(config.TokenExpirationTime > 0, "token-expiration-time must be positive")
+	check(config.TokenDenylistRefreshInterval > 0, "token-denylist-refresh-interval must be positive")
+
+	switch config.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log-level must be debug, info, warn or error, got %q", config.LogLevel)
+	}
+
+	check(config.DatabaseMaxConns >= 0 && config.DatabaseMinConns >= 0, "database-max-conns and database-min-conns must not be negative")
+	check(config.DatabaseMaxConns == 0 || config.DatabaseMinConns <= config.DatabaseMaxConns,
+		"database-min-conns (%d) must not exceed database-max-conns (%d)", config.DatabaseMinConns, config.DatabaseMaxConns)
+
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"database-max-conn-lifetime", config.DatabaseMaxConnLifetime},
+		{"database-max-conn-idle-time", config.DatabaseMaxConnIdleTime},
+		{"server-read-header-timeout", config.ServerReadHeaderTimeout},
+		{"server-read-timeout", config.ServerReadTimeout},
+		{"server-write-timeout", config.ServerWriteTimeout},
+		{"server-idle-timeout", config.ServerIdleTimeout},
+		{"shutdown-delay", config.ShutdownDelay},
+		{"recipes-cache-ttl", config.RecipesCacheTTL},
+	} {
+		check(timeout.value >= 0, "%s must not be negative", timeout.name)
+	}
+	check(config.ShutdownTimeout > 0, "shutdown-timeout must be positive")
+
+	check(config.Argon2Memory > 0 && config.Argon2Iterations > 0 && config.Argon2Parallelism > 0,
+		"argon2-memory, argon2-iterations and argon2-parallelism must be positive")
+	check(config.PasswordMinLength > 0 && config.PasswordMinLength <= config.PasswordMaxLength,
+		"password-min-length (%d) must be positive and not exceed password-max-length (%d)", config.PasswordMinLength, config.PasswordMaxLength)
+	check(config.RecipesCacheSize >= 0, "recipes-cache-size must not be negative")
+
+	switch config.RateLimitBackend {
+	case rateLimitBackendMemory, rateLimitBackendDatabase, rateLimitBackendNone:
+	default:
+		check(false, "rate-limit-backend must be %q, %q or %q, got %q",
+			rateLimitBackendMemory, rateLimitBackendDatabase, rateLimitBackendNone, config.RateLimitBackend)
+	}
+	if _, err := config.RateLimits(); err != nil {
+		group.Add(ErrConfig.Wrap(err))
+	}
+
+	return group.Err()
+}
+
+// TokensConfig returns configuration of tokens service, signing keys are parsed in jwt mode.
+func (config Config) TokensConfig() (_ tokens.Config, err error) {
+	tokensConfig := tokens.Config{
+		TokenExpirationTime:     config.TokenExpirationTime,
+		Mode:                    tokens.Mode(config.TokenMode),
+		DenylistRefreshInterval: config.TokenDenylistRefreshInterval,
+	}
+
+	if tokensConfig.Mode == tokens.ModeJWT {
+		tokensConfig.Keys, err = tokens.ParseKeySet(config.JWTKeys, config.JWTSigningKeyID)
+		if err != nil {
+			return tokens.Config{}, err
+		}
+	}
+
+	return tokensConfig, nil
+}
+
+// rate limit backends.
+const (
+	rateLimitBackendMemory   = "memory"
+	rateLimitBackendDatabase = "database"
+	rateLimitBackendNone     = "none"
+)
+
+// RateLimits returns parsed rate limit policies of console server routes.
+func (config Config) RateLimits() (limits consoleserver.RateLimits, err error) {
+	var group errs.Group
+	for _, policy := range []struct {
+		target **ratelimit.Policy
+		name   string
+		value  string
+	}{
+		{&limits.Auth, "auth", config.RateLimitAuth},
+		{&limits.Read, "read", config.RateLimitRead},
+		{&limits.Write, "write", config.RateLimitWrite},
+	} {
+		*policy.target, err = ratelimit.ParsePolicy(policy.name, policy.value)
+		group.Add(err)
+	}
+
+	return limits, group.Err()
+}
+
+// UsersConfig returns configuration of users service, breached passwords are loaded when configured.
+func (config Config) UsersConfig() (_ users.Config, err error) {
+	usersConfig := users.Config{
+		Argon2: users.Argon2Params{
+			Memory:      config.Argon2Memory,
+			Iterations:  config.Argon2Iterations,
+			Parallelism: config.Argon2Parallelism,
+		},
+		PasswordPolicy: users.PasswordPolicy{
+			MinLength:            config.PasswordMinLength,
+			MaxLength:            config.PasswordMaxLength,
+			RequireLower:         config.PasswordRequireLower,
+			RequireUpper:         config.PasswordRequireUpper,
+			RequireDigit:         config.PasswordRequireDigit,
+			RequireSpecial:       config.PasswordRequireSpecial,
+			DisallowPersonalInfo: config.PasswordDisallowPersonalInfo,
+		},
+	}
+
+	if config.BreachedPasswordsPath != "" {
+		usersConfig.Breached, err = users.LoadBreachedPasswords(config.BreachedPasswordsPath)
+		if err != nil {
+			return users.Config{}, err
+		}
+	}
+
+	return usersConfig, nil
 }
 
 type KitchenNerd struct {
+	Log      logger.Logger
 	Config   Config
 	Database DB
 
@@ -54,6 +309,42 @@ type KitchenNerd struct {
 
 	Recipes struct {
 		Service *recipes.Service
+		// Cache is nil when recipes cache is disabled.
+		Cache *recipes.CachedDB
+	}
+
+	// Audit exposes audit log related logic.
+	Audit struct {
+		Service *audit.Service
+	}
+
+	// Tokens exposes session tokens related logic.
+	Tokens struct {
+		Service *tokens.Service
+	}
+
+	// APIKeys exposes personal api keys related logic.
+	APIKeys struct {
+		Service *apikeys.Service
+	}
+
+	// Metrics exposes metrics in Prometheus format and detailed readiness on an internal listener.
+	Metrics struct {
+		Registry *prometheus.Registry
+		// Listener and Endpoint are nil when metrics address is not configured.
+		Listener net.Listener
+		Endpoint *metrics.Server
+	}
+
+	// RateLimit limits requests of clients.
+	RateLimit struct {
+		// Service is nil when rate limits are disabled.
+		Service *ratelimit.Service
+	}
+
+	// Health checks readiness of components.
+	Health struct {
+		Checker *health.Checker
 	}
 
 	// Console web server with web UI.
@@ -64,17 +355,97 @@ type KitchenNerd struct {
 }
 
 // New is a constructor for KitchenNerd.
-func New(config Config, db DB) (kitchenNerd *KitchenNerd, err error) {
+func New(log logger.Logger, config Config, db DB) (kitchenNerd *KitchenNerd, err error) {
 	kitchenNerd = &KitchenNerd{
+		Log:      log,
 		Database: db,
 	}
 
+	// servers take ownership of listeners only when New succeeds, so close them on later failures.
+	var listeners []net.Listener
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		for _, listener := range listeners {
+			err = errs.Combine(err, listener.Close())
+		}
+	}()
+
+	{ // audit setup.
+		kitchenNerd.Audit.Service = audit.NewService(log, db.Audit())
+	}
+
+	{ // tokens setup.
+		tokensConfig, err := config.TokensConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		kitchenNerd.Tokens.Service = tokens.NewService(log, tokensConfig, db.Tokens())
+	}
+
 	{ // users setup.
-		kitchenNerd.Users.Service = users.NewService(db.Users(), db.Tokens())
+		usersConfig, err := config.UsersConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		kitchenNerd.Users.Service = users.NewService(log, usersConfig, db.Users(), kitchenNerd.Tokens.Service, kitchenNerd.Audit.Service)
 	}
 
 	{ // recipes setup.
-		kitchenNerd.Recipes.Service = recipes.NewService(db.Recipes())
+		recipesDB := db.Recipes()
+		if config.RecipesCacheSize > 0 {
+			kitchenNerd.Recipes.Cache = recipes.NewCachedDB(recipesDB, recipes.CacheConfig{
+				Size:      config.RecipesCacheSize,
+				TTL:       config.RecipesCacheTTL,
+				ListPages: config.RecipesCacheListPages,
+			})
+			recipesDB = kitchenNerd.Recipes.Cache
+		}
+
+		kitchenNerd.Recipes.Service = recipes.NewService(recipesDB, kitchenNerd.Audit.Service)
+	}
+
+	{ // api keys setup.
+		kitchenNerd.APIKeys.Service = apikeys.NewService(db.APIKeys(), kitchenNerd.Audit.Service)
+	}
+
+	{ // health setup.
+		kitchenNerd.Health.Checker = health.NewChecker()
+		kitchenNerd.Health.Checker.Add("database", db.Ping)
+		kitchenNerd.Health.Checker.Add("migrations", kitchenNerd.checkMigrations)
+	}
+
+	{ // metrics setup.
+		kitchenNerd.Metrics.Registry = metrics.NewRegistry()
+		kitchenNerd.registerMetrics()
+
+		if config.MetricsAddress != "" {
+			kitchenNerd.Metrics.Listener, err = net.Listen("tcp", config.MetricsAddress)
+			if err != nil {
+				return nil, err
+			}
+			listeners = append(listeners, kitchenNerd.Metrics.Listener)
+
+			kitchenNerd.Metrics.Endpoint = metrics.NewServer(log, kitchenNerd.Metrics.Listener, kitchenNerd.Metrics.Registry, kitchenNerd.Health.Checker)
+		}
+	}
+
+	{ // rate limit setup.
+		limits, err := config.RateLimits()
+		if err != nil {
+			return nil, err
+		}
+
+		switch config.RateLimitBackend {
+		case rateLimitBackendMemory:
+			kitchenNerd.RateLimit.Service = ratelimit.NewService(log, ratelimit.NewMemoryDB(), limits.Auth, limits.Read, limits.Write)
+		case rateLimitBackendDatabase:
+			kitchenNerd.RateLimit.Service = ratelimit.NewService(log, db.RateLimits(), limits.Auth, limits.Read, limits.Write)
+		}
 	}
 
 	{ // console setup.
@@ -82,36 +453,85 @@ func New(config Config, db DB) (kitchenNerd *KitchenNerd, err error) {
 		if err != nil {
 			return nil, err
 		}
-		log.Println("123")
+		listeners = append(listeners, kitchenNerd.Console.Listener)
+
 		cfg := consoleserver.Config{
-			ServerAddress: config.ServerAddress,
-			StaticDir:     config.StaticDir,
+			StaticDir:         config.StaticDir,
+			ReadHeaderTimeout: config.ServerReadHeaderTimeout,
+			ReadTimeout:       config.ServerReadTimeout,
+			WriteTimeout:      config.ServerWriteTimeout,
+			IdleTimeout:       config.ServerIdleTimeout,
+			ShutdownDelay:     config.ShutdownDelay,
+			ShutdownTimeout:   config.ShutdownTimeout,
+			TrustForwardedFor: config.ServerTrustForwardedFor,
+		}
+		cfg.RateLimits, err = config.RateLimits()
+		if err != nil {
+			return nil, err
 		}
 
 		kitchenNerd.Console.Endpoint, err = consoleserver.NewServer(
+			log,
+			kitchenNerd.Metrics.Registry,
+			kitchenNerd.Health.Checker,
 			cfg,
 			kitchenNerd.Console.Listener,
 			kitchenNerd.Users.Service,
 			kitchenNerd.Recipes.Service,
+			kitchenNerd.Tokens.Service,
+			kitchenNerd.APIKeys.Service,
+			kitchenNerd.Audit.Service,
+			kitchenNerd.RateLimit.Service,
 		)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 	}
 
 	return kitchenNerd, nil
 }
 
-// Run runs kitchenNerd until it's either closed or it errors.
+// Run runs kitchenNerd until context is canceled or it errors. Servers drain in-flight requests first,
+// background workers are stopped after them, since requests may still depend on workers.
 func (kitchenNerd *KitchenNerd) Run(ctx context.Context) error {
-	group, ctx := errgroup.WithContext(ctx)
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers errgroup.Group
+
+	// keep signed tokens denylist up to date.
+	workers.Go(func() error {
+		return ignoreCancel(kitchenNerd.Tokens.Service.Run(workersCtx))
+	})
+
+	// remove buckets of clients which stopped sending requests.
+	if kitchenNerd.RateLimit.Service != nil {
+		workers.Go(func() error {
+			return ignoreCancel(kitchenNerd.RateLimit.Service.Run(workersCtx))
+		})
+	}
+
+	servers, ctx := errgroup.WithContext(ctx)
 
 	// start kitchenNerd servers as a separate goroutine.
-	group.Go(func() error {
+	servers.Go(func() error {
 		return ignoreCancel(kitchenNerd.Console.Endpoint.Run(ctx))
 	})
 
-	return group.Wait()
+	if kitchenNerd.Metrics.Endpoint != nil {
+		servers.Go(func() error {
+			return ignoreCancel(kitchenNerd.Metrics.Endpoint.Run(ctx))
+		})
+	}
+
+	serversErr := servers.Wait()
+	kitchenNerd.Log.Info("servers stopped, stopping background workers")
+
+	stopWorkers()
+	workersErr := workers.Wait()
+	kitchenNerd.Log.Info("background workers stopped")
+
+	return errs.Combine(serversErr, workersErr)
 }
 
 // Close closes all the resources.
@@ -119,10 +539,94 @@ func (kitchenNerd *KitchenNerd) Close() error {
 	var errlist errs.Group
 
 	errlist.Add(kitchenNerd.Console.Endpoint.Close())
+	if kitchenNerd.Metrics.Endpoint != nil {
+		errlist.Add(kitchenNerd.Metrics.Endpoint.Close())
+	}
 
 	return errlist.Err()
 }
 
+// checkMigrations checks that all migrations are applied and weren't modified since.
+func (kitchenNerd *KitchenNerd) checkMigrations(ctx context.Context) error {
+	statuses, err := kitchenNerd.Database.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		switch {
+		case !status.Applied:
+			return errs.New("migration %04d_%s is pending", status.Version, status.Name)
+		case status.Modified:
+			return errs.New("migration %04d_%s was modified after it had been applied", status.Version, status.Name)
+		}
+	}
+
+	return nil
+}
+
+// registerMetrics registers metrics of database pool, logins and recipes.
+func (kitchenNerd *KitchenNerd) registerMetrics() {
+	registry := kitchenNerd.Metrics.Registry
+
+	poolStat := func(value func(stats PoolStats) float64) func() float64 {
+		return func() float64 {
+			return value(kitchenNerd.Database.Stats())
+		}
+	}
+	registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "db_pool_acquired_connections",
+			Help: "Number of database connections in use.",
+		}, poolStat(func(stats PoolStats) float64 { return float64(stats.Acquired) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "db_pool_idle_connections",
+			Help: "Number of idle database connections.",
+		}, poolStat(func(stats PoolStats) float64 { return float64(stats.Idle) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "db_pool_max_connections",
+			Help: "Maximum number of database connections, zero is unlimited.",
+		}, poolStat(func(stats PoolStats) float64 { return float64(stats.Max) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "db_pool_wait_total",
+			Help: "Number of connection acquires which waited for a connection.",
+		}, poolStat(func(stats PoolStats) float64 { return float64(stats.WaitCount) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "db_pool_acquire_seconds_total",
+			Help: "Total time spent acquiring database connections, including waiting for them.",
+		}, poolStat(func(stats PoolStats) float64 { return stats.AcquireDuration.Seconds() })),
+	)
+
+	registry.MustRegister(metrics.NewCounterFunc("user_logins_total", "Number of login attempts by result.", []string{"result"},
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			stats := kitchenNerd.Users.Service.LoginStats()
+			return []metrics.Sample{
+				{LabelValues: []string{"success"}, Value: float64(stats.Succeeded)},
+				{LabelValues: []string{"failure"}, Value: float64(stats.Failed)},
+			}, nil
+		}))
+
+	registry.MustRegister(metrics.NewGaugeFunc("recipes", "Number of recipes.", nil,
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			count, err := kitchenNerd.Recipes.Service.Count(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return []metrics.Sample{{Value: float64(count)}}, nil
+		}))
+
+	if kitchenNerd.Recipes.Cache != nil {
+		registry.MustRegister(metrics.NewCounterFunc("recipes_cache_requests_total", "Number of recipes cache lookups by result.", []string{"result"},
+			func(ctx context.Context) ([]metrics.Sample, error) {
+				stats := kitchenNerd.Recipes.Cache.Stats()
+				return []metrics.Sample{
+					{LabelValues: []string{"hit"}, Value: float64(stats.Hits)},
+					{LabelValues: []string{"miss"}, Value: float64(stats.Misses)},
+				}, nil
+			}))
+	}
+}
+
 // we ignore cancellation and stopping errors since they are expected.
 func ignoreCancel(err error) error {
 	if errors.Is(err, context.Canceled) {
@@ -0,0 +1,48 @@
+package kitchen_nerd_test
+
+import (
+	"net"
+	"testing"
+
+	"kitchen_nerd"
+	"kitchen_nerd/database/memory"
+	"kitchen_nerd/pkg/config"
+	"kitchen_nerd/pkg/logger"
+)
+
+func TestNewClosesListenersOnFailure(t *testing.T) {
+	t.Setenv("DATABASE_URL", "memory://")
+
+	// console can't listen on the busy address, so New fails after metrics listener is opened.
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = busy.Close() }()
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricsAddress := free.Addr().String()
+	if err := free.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := new(kitchen_nerd.Config)
+	if err := config.Load(cfg, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	cfg.ServerAddress = busy.Addr().String()
+	cfg.MetricsAddress = metricsAddress
+
+	if _, err := kitchen_nerd.New(logger.NewNop(), *cfg, memory.New()); err == nil {
+		t.Fatal("expected error of busy console address")
+	}
+
+	listener, err := net.Listen("tcp", metricsAddress)
+	if err != nil {
+		t.Fatalf("metrics listener was not closed: %v", err)
+	}
+	_ = listener.Close()
+}
@@ -0,0 +1,126 @@
+package apperr
+
+import (
+	"github.com/zeebo/errs"
+)
+
+// Kind is a category of domain errors, clients can rely on it to decide how to handle an error.
+type Kind string
+
+const (
+	// KindInternal is an unexpected failure, default kind of errors without one.
+	KindInternal Kind = "internal"
+	// KindBadRequest indicates malformed request, e.g. invalid json or id.
+	KindBadRequest Kind = "bad_request"
+	// KindValidation indicates well-formed request with invalid values.
+	KindValidation Kind = "validation"
+	// KindUnauthorized indicates missing or wrong credentials.
+	KindUnauthorized Kind = "unauthorized"
+	// KindForbidden indicates that authenticated user is not allowed to perform the action.
+	KindForbidden Kind = "forbidden"
+	// KindNotFound indicates that requested entity does not exist.
+	KindNotFound Kind = "not_found"
+	// KindConflict indicates that the action conflicts with current state, e.g. duplicate email.
+	KindConflict Kind = "conflict"
+	// KindRateLimited indicates that client made too many requests.
+	KindRateLimited Kind = "rate_limited"
+	// KindTooLarge indicates that request body exceeds the size limit.
+	KindTooLarge Kind = "too_large"
+)
+
+// Generic classes of each kind, for errors that don't need a more specific code.
+var (
+	ErrBadRequest   = NewClass(KindBadRequest, "bad_request", "bad request")
+	ErrValidation   = NewClass(KindValidation, "validation_failed", "validation failed")
+	ErrUnauthorized = NewClass(KindUnauthorized, "unauthorized", "unauthorized")
+	ErrForbidden    = NewClass(KindForbidden, "forbidden", "forbidden")
+	ErrNotFound     = NewClass(KindNotFound, "not_found", "not found")
+	ErrConflict     = NewClass(KindConflict, "conflict", "conflict")
+	ErrRateLimited  = NewClass(KindRateLimited, "rate_limited", "rate limited")
+	ErrTooLarge     = NewClass(KindTooLarge, "too_large", "request too large")
+)
+
+// Error is a domain error of some kind with stable machine readable code.
+type Error struct {
+	Kind Kind
+	// Code is a stable machine readable code of the error, e.g. "recipe_not_found".
+	Code string
+	Err  error
+
+	class *Class
+}
+
+// Error returns error message.
+func (err *Error) Error() string { return err.Err.Error() }
+
+// Unwrap returns underlying error.
+func (err *Error) Unwrap() error { return err.Err }
+
+// Class creates domain errors of the same kind and code, it mirrors errs.Class.
+type Class struct {
+	kind  Kind
+	code  string
+	class errs.Class
+}
+
+// NewClass is a constructor for Class, message prefixes messages of all errors of the class.
+func NewClass(kind Kind, code, message string) *Class {
+	return &Class{kind: kind, code: code, class: errs.Class(message)}
+}
+
+// New returns error of the class with formatted message.
+func (class *Class) New(format string, args ...interface{}) error {
+	return class.create(class.class.New(format, args...))
+}
+
+// Wrap wraps err into error of the class, nil stays nil.
+func (class *Class) Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return class.create(class.class.Wrap(err))
+}
+
+// Has checks if err or any of its causes is of the class.
+func (class *Class) Has(err error) bool {
+	return errs.IsFunc(err, func(err error) bool {
+		domainErr, ok := err.(*Error)
+		return ok && domainErr.class == class
+	})
+}
+
+// Kind returns kind of errors of the class.
+func (class *Class) Kind() Kind { return class.kind }
+
+// Code returns code of errors of the class.
+func (class *Class) Code() string { return class.code }
+
+func (class *Class) create(err error) error {
+	return &Error{Kind: class.kind, Code: class.code, Err: err, class: class}
+}
+
+// Sentinel returns error value of the kind which can be compared with errors.Is.
+func Sentinel(kind Kind, code, message string) error {
+	return &Error{Kind: kind, Code: code, Err: errs.New("%s", message)}
+}
+
+// Find returns the outermost domain error in the err chain.
+func Find(err error) (*Error, bool) {
+	var found *Error
+	errs.IsFunc(err, func(err error) bool {
+		found, _ = err.(*Error)
+		return found != nil
+	})
+
+	return found, found != nil
+}
+
+// KindOf returns kind of err, errors without a domain kind are internal.
+func KindOf(err error) Kind {
+	if domainErr, ok := Find(err); ok {
+		return domainErr.Kind
+	}
+
+	return KindInternal
+}
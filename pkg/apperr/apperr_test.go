@@ -0,0 +1,63 @@
+package apperr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/pkg/apperr"
+)
+
+func TestFind(t *testing.T) {
+	errNoRecipe := apperr.NewClass(apperr.KindNotFound, "recipe_not_found", "recipe not found")
+	errInUse := apperr.Sentinel(apperr.KindConflict, "email_in_use", "email is already in use")
+	errController := errs.Class("controller error")
+
+	for _, test := range []struct {
+		name string
+		err  error
+		kind apperr.Kind
+		code string
+	}{
+		{"nil", nil, apperr.KindInternal, ""},
+		{"plain", errors.New("connection refused"), apperr.KindInternal, ""},
+		{"class", errNoRecipe.New(""), apperr.KindNotFound, "recipe_not_found"},
+		{"sentinel", errInUse, apperr.KindConflict, "email_in_use"},
+		{"wrapped by errs class", errController.Wrap(errNoRecipe.New("")), apperr.KindNotFound, "recipe_not_found"},
+		{"wrapped by fmt", fmt.Errorf("create: %w", errInUse), apperr.KindConflict, "email_in_use"},
+		{"domain cause of plain error", errNoRecipe.Wrap(errors.New("no rows")), apperr.KindNotFound, "recipe_not_found"},
+		{"outermost of nested", apperr.ErrBadRequest.Wrap(errController.Wrap(errNoRecipe.New(""))), apperr.KindBadRequest, "bad_request"},
+		{"outermost under errs class", errController.Wrap(apperr.ErrForbidden.Wrap(errInUse)), apperr.KindForbidden, "forbidden"},
+	} {
+		found, ok := apperr.Find(test.err)
+		if ok != (test.code != "") {
+			t.Fatalf("%s: expected found %t, got %+v", test.name, test.code != "", found)
+		}
+		if ok && (found.Kind != test.kind || found.Code != test.code) {
+			t.Fatalf("%s: expected %s %q, got %s %q", test.name, test.kind, test.code, found.Kind, found.Code)
+		}
+		if kind := apperr.KindOf(test.err); kind != test.kind {
+			t.Fatalf("%s: expected kind %s, got %s", test.name, test.kind, kind)
+		}
+	}
+}
+
+func TestClassHas(t *testing.T) {
+	errNoRecipe := apperr.NewClass(apperr.KindNotFound, "recipe_not_found", "recipe not found")
+	errNoUser := apperr.NewClass(apperr.KindNotFound, "user_not_found", "user not found")
+	errController := errs.Class("controller error")
+
+	err := errController.Wrap(apperr.ErrBadRequest.Wrap(errNoRecipe.New("")))
+	if !errNoRecipe.Has(err) || !apperr.ErrBadRequest.Has(err) {
+		t.Fatalf("expected classes of the chain in %v", err)
+	}
+	// classes of the same kind are still different.
+	if errNoUser.Has(err) || apperr.ErrNotFound.Has(err) {
+		t.Fatalf("unexpected class in %v", err)
+	}
+	if errNoRecipe.Wrap(nil) != nil {
+		t.Fatal("expected nil stays nil")
+	}
+}
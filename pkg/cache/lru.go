@@ -0,0 +1,110 @@
+// Package cache provides in-process caches.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a size bound cache, which evicts least recently used entries first
+// and treats entries older than ttl as missing. It is safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[K]*list.Element
+}
+
+// entry is a cached value with its expiration time.
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// NewLRU is a constructor for LRU cache of at most size entries living for ttl.
+func NewLRU[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[K]*list.Element),
+	}
+}
+
+// Get returns value by key if it is cached and not expired.
+func (cache *LRU[K, V]) Get(key K) (value V, ok bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return value, false
+	}
+
+	cached := element.Value.(*entry[K, V])
+	if time.Now().After(cached.expiresAt) {
+		cache.remove(element)
+		return value, false
+	}
+
+	cache.order.MoveToFront(element)
+
+	return cached.value, true
+}
+
+// Set caches value by key, evicting least recently used entry if cache is full.
+func (cache *LRU[K, V]) Set(key K, value V) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	expiresAt := time.Now().Add(cache.ttl)
+	if element, ok := cache.entries[key]; ok {
+		cached := element.Value.(*entry[K, V])
+		cached.value, cached.expiresAt = value, expiresAt
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	for cache.order.Len() > cache.size {
+		cache.remove(cache.order.Back())
+	}
+}
+
+// Delete removes value by key.
+func (cache *LRU[K, V]) Delete(key K) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if element, ok := cache.entries[key]; ok {
+		cache.remove(element)
+	}
+}
+
+// Purge removes all values.
+func (cache *LRU[K, V]) Purge() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.order.Init()
+	cache.entries = make(map[K]*list.Element)
+}
+
+// Len returns number of cached values, including expired ones which are not evicted yet.
+func (cache *LRU[K, V]) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.order.Len()
+}
+
+// remove removes the element, cache must be locked.
+func (cache *LRU[K, V]) remove(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,268 @@
+// Package config loads struct configuration from layers, each of them overrides the previous ones:
+//
+//  1. defaults from `envDefault` tags;
+//  2. config file in YAML or JSON;
+//  3. environment variables named by `env` tags;
+//  4. command line flags.
+//
+// Every field with `env` tag is a setting. Its name in config file and command line is derived
+// from the variable name, e.g. DATABASE_URL is set by "database-url" key and --database-url flag.
+// Settings marked by `notEmpty` option of `env` tag are required, fields tagged with `secret:"true"`
+// are redacted when printed.
+package config
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/zeebo/errs"
+	"gopkg.in/yaml.v3"
+)
+
+// Error indicates invalid configuration.
+var Error = errs.Class("config")
+
+// redacted replaces values of secrets.
+const redacted = "xxxxx"
+
+// setting is a single configurable field.
+type setting struct {
+	// Name is a key in config file and a flag name.
+	Name string
+	// Env is a name of environment variable.
+	Env      string
+	Default  string
+	Required bool
+	Secret   bool
+
+	value reflect.Value
+}
+
+// BindFlags registers a flag for every setting of cfg, cfg must be a pointer to struct.
+// Flags have no defaults, so that only flags set in command line override other layers.
+func BindFlags(flags *pflag.FlagSet, cfg interface{}) {
+	for _, setting := range settings(cfg) {
+		usage := "overrides env " + setting.Env
+		if setting.Default != "" {
+			usage += " (default " + strconv.Quote(setting.Default) + ")"
+		}
+
+		flags.String(setting.Name, "", usage)
+	}
+}
+
+// Load fills cfg from defaults, config file at path, environment and flags. Empty path skips the file,
+// nil flags skip command line. Returned error lists every invalid and missing setting.
+func Load(cfg interface{}, path string, flags *pflag.FlagSet) error {
+	file, err := readFile(path)
+	if err != nil {
+		return err
+	}
+
+	var group errs.Group
+	known := make(map[string]bool)
+	for _, setting := range settings(cfg) {
+		known[setting.Name] = true
+
+		value, source, ok := setting.Default, "default", setting.Default != ""
+		if fileValue, found := file[setting.Name]; found {
+			value, source, ok = fileValue, "config file key "+setting.Name, true
+		}
+		if envValue, found := os.LookupEnv(setting.Env); found {
+			value, source, ok = envValue, "env "+setting.Env, true
+		}
+		if flags != nil && flags.Changed(setting.Name) {
+			value, _ = flags.GetString(setting.Name)
+			source, ok = "flag --"+setting.Name, true
+		}
+
+		if ok {
+			if err := setValue(setting.value, value); err != nil {
+				group.Add(Error.New("%s: invalid value %q of %s: %v", setting.Name, value, source, err))
+				continue
+			}
+		}
+
+		if setting.Required && setting.value.IsZero() {
+			group.Add(Error.New("%s is required: set config file key %s, env %s or flag --%s",
+				setting.Name, setting.Name, setting.Env, setting.Name))
+		}
+	}
+
+	for name := range file {
+		if !known[name] {
+			group.Add(Error.New("unknown key %q in config file %s", name, path))
+		}
+	}
+
+	return group.Err()
+}
+
+// Print writes settings of cfg as a config file with secrets redacted.
+func Print(w io.Writer, cfg interface{}) error {
+	document := &yaml.Node{Kind: yaml.MappingNode}
+	for _, setting := range settings(cfg) {
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: formatValue(setting.value)}
+		switch setting.value.Kind() {
+		case reflect.Bool:
+			value.Tag = "!!bool"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if setting.value.Type() != reflect.TypeOf(time.Duration(0)) {
+				value.Tag = "!!int"
+			}
+		}
+		if setting.Secret {
+			value.Value = redact(value.Value)
+		}
+
+		document.Content = append(document.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: setting.Name}, value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return Error.Wrap(err)
+	}
+
+	return Error.Wrap(encoder.Close())
+}
+
+// settings lists settings of cfg in order of its fields, embedded structs are flattened.
+func settings(cfg interface{}) []setting {
+	var list []setting
+
+	var walk func(value reflect.Value)
+	walk = func(value reflect.Value) {
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				walk(value.Field(i))
+				continue
+			}
+
+			tag, ok := field.Tag.Lookup("env")
+			if !ok || !field.IsExported() {
+				continue
+			}
+
+			env, options, _ := strings.Cut(tag, ",")
+			list = append(list, setting{
+				Name:     strings.ReplaceAll(strings.ToLower(env), "_", "-"),
+				Env:      env,
+				Default:  field.Tag.Get("envDefault"),
+				Required: strings.Contains(options, "notEmpty"),
+				Secret:   field.Tag.Get("secret") == "true",
+				value:    value.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem())
+
+	return list
+}
+
+// readFile reads flat mapping of settings from YAML or JSON file, values are kept as text.
+func readFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+
+	var document map[string]yaml.Node
+	if err = yaml.Unmarshal(data, &document); err != nil {
+		return nil, Error.New("could not parse config file %s: %v", path, err)
+	}
+
+	values := make(map[string]string, len(document))
+	for name, node := range document {
+		if node.Kind != yaml.ScalarNode {
+			return nil, Error.New("key %q in config file %s must have a single value, line %d", name, path, node.Line)
+		}
+		values[name] = node.Value
+	}
+
+	return values, nil
+}
+
+// setValue parses text into field of a supported type.
+func setValue(field reflect.Value, text string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(text)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		field.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(value)
+	default:
+		return errs.New("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// formatValue formats field the way setValue parses it.
+func formatValue(field reflect.Value) string {
+	if duration, ok := field.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+
+	switch field.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10)
+	default:
+		return field.String()
+	}
+}
+
+// redact hides secret value, only password is hidden in urls to keep them recognizable.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	if parsed, err := url.Parse(value); err == nil && parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			return parsed.Redacted()
+		}
+	}
+
+	return redacted
+}
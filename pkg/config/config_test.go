@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"kitchen_nerd/pkg/config"
+)
+
+type testConfig struct {
+	DatabaseURL string        `env:"TEST_DATABASE_URL,notEmpty" secret:"true"`
+	Address     string        `env:"TEST_ADDRESS" envDefault:"127.0.0.1:8088"`
+	Timeout     time.Duration `env:"TEST_TIMEOUT" envDefault:"30s"`
+	Workers     int           `env:"TEST_WORKERS" envDefault:"1"`
+	Debug       bool          `env:"TEST_DEBUG"`
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := "test-database-url: postgres://kitchen:nerd@db/kitchen\ntest-timeout: 1m\ntest-workers: 2\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEST_WORKERS", "3")
+	t.Setenv("TEST_DEBUG", "true")
+
+	cfg := new(testConfig)
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	config.BindFlags(flags, cfg)
+	if err := flags.Parse([]string{"--test-debug=false"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.Load(cfg, path, flags); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := testConfig{
+		DatabaseURL: "postgres://kitchen:nerd@db/kitchen",
+		Address:     "127.0.0.1:8088",
+		Timeout:     time.Minute,
+		Workers:     3,
+		Debug:       false,
+	}
+	if *cfg != expected {
+		t.Fatalf("unexpected config\n got: %+v\nwant: %+v", *cfg, expected)
+	}
+
+	var printed strings.Builder
+	if err := config.Print(&printed, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPrint := `test-database-url: postgres://kitchen:xxxxx@db/kitchen
+test-address: 127.0.0.1:8088
+test-timeout: 1m0s
+test-workers: 3
+test-debug: false
+`
+	if printed.String() != expectedPrint {
+		t.Fatalf("unexpected print\n got: %s\nwant: %s", printed.String(), expectedPrint)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"test-timeout": "soon", "test-port": 80}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := config.Load(new(testConfig), path, nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, message := range []string{
+		"test-database-url is required",
+		`invalid value "soon" of config file key test-timeout`,
+		`unknown key "test-port"`,
+	} {
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("expected %q in %q", message, err.Error())
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status of the process or a component.
+type Status string
+
+const (
+	// StatusOK indicates that component is able to serve requests.
+	StatusOK Status = "ok"
+	// StatusFailing indicates that component is not able to serve requests.
+	StatusFailing Status = "failing"
+)
+
+// checkTimeout limits time of all readiness checks, probes usually time out in a few seconds.
+const checkTimeout = 3 * time.Second
+
+// Check reports whether a component is ready, nil error means it is.
+type Check func(ctx context.Context) error
+
+// Report is a body of health responses.
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// Component is readiness of a single component.
+type Component struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Checker runs readiness checks of registered components.
+type Checker struct {
+	mu     sync.Mutex
+	checks map[string]Check
+}
+
+// NewChecker is a constructor for Checker.
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]Check)}
+}
+
+// Add registers check of the named component.
+func (checker *Checker) Add(name string, check Check) {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	checker.checks[name] = check
+}
+
+// Check runs all checks concurrently and returns their breakdown, status is ok only when all components are.
+func (checker *Checker) Check(ctx context.Context) Report {
+	checker.mu.Lock()
+	names := make([]string, 0, len(checker.checks))
+	checks := make(map[string]Check, len(checker.checks))
+	for name, check := range checker.checks {
+		names = append(names, name)
+		checks[name] = check
+	}
+	checker.mu.Unlock()
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			errs[i] = check(ctx)
+		}(i, checks[name])
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Components: make(map[string]Component, len(names))}
+	for i, name := range names {
+		if errs[i] != nil {
+			report.Status = StatusFailing
+			report.Components[name] = Component{Status: StatusFailing, Error: errs[i].Error()}
+			continue
+		}
+		report.Components[name] = Component{Status: StatusOK}
+	}
+
+	return report
+}
+
+// LivenessHandler replies that process is alive.
+func LivenessHandler(w http.ResponseWriter, _ *http.Request) {
+	writeReport(w, Report{Status: StatusOK})
+}
+
+// ReadinessHandler replies with statuses of components, with 503 status when any of them fails.
+// Errors of checks are left out, since they may reveal internals on a public listener.
+func (checker *Checker) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	report := checker.Check(r.Context())
+	for name, component := range report.Components {
+		component.Error = ""
+		report.Components[name] = component
+	}
+
+	writeReport(w, report)
+}
+
+// DetailedReadinessHandler replies like ReadinessHandler including errors of checks,
+// it is meant for internal listeners only.
+func (checker *Checker) DetailedReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, checker.Check(r.Context()))
+}
+
+// writeReport writes report with status code matching its status.
+func writeReport(w http.ResponseWriter, report Report) {
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
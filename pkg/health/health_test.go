@@ -0,0 +1,64 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kitchen_nerd/pkg/health"
+)
+
+// serve sends readiness request to the handler and decodes its json body.
+func serve(t *testing.T, handler http.HandlerFunc) (int, health.Report) {
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+
+	var report health.Report
+	if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+
+	return recorder.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	checker := health.NewChecker()
+	checker.Add("database", func(ctx context.Context) error { return nil })
+
+	if status, report := serve(t, checker.ReadinessHandler); status != http.StatusOK || report.Status != health.StatusOK {
+		t.Fatalf("unexpected readiness %d %+v", status, report)
+	}
+
+	checker.Add("migrations", func(ctx context.Context) error { return errors.New("migration 0007 is pending") })
+
+	status, report := serve(t, checker.DetailedReadinessHandler)
+	if status != http.StatusServiceUnavailable || report.Status != health.StatusFailing {
+		t.Fatalf("unexpected readiness %d %+v", status, report)
+	}
+	if report.Components["database"].Status != health.StatusOK || report.Components["migrations"].Error != "migration 0007 is pending" {
+		t.Fatalf("unexpected components %+v", report.Components)
+	}
+
+	// public readiness doesn't reveal errors.
+	status, report = serve(t, checker.ReadinessHandler)
+	if status != http.StatusServiceUnavailable || report.Status != health.StatusFailing {
+		t.Fatalf("unexpected readiness %d %+v", status, report)
+	}
+	if migrations := report.Components["migrations"]; migrations.Status != health.StatusFailing || migrations.Error != "" {
+		t.Fatalf("unexpected migrations component %+v", migrations)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	status, report := serve(t, health.LivenessHandler)
+	if status != http.StatusOK || report.Status != health.StatusOK || len(report.Components) != 0 {
+		t.Fatalf("unexpected liveness %d %+v", status, report)
+	}
+}
@@ -1,11 +1,110 @@
 package logger
 
+import (
+	"context"
+	"sync"
+	"time"
+)
+
 // Logger exposes functionality to write messages in stdout.
 type Logger interface {
 	// Error is used to send formatted as error message.
-	Error(msg string, err error)
-	// Debug is used to send formatted debug message.
-	Debug(msg string)
+	Error(msg string, err error, fields ...Field)
 	// Warn is used to send formatted warning message.
-	Warn(msg string)
+	Warn(msg string, fields ...Field)
+	// Info is used to send formatted info message.
+	Info(msg string, fields ...Field)
+	// Debug is used to send formatted debug message.
+	Debug(msg string, fields ...Field)
+	// With returns logger which adds fields to every message.
+	With(fields ...Field) Logger
+}
+
+// Field is a key value pair attached to a log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns string field.
+func String(key, value string) Field { return Field{Key: key, Value: value} }
+
+// Int returns integer field.
+func Int(key string, value int) Field { return Field{Key: key, Value: value} }
+
+// Duration returns duration field.
+func Duration(key string, value time.Duration) Field { return Field{Key: key, Value: value} }
+
+// Any returns field of arbitrary value.
+func Any(key string, value interface{}) Field { return Field{Key: key, Value: value} }
+
+// NewNop returns logger which discards all messages.
+func NewNop() Logger { return nop{} }
+
+// nop is a logger which discards all messages.
+type nop struct{}
+
+func (nop) Error(string, error, ...Field) {}
+func (nop) Warn(string, ...Field)         {}
+func (nop) Info(string, ...Field)         {}
+func (nop) Debug(string, ...Field)        {}
+func (nop) With(...Field) Logger          { return nop{} }
+
+// Entry collects fields of a message written once an operation completes, e.g. of a request log,
+// so that handlers deeper in the call chain can annotate it.
+type Entry struct {
+	mu     sync.Mutex
+	fields []Field
+}
+
+// Add adds fields to the entry.
+func (entry *Entry) Add(fields ...Field) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	entry.fields = append(entry.fields, fields...)
+}
+
+// Fields returns fields of the entry.
+func (entry *Entry) Fields() []Field {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	return append([]Field(nil), entry.fields...)
+}
+
+type (
+	loggerKey struct{}
+	entryKey  struct{}
+)
+
+// NewContext returns context carrying the logger.
+func NewContext(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// FromContext returns logger of the context or fallback if context carries none.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if log, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return log
+	}
+
+	return fallback
+}
+
+// WithEntry returns context carrying the entry.
+func WithEntry(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// Annotate adds fields to the logger and to the entry of the context, if there are any.
+func Annotate(ctx context.Context, fields ...Field) context.Context {
+	if entry, ok := ctx.Value(entryKey{}).(*Entry); ok {
+		entry.Add(fields...)
+	}
+	if log, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		ctx = NewContext(ctx, log.With(fields...))
+	}
+
+	return ctx
 }
@@ -2,6 +2,7 @@ package zaplog
 
 import (
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"kitchen_nerd/pkg/logger"
 )
@@ -14,24 +15,61 @@ type zaplog struct {
 	client *zap.Logger
 }
 
-// NewLog is a constructor for a logger.Logger.
-func NewLog() logger.Logger {
-	return &zaplog{
-		client: zap.NewExample(),
+// NewLog is a constructor for a logger.Logger which writes json messages of the level and above to stdout,
+// level is one of "debug", "info", "warn" or "error".
+func NewLog(level string) (logger.Logger, error) {
+	atomicLevel, err := zap.ParseAtomicLevel(level)
+	if err != nil {
+		return nil, err
 	}
+
+	config := zap.NewProductionConfig()
+	config.Level = atomicLevel
+	config.Sampling = nil
+	config.OutputPaths = []string{"stdout"}
+	config.EncoderConfig.TimeKey = "time"
+	config.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	config.EncoderConfig.EncodeDuration = zapcore.MillisDurationEncoder
+
+	client, err := config.Build(zap.AddCallerSkip(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return &zaplog{client: client}, nil
 }
 
 // Debug is used to send formatted debug message.
-func (log zaplog) Debug(msg string) {
-	log.client.Debug(msg)
+func (log zaplog) Debug(msg string, fields ...logger.Field) {
+	log.client.Debug(msg, zapFields(fields)...)
+}
+
+// Info is used to send formatted info message.
+func (log zaplog) Info(msg string, fields ...logger.Field) {
+	log.client.Info(msg, zapFields(fields)...)
 }
 
 // Warn is used to send formatted warn message.
-func (log zaplog) Warn(msg string) {
-	log.client.Warn(msg)
+func (log zaplog) Warn(msg string, fields ...logger.Field) {
+	log.client.Warn(msg, zapFields(fields)...)
 }
 
 // Error is used to send formatted as error message.
-func (log zaplog) Error(msg string, err error) {
-	log.client.Error(msg, zap.Error(err))
+func (log zaplog) Error(msg string, err error, fields ...logger.Field) {
+	log.client.Error(msg, append(zapFields(fields), zap.Error(err))...)
+}
+
+// With returns logger which adds fields to every message.
+func (log zaplog) With(fields ...logger.Field) logger.Logger {
+	return &zaplog{client: log.client.With(zapFields(fields)...)}
+}
+
+// zapFields converts fields to zap ones.
+func zapFields(fields []logger.Field) []zap.Field {
+	converted := make([]zap.Field, 0, len(fields))
+	for _, field := range fields {
+		converted = append(converted, zap.Any(field.Key, field.Value))
+	}
+
+	return converted
 }
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/zeebo/errs"
+)
+
+// Error indicates that metrics could not be collected.
+var Error = errs.Class("metrics error")
+
+// NewRegistry returns Prometheus registry with Go runtime and process metrics registered.
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return registry
+}
+
+// Sample is a value of a metric with specific label values.
+type Sample struct {
+	// LabelValues are in order of label names of the metric.
+	LabelValues []string
+	Value       float64
+}
+
+// CollectFunc returns samples of a metric, it is called on every scrape.
+type CollectFunc func(ctx context.Context) ([]Sample, error)
+
+// NewCounterFunc returns a counter with the label names which samples are collected on every scrape,
+// e.g. from stats of a component.
+func NewCounterFunc(name, help string, labels []string, collect CollectFunc) prometheus.Collector {
+	return &funcCollector{
+		desc:      prometheus.NewDesc(name, help, labels, nil),
+		valueType: prometheus.CounterValue,
+		collect:   collect,
+	}
+}
+
+// NewGaugeFunc returns a gauge with the label names which samples are collected on every scrape.
+func NewGaugeFunc(name, help string, labels []string, collect CollectFunc) prometheus.Collector {
+	return &funcCollector{
+		desc:      prometheus.NewDesc(name, help, labels, nil),
+		valueType: prometheus.GaugeValue,
+		collect:   collect,
+	}
+}
+
+// funcCollector is a metric collected by a function which may fail, unlike prometheus.GaugeFunc.
+type funcCollector struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	collect   CollectFunc
+}
+
+// Describe sends descriptor of the metric.
+func (collector *funcCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.desc
+}
+
+// Collect sends samples of the metric, failure is reported as an invalid metric,
+// so the rest of metrics are still served.
+func (collector *funcCollector) Collect(ch chan<- prometheus.Metric) {
+	samples, err := collector.collect(context.Background())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(collector.desc, Error.Wrap(err))
+		return
+	}
+
+	for _, sample := range samples {
+		metric, err := prometheus.NewConstMetric(collector.desc, collector.valueType, sample.Value, sample.LabelValues...)
+		if err != nil {
+			metric = prometheus.NewInvalidMetric(collector.desc, Error.Wrap(err))
+		}
+
+		ch <- metric
+	}
+}
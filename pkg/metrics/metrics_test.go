@@ -0,0 +1,78 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/pkg/metrics"
+)
+
+func TestHandler(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	registry.MustRegister(
+		metrics.NewGaugeFunc("recipes", "Number of recipes.", []string{"kind"}, func(ctx context.Context) ([]metrics.Sample, error) {
+			return []metrics.Sample{
+				{LabelValues: []string{"soup"}, Value: 3},
+				{LabelValues: []string{`say "borsch"`}, Value: 1},
+			}, nil
+		}),
+		metrics.NewCounterFunc("logins_total", "Number of logins.", nil, func(ctx context.Context) ([]metrics.Sample, error) {
+			return []metrics.Sample{{Value: 7}}, nil
+		}),
+		metrics.NewGaugeFunc("broken", "Fails to collect.", nil, func(ctx context.Context) ([]metrics.Sample, error) {
+			return nil, errors.New("database is down")
+		}),
+	)
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler(logger.NewNop(), registry).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	body, err := io.ReadAll(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exposition := string(body)
+
+	// failed metric is skipped, the rest are still served.
+	for _, line := range []string{
+		"# TYPE recipes gauge",
+		`recipes{kind="soup"} 3`,
+		`recipes{kind="say \"borsch\""} 1`,
+		"# TYPE logins_total counter",
+		"logins_total 7",
+	} {
+		if !strings.Contains(exposition, line) {
+			t.Fatalf("metrics don't contain %q:\n%s", line, exposition)
+		}
+	}
+	if strings.Contains(exposition, "broken") {
+		t.Fatalf("failed metric is served:\n%s", exposition)
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	families, err := metrics.NewRegistry().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool, len(families))
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+	if !names["go_goroutines"] {
+		t.Fatalf("runtime metrics are not registered: %v", names)
+	}
+}
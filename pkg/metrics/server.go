@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/sync/errgroup"
+
+	"kitchen_nerd/pkg/health"
+	"kitchen_nerd/pkg/logger"
+)
+
+// Handler serves metrics of the gatherer in Prometheus exposition format.
+// Metrics which failed to collect are logged and left out of the response.
+func Handler(log logger.Logger, gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+		ErrorLog:      errorLog{log: log},
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// errorLog reports errors of metrics collection to the logger.
+type errorLog struct {
+	log logger.Logger
+}
+
+// Println logs metrics collection error.
+func (errorLog errorLog) Println(v ...interface{}) {
+	errorLog.log.Error("could not collect metrics", Error.New("%s", fmt.Sprint(v...)))
+}
+
+// Server serves /metrics and detailed /readyz on an internal listener, separately from the public endpoints.
+//
+// architecture: Endpoint
+type Server struct {
+	listener net.Listener
+	server   http.Server
+}
+
+// NewServer is a constructor for metrics server.
+func NewServer(log logger.Logger, listener net.Listener, gatherer prometheus.Gatherer, checker *health.Checker) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(log, gatherer))
+	mux.HandleFunc("/readyz", checker.DetailedReadinessHandler)
+
+	return &Server{
+		listener: listener,
+		server:   http.Server{Handler: mux},
+	}
+}
+
+// Run serves metrics until context is canceled.
+func (server *Server) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var group errgroup.Group
+	group.Go(func() error {
+		<-ctx.Done()
+		return Error.Wrap(server.server.Shutdown(context.Background()))
+	})
+	group.Go(func() error {
+		defer cancel()
+		err := server.server.Serve(server.listener)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		return Error.Wrap(err)
+	})
+
+	return group.Wait()
+}
+
+// Close closes server and underlying listener.
+func (server *Server) Close() error {
+	return Error.Wrap(server.server.Close())
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/pkg/apperr"
+)
+
+// ErrInvalidCursor indicates that pagination cursor can't be decoded.
+var ErrInvalidCursor = apperr.NewClass(apperr.KindBadRequest, "invalid_cursor", "invalid cursor")
+
+// Cursor points at a row of a list sorted by creation time and id, it is passed to clients as an opaque string.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"i"`
+	// Backward means that the page before the row is requested.
+	Backward bool `json:"b,omitempty"`
+}
+
+// NewCursor is a constructor for Cursor.
+func NewCursor(createdAt time.Time, id uuid.UUID, backward bool) *Cursor {
+	return &Cursor{CreatedAt: createdAt, ID: id, Backward: backward}
+}
+
+// Encode returns opaque string representation of the cursor.
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses cursor from its opaque string representation.
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+
+	cursor := new(Cursor)
+	if err = json.Unmarshal(data, cursor); err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,96 @@
+package util_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/pkg/util"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	createdAt := time.Date(2023, 9, 1, 12, 0, 0, 123456000, time.UTC)
+
+	for _, cursor := range []*util.Cursor{
+		util.NewCursor(createdAt, uuid.New(), false),
+		util.NewCursor(createdAt, uuid.New(), true),
+	} {
+		decoded, err := util.DecodeCursor(cursor.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID || decoded.Backward != cursor.Backward {
+			t.Fatalf("expected cursor %+v, got %+v", cursor, decoded)
+		}
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	encoded := util.NewCursor(time.Now(), uuid.New(), false).Encode()
+
+	for name, invalid := range map[string]string{
+		"not base64":   "not a cursor!",
+		"padded":       encoded + "==",
+		"truncated":    encoded[:len(encoded)-4],
+		"not json":     base64.RawURLEncoding.EncodeToString([]byte("cursor")),
+		"wrong time":   base64.RawURLEncoding.EncodeToString([]byte(`{"t":"yesterday","i":"` + uuid.NewString() + `"}`)),
+		"wrong id":     base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2023-09-01T12:00:00Z","i":"42"}`)),
+		"wrong fields": base64.RawURLEncoding.EncodeToString([]byte(`[1,2]`)),
+	} {
+		if _, err := util.DecodeCursor(invalid); !util.ErrInvalidCursor.Has(err) {
+			t.Fatalf("%s: expected invalid cursor error, got %v", name, err)
+		}
+	}
+}
+
+func TestPageCursors(t *testing.T) {
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	first := *util.NewCursor(now, uuid.New(), false)
+	last := *util.NewCursor(now.Add(-time.Hour), uuid.New(), false)
+
+	forward := util.NewCursor(now.Add(time.Hour), uuid.New(), false)
+	backward := util.NewCursor(now.Add(-2*time.Hour), uuid.New(), true)
+
+	for _, test := range []struct {
+		name       string
+		pagination *util.PaginationReq
+		hasMore    bool
+		next, prev bool
+	}{
+		{"first page", &util.PaginationReq{Size: 2, Page: 1}, true, true, false},
+		{"single page", &util.PaginationReq{Size: 2, Page: 1}, false, false, false},
+		{"last offset page", &util.PaginationReq{Size: 2, Page: 3}, false, false, true},
+		{"middle offset page", &util.PaginationReq{Size: 2, Page: 2}, true, true, true},
+		{"next page", &util.PaginationReq{Size: 2, Cursor: forward}, true, true, true},
+		{"last page", &util.PaginationReq{Size: 2, Cursor: forward}, false, false, true},
+		{"previous page", &util.PaginationReq{Size: 2, Cursor: backward}, true, true, true},
+		{"first page backward", &util.PaginationReq{Size: 2, Cursor: backward}, false, true, false},
+	} {
+		cursors := util.PageCursors(test.pagination, test.hasMore, first, last)
+		if (cursors.Next != "") != test.next || (cursors.Prev != "") != test.prev {
+			t.Fatalf("%s: expected next %v and prev %v, got %+v", test.name, test.next, test.prev, cursors)
+		}
+
+		// next page starts after the last row, previous page ends before the first one.
+		if test.next {
+			next, err := util.DecodeCursor(cursors.Next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if next.ID != last.ID || !next.CreatedAt.Equal(last.CreatedAt) || next.Backward {
+				t.Fatalf("%s: unexpected next cursor %+v", test.name, next)
+			}
+		}
+		if test.prev {
+			prev, err := util.DecodeCursor(cursors.Prev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prev.ID != first.ID || !prev.CreatedAt.Equal(first.CreatedAt) || !prev.Backward {
+				t.Fatalf("%s: unexpected previous cursor %+v", test.name, prev)
+			}
+		}
+	}
+}
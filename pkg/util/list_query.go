@@ -0,0 +1,182 @@
+package util
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/pkg/apperr"
+)
+
+// ErrInvalidListQuery indicates that sort or filter query params are invalid.
+var ErrInvalidListQuery = apperr.NewClass(apperr.KindBadRequest, "invalid_list_query", "invalid list query")
+
+// FieldType defines type of a listed field value.
+type FieldType string
+
+const (
+	// FieldString is a text field.
+	FieldString FieldType = "string"
+	// FieldTime is a time field, values are RFC 3339 times or dates.
+	FieldTime FieldType = "time"
+	// FieldNumber is a numeric field.
+	FieldNumber FieldType = "number"
+	// FieldBool is a boolean field.
+	FieldBool FieldType = "bool"
+	// FieldUUID is an id field.
+	FieldUUID FieldType = "uuid"
+)
+
+// Operator defines how field is compared with a filter value.
+type Operator string
+
+// Possible values of filter operator.
+const (
+	OperatorEq       Operator = "eq"
+	OperatorNe       Operator = "ne"
+	OperatorContains Operator = "contains"
+	OperatorGt       Operator = "gt"
+	OperatorGte      Operator = "gte"
+	OperatorLt       Operator = "lt"
+	OperatorLte      Operator = "lte"
+)
+
+// operators lists which operators can be applied to fields of each type.
+var operators = map[FieldType][]Operator{
+	FieldString: {OperatorEq, OperatorNe, OperatorContains},
+	FieldTime:   {OperatorEq, OperatorNe, OperatorGt, OperatorGte, OperatorLt, OperatorLte},
+	FieldNumber: {OperatorEq, OperatorNe, OperatorGt, OperatorGte, OperatorLt, OperatorLte},
+	FieldBool:   {OperatorEq, OperatorNe},
+	FieldUUID:   {OperatorEq, OperatorNe},
+}
+
+// ListField describes a field clients may filter and sort a list by.
+type ListField struct {
+	Type     FieldType
+	Sortable bool
+}
+
+// ListFields is a whitelist of fields of a listed resource by their names in the api.
+type ListFields map[string]ListField
+
+// Sort defines ordering by a field.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Condition defines filtering by a field, value has go type matching field type.
+type Condition struct {
+	Field    string
+	Operator Operator
+	Value    interface{}
+}
+
+// ListQuery contains sorting and filtering of a list.
+type ListQuery struct {
+	Sort    []Sort
+	Filters []Condition
+}
+
+// filterParam matches filter query params like filter[title][contains] or filter[title].
+var filterParam = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+// ParseListQuery parses sort and filter query params, e.g.
+// sort=-createdAt,title&filter[title][contains]=borsch&filter[createdAt][gte]=2023-01-01.
+// Only fields from the whitelist are accepted.
+func ParseListQuery(q url.Values, fields ListFields) (*ListQuery, error) {
+	query := new(ListQuery)
+
+	if sorting := q.Get("sort"); sorting != "" {
+		for _, name := range strings.Split(sorting, ",") {
+			order := Sort{Field: strings.TrimSpace(name)}
+			if strings.HasPrefix(order.Field, "-") {
+				order.Field, order.Desc = order.Field[1:], true
+			}
+
+			field, ok := fields[order.Field]
+			if !ok || !field.Sortable {
+				return nil, ErrInvalidListQuery.New("can't sort by %q", order.Field)
+			}
+
+			query.Sort = append(query.Sort, order)
+		}
+	}
+
+	params := make([]string, 0, len(q))
+	for param := range q {
+		params = append(params, param)
+	}
+	// params are sorted, so same query always produces same sql.
+	sort.Strings(params)
+
+	for _, param := range params {
+		values := q[param]
+		match := filterParam.FindStringSubmatch(param)
+		if match == nil {
+			continue
+		}
+
+		name, operator := match[1], Operator(match[2])
+		if operator == "" {
+			operator = OperatorEq
+		}
+
+		field, ok := fields[name]
+		if !ok {
+			return nil, ErrInvalidListQuery.New("can't filter by %q", name)
+		}
+		if !isOperatorAllowed(field.Type, operator) {
+			return nil, ErrInvalidListQuery.New("operator %q can't be applied to %q", operator, name)
+		}
+
+		for _, raw := range values {
+			value, err := parseFieldValue(field.Type, raw)
+			if err != nil {
+				return nil, ErrInvalidListQuery.New("invalid value of %q: %v", name, err)
+			}
+
+			query.Filters = append(query.Filters, Condition{Field: name, Operator: operator, Value: value})
+		}
+	}
+
+	return query, nil
+}
+
+// IsSorted checks if custom sorting is requested.
+func (query *ListQuery) IsSorted() bool {
+	return query != nil && len(query.Sort) > 0
+}
+
+func isOperatorAllowed(fieldType FieldType, operator Operator) bool {
+	for _, allowed := range operators[fieldType] {
+		if allowed == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFieldValue converts raw query value to go type matching field type.
+func parseFieldValue(fieldType FieldType, raw string) (interface{}, error) {
+	switch fieldType {
+	case FieldTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02", raw)
+	case FieldNumber:
+		return strconv.ParseFloat(raw, 64)
+	case FieldBool:
+		return strconv.ParseBool(raw)
+	case FieldUUID:
+		return uuid.Parse(raw)
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,149 @@
+package util_test
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/pkg/util"
+)
+
+// testFields is a whitelist of fields of a listed resource.
+var testFields = util.ListFields{
+	"title":     {Type: util.FieldString, Sortable: true},
+	"createdAt": {Type: util.FieldTime, Sortable: true},
+	"servings":  {Type: util.FieldNumber, Sortable: true},
+	"public":    {Type: util.FieldBool},
+	"authorID":  {Type: util.FieldUUID},
+}
+
+func TestParseListQuery(t *testing.T) {
+	authorID := uuid.New()
+
+	for _, test := range []struct {
+		name     string
+		query    string
+		expected util.ListQuery
+	}{
+		{
+			name:  "empty",
+			query: "page=1&size=10",
+		},
+		{
+			name:  "sort",
+			query: "sort=-createdAt, title",
+			expected: util.ListQuery{Sort: []util.Sort{
+				{Field: "createdAt", Desc: true},
+				{Field: "title"},
+			}},
+		},
+		{
+			name:  "default operator",
+			query: "filter[title]=borsch",
+			expected: util.ListQuery{Filters: []util.Condition{
+				{Field: "title", Operator: util.OperatorEq, Value: "borsch"},
+			}},
+		},
+		{
+			name:  "typed values",
+			query: "filter[servings][gte]=2.5&filter[public]=true&filter[authorID][ne]=" + authorID.String() + "&filter[title][contains]=50%25_off",
+			expected: util.ListQuery{Filters: []util.Condition{
+				{Field: "authorID", Operator: util.OperatorNe, Value: authorID},
+				{Field: "public", Operator: util.OperatorEq, Value: true},
+				{Field: "servings", Operator: util.OperatorGte, Value: 2.5},
+				{Field: "title", Operator: util.OperatorContains, Value: "50%_off"},
+			}},
+		},
+		{
+			name:  "time",
+			query: "filter[createdAt][gte]=2023-09-01&filter[createdAt][lt]=2023-09-02T10:30:00%2B02:00",
+			expected: util.ListQuery{Filters: []util.Condition{
+				{Field: "createdAt", Operator: util.OperatorGte, Value: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)},
+				{Field: "createdAt", Operator: util.OperatorLt, Value: time.Date(2023, 9, 2, 8, 30, 0, 0, time.UTC)},
+			}},
+		},
+		{
+			name:  "repeated filter",
+			query: "filter[title][ne]=borsch&filter[title][ne]=okroshka",
+			expected: util.ListQuery{Filters: []util.Condition{
+				{Field: "title", Operator: util.OperatorNe, Value: "borsch"},
+				{Field: "title", Operator: util.OperatorNe, Value: "okroshka"},
+			}},
+		},
+	} {
+		values, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		query, err := util.ParseListQuery(values, testFields)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !listQueryEqual(*query, test.expected) {
+			t.Fatalf("%s: expected %+v, got %+v", test.name, test.expected, *query)
+		}
+		if query.IsSorted() != (len(test.expected.Sort) > 0) {
+			t.Fatalf("%s: unexpected sorted state", test.name)
+		}
+	}
+}
+
+func TestParseListQueryInvalid(t *testing.T) {
+	for name, query := range map[string]string{
+		"unknown sort field":   "sort=author",
+		"unknown desc field":   "sort=-author",
+		"not sortable field":   "sort=public",
+		"empty sort field":     "sort=title,",
+		"unknown filter field": "filter[author]=alice",
+		"unknown operator":     "filter[title][like]=borsch",
+		"operator of type":     "filter[title][gt]=borsch",
+		"bool operator":        "filter[public][contains]=true",
+		"bad time":             "filter[createdAt][gte]=yesterday",
+		"bad number":           "filter[servings]=two",
+		"bad bool":             "filter[public]=maybe",
+		"bad uuid":             "filter[authorID]=42",
+	} {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = util.ParseListQuery(values, testFields); !util.ErrInvalidListQuery.Has(err) {
+			t.Fatalf("%s: expected invalid list query error, got %v", name, err)
+		}
+	}
+}
+
+// listQueryEqual compares list queries, times are compared as instants.
+func listQueryEqual(expected, actual util.ListQuery) bool {
+	if len(expected.Sort) != len(actual.Sort) || len(expected.Filters) != len(actual.Filters) {
+		return false
+	}
+	if len(expected.Sort) > 0 && !reflect.DeepEqual(expected.Sort, actual.Sort) {
+		return false
+	}
+
+	for i, condition := range expected.Filters {
+		other := actual.Filters[i]
+		if condition.Field != other.Field || condition.Operator != other.Operator {
+			return false
+		}
+
+		if expectedTime, ok := condition.Value.(time.Time); ok {
+			actualTime, ok := other.Value.(time.Time)
+			if !ok || !expectedTime.Equal(actualTime) {
+				return false
+			}
+			continue
+		}
+		if condition.Value != other.Value {
+			return false
+		}
+	}
+
+	return true
+}
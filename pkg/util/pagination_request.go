@@ -8,6 +8,8 @@ import (
 type PaginationReq struct {
 	Size uint64 `json:"size"`
 	Page uint64 `json:"page"`
+	// Cursor switches pagination to keyset mode, where page is ignored.
+	Cursor *Cursor `json:"cursor"`
 }
 
 func NewPaginationReq(size uint64, page uint64) *PaginationReq {
@@ -26,7 +28,7 @@ func (r *PaginationReq) ProcessQueryParams(q url.Values) error {
 
 	r.Size = limit
 	r.Page = page
-	return nil
+	return r.processCursor(q)
 }
 
 func (r *PaginationReq) ProcessQueryParamsOptional(q url.Values) error {
@@ -42,7 +44,20 @@ func (r *PaginationReq) ProcessQueryParamsOptional(q url.Values) error {
 
 	r.Size = size
 	r.Page = page
-	return nil
+	return r.processCursor(q)
+}
+
+func (r *PaginationReq) processCursor(q url.Values) (err error) {
+	if v := q.Get("cursor"); v != "" {
+		r.Cursor, err = DecodeCursor(v)
+	}
+
+	return err
+}
+
+// IsKeyset checks if pagination is made by cursor.
+func (r *PaginationReq) IsKeyset() bool {
+	return r.Cursor != nil
 }
 
 func (r *PaginationReq) parseValue(v, defaultValue, errMsg string) (uint64, error) {
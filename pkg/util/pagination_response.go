@@ -1,11 +1,48 @@
 package util
 
 type PaginationResponse struct {
-	Size  uint64 `json:"size"`
-	Page  uint64 `json:"page"`
-	Total uint64 `json:"total"`
+	Size       uint64 `json:"size"`
+	Page       uint64 `json:"page"`
+	Total      uint64 `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
 }
 
 func NewPaginationResponse(size, page, total uint64) *PaginationResponse {
 	return &PaginationResponse{Size: size, Page: page, Total: total}
 }
+
+// Cursors points at pages next to the current one, empty cursor means there is no such page.
+type Cursors struct {
+	Next string
+	Prev string
+}
+
+// PageCursors returns cursors of pages around a page of a list sorted by creation time and id, newest first.
+// First and last point at bounds of the page, hasMore reports that there are more rows in the direction of listing.
+func PageCursors(pagination *PaginationReq, hasMore bool, first, last Cursor) Cursors {
+	var cursors Cursors
+
+	cursor := pagination.Cursor
+	hasNext := hasMore
+	hasPrev := (cursor == nil && pagination.Page > 1) || (cursor != nil && !cursor.Backward)
+	if cursor != nil && cursor.Backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	if hasNext {
+		cursors.Next = NewCursor(last.CreatedAt, last.ID, false).Encode()
+	}
+	if hasPrev {
+		cursors.Prev = NewCursor(first.CreatedAt, first.ID, true).Encode()
+	}
+
+	return cursors
+}
+
+// WithCursors sets cursors of neighbouring pages.
+func (r *PaginationResponse) WithCursors(cursors Cursors) *PaginationResponse {
+	r.NextCursor = cursors.Next
+	r.PrevCursor = cursors.Prev
+	return r
+}
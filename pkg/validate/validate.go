@@ -0,0 +1,260 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"kitchen_nerd/pkg/apperr"
+)
+
+// FieldError describes invalid value of a request field.
+type FieldError struct {
+	// Field is a path of the field in the request, e.g. "ingredients[2].unit".
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is a list of invalid fields of a request.
+type Errors []FieldError
+
+// Error returns messages of all invalid fields.
+func (errors Errors) Error() string {
+	messages := make([]string, 0, len(errors))
+	for _, err := range errors {
+		messages = append(messages, err.Field+" "+err.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Rule checks value of a field, param is the rule parameter from the tag, e.g. "3" of "min=3".
+// It returns message describing why the value is invalid or empty string when the value is valid.
+type Rule func(value reflect.Value, param string) string
+
+var (
+	mu    sync.RWMutex
+	rules = map[string]Rule{
+		"required": required,
+		"min":      minimum,
+		"max":      maximum,
+		"oneof":    oneOf,
+		"email":    email,
+	}
+)
+
+// Register adds rule that can be used in validate tags by its name, e.g. rules of domain values.
+func Register(name string, rule Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	rules[name] = rule
+}
+
+// Struct checks fields of v against rules of their validate tags, e.g. `validate:"required,max=255"`.
+// Nested structs and slices of structs are checked as well, nil pointers are treated as omitted
+// optional fields. It returns validation error listing all invalid fields or nil.
+//
+// Besides registered rules, "eqfield=Name" checks that the field equals to the sibling field Name.
+func Struct(v interface{}) error {
+	var errors Errors
+	check(reflect.ValueOf(v), "", &errors)
+	if len(errors) == 0 {
+		return nil
+	}
+
+	return apperr.ErrValidation.Wrap(errors)
+}
+
+// check walks into value and collects invalid fields.
+func check(value reflect.Value, path string, errors *Errors) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		checkStruct(value, path, errors)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			check(value.Index(i), fmt.Sprintf("%s[%d]", path, i), errors)
+		}
+	}
+}
+
+// checkStruct applies rules of struct fields.
+func checkStruct(value reflect.Value, path string, errors *Errors) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := fieldName(field)
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+
+		fieldValue := value.Field(i)
+		if field.Type.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		if message := checkTag(value, fieldValue, field.Tag.Get("validate")); message != "" {
+			*errors = append(*errors, FieldError{Field: fieldPath, Message: message})
+			continue
+		}
+
+		check(fieldValue, fieldPath, errors)
+	}
+}
+
+// checkTag applies rules of the tag in order and returns message of the first failed one.
+func checkTag(parent, value reflect.Value, tag string) string {
+	if tag == "" {
+		return ""
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		if name == "eqfield" {
+			sibling, ok := parent.Type().FieldByName(param)
+			if !ok {
+				panic(fmt.Sprintf("validate: unknown field %q in eqfield rule of %s", param, parent.Type()))
+			}
+			if !value.Equal(parent.FieldByIndex(sibling.Index)) {
+				return "must match " + fieldName(sibling)
+			}
+			continue
+		}
+
+		apply, ok := rules[name]
+		if !ok {
+			panic(fmt.Sprintf("validate: unknown rule %q", name))
+		}
+		if message := apply(value, param); message != "" {
+			return message
+		}
+	}
+
+	return ""
+}
+
+// fieldName returns name of the field in json, which clients refer to.
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
+
+func required(value reflect.Value, _ string) string {
+	if value.IsZero() {
+		return "is required"
+	}
+
+	return ""
+}
+
+func minimum(value reflect.Value, param string) string {
+	limit := number(param)
+
+	switch value.Kind() {
+	case reflect.String:
+		if float64(utf8.RuneCountInString(value.String())) < limit {
+			return "must be at least " + param + " characters long"
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if float64(value.Len()) < limit {
+			return "must contain at least " + param + " items"
+		}
+	default:
+		if toFloat(value) < limit {
+			return "must be at least " + param
+		}
+	}
+
+	return ""
+}
+
+func maximum(value reflect.Value, param string) string {
+	limit := number(param)
+
+	switch value.Kind() {
+	case reflect.String:
+		if float64(utf8.RuneCountInString(value.String())) > limit {
+			return "must be at most " + param + " characters long"
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if float64(value.Len()) > limit {
+			return "must contain at most " + param + " items"
+		}
+	default:
+		if toFloat(value) > limit {
+			return "must be at most " + param
+		}
+	}
+
+	return ""
+}
+
+func oneOf(value reflect.Value, param string) string {
+	options := strings.Fields(param)
+	for _, option := range options {
+		if fmt.Sprint(value.Interface()) == option {
+			return ""
+		}
+	}
+
+	return "must be one of: " + strings.Join(options, ", ")
+}
+
+func email(value reflect.Value, _ string) string {
+	address, err := mail.ParseAddress(value.String())
+	if err != nil || address.Address != value.String() {
+		return "must be a valid email address"
+	}
+
+	return ""
+}
+
+// number parses numeric rule param, invalid params are programming errors.
+func number(param string) float64 {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validate: invalid number %q", param))
+	}
+
+	return n
+}
+
+// toFloat returns numeric value of the field.
+func toFloat(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	default:
+		panic(fmt.Sprintf("validate: %s is not a number", value.Type()))
+	}
+}
@@ -0,0 +1,69 @@
+package validate_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/validate"
+)
+
+type item struct {
+	Name     string  `json:"name" validate:"required"`
+	Quantity float64 `json:"quantity" validate:"min=0,max=100"`
+	Unit     string  `json:"unit" validate:"oneof=gram piece"`
+}
+
+type request struct {
+	Email    string  `json:"email" validate:"required,email"`
+	Password string  `json:"password" validate:"required,min=6"`
+	Repeated string  `json:"repeated" validate:"eqfield=Password"`
+	Title    *string `json:"title" validate:"required"`
+	Items    []item  `json:"items" validate:"max=3"`
+}
+
+func TestStruct(t *testing.T) {
+	empty := ""
+	err := validate.Struct(request{
+		Email:    "cook@example",
+		Password: "borsch",
+		Repeated: "borscht",
+		Title:    &empty,
+		Items: []item{
+			{Name: "beet", Quantity: 2, Unit: "piece"},
+			{Quantity: -1, Unit: "cup"},
+		},
+	})
+	if !apperr.ErrValidation.Has(err) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+
+	var fields validate.Errors
+	if !errors.As(err, &fields) {
+		t.Fatalf("expected field errors, got %v", err)
+	}
+
+	expected := validate.Errors{
+		{Field: "repeated", Message: "must match password"},
+		{Field: "title", Message: "is required"},
+		{Field: "items[1].name", Message: "is required"},
+		{Field: "items[1].quantity", Message: "must be at least 0"},
+		{Field: "items[1].unit", Message: "must be one of: gram, piece"},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("unexpected field errors\n got: %+v\nwant: %+v", fields, expected)
+	}
+}
+
+func TestStructValid(t *testing.T) {
+	err := validate.Struct(&request{
+		Email:    "cook@example.com",
+		Password: "borsch",
+		Repeated: "borsch",
+		Items:    []item{{Name: "beet", Quantity: 2, Unit: "piece"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ensures that memoryDB implements DB.
+var _ DB = (*memoryDB)(nil)
+
+// memoryDB keeps buckets in memory of a single instance.
+//
+// architecture: Database
+type memoryDB struct {
+	mu      sync.Mutex
+	buckets map[string]Bucket
+}
+
+// NewMemoryDB returns DB which keeps buckets in memory, limits are not shared between instances.
+func NewMemoryDB() DB {
+	return &memoryDB{buckets: make(map[string]Bucket)}
+}
+
+// Take takes a token from the bucket stored by key.
+func (db *memoryDB) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var existing *Bucket
+	if bucket, ok := db.buckets[key]; ok {
+		existing = &bucket
+	}
+
+	bucket, result := policy.Take(existing, now)
+	db.buckets[key] = bucket
+
+	return result, nil
+}
+
+// DeleteStale removes buckets which were not updated since given time.
+func (db *memoryDB) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var deleted int64
+	for key, bucket := range db.buckets {
+		if bucket.UpdatedAt.Before(before) {
+			delete(db.buckets, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/pkg/logger"
+)
+
+// ErrRateLimit indicates that there was an error in the service.
+var ErrRateLimit = errs.Class("rate limit service error")
+
+// cleanupInterval defines how often buckets which are full again are removed.
+const cleanupInterval = 5 * time.Minute
+
+// Policy is a token bucket which allows Burst requests at once and refills at Burst per Period.
+type Policy struct {
+	Name   string
+	Burst  int
+	Period time.Duration
+}
+
+// ParsePolicy parses policy in "requests/period" format, e.g. "20/1m". Empty value or "0" disables the policy,
+// which is returned as nil.
+func ParsePolicy(name, value string) (*Policy, error) {
+	if value == "" || value == "0" {
+		return nil, nil
+	}
+
+	burst, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return nil, ErrRateLimit.New("policy %s: %q is not in requests/period format, e.g. 20/1m", name, value)
+	}
+
+	policy := &Policy{Name: name}
+
+	var err error
+	if policy.Burst, err = strconv.Atoi(burst); err != nil || policy.Burst < 1 {
+		return nil, ErrRateLimit.New("policy %s: number of requests must be a positive integer, got %q", name, burst)
+	}
+	if policy.Period, err = time.ParseDuration(period); err != nil || policy.Period <= 0 {
+		return nil, ErrRateLimit.New("policy %s: period must be a positive duration, got %q", name, period)
+	}
+
+	return policy, nil
+}
+
+// String formats policy the way ParsePolicy parses it.
+func (policy Policy) String() string {
+	return strconv.Itoa(policy.Burst) + "/" + policy.Period.String()
+}
+
+// Rate returns number of tokens added to the bucket per second.
+func (policy Policy) Rate() float64 {
+	return float64(policy.Burst) / policy.Period.Seconds()
+}
+
+// Bucket is a state of policy's bucket of a client.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Take refills bucket for the time passed since its update and takes a token from it when there is one,
+// nil bucket is a new one. It returns updated bucket and result of the request.
+func (policy Policy) Take(bucket *Bucket, now time.Time) (Bucket, Result) {
+	if bucket == nil {
+		bucket = &Bucket{Tokens: float64(policy.Burst), UpdatedAt: now}
+	}
+
+	updated := Bucket{Tokens: bucket.Tokens, UpdatedAt: bucket.UpdatedAt}
+	if elapsed := now.Sub(bucket.UpdatedAt); elapsed > 0 {
+		updated.Tokens = math.Min(float64(policy.Burst), updated.Tokens+elapsed.Seconds()*policy.Rate())
+		updated.UpdatedAt = now
+	}
+
+	allowed := updated.Tokens >= 1
+	if allowed {
+		updated.Tokens--
+	}
+
+	return updated, policy.Result(allowed, updated.Tokens)
+}
+
+// Result describes decision about a request and state of the bucket after it.
+type Result struct {
+	Allowed bool
+	// Limit is the maximum number of requests in a burst.
+	Limit int
+	// Remaining is a number of requests allowed right now.
+	Remaining int
+	// RetryAfter is time until the next request is allowed, zero when it's allowed already.
+	RetryAfter time.Duration
+	// ResetAfter is time until the bucket is full again.
+	ResetAfter time.Duration
+}
+
+// Result returns result of the request which left given number of tokens in the bucket.
+func (policy Policy) Result(allowed bool, tokens float64) Result {
+	result := Result{
+		Allowed:    allowed,
+		Limit:      policy.Burst,
+		Remaining:  int(math.Max(0, math.Floor(tokens))),
+		ResetAfter: secondsDuration((float64(policy.Burst) - tokens) / policy.Rate()),
+	}
+	if tokens < 1 {
+		result.RetryAfter = secondsDuration((1 - tokens) / policy.Rate())
+	}
+
+	return result
+}
+
+// secondsDuration converts non-negative number of seconds to duration.
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(math.Max(0, seconds) * float64(time.Second))
+}
+
+// DB stores buckets of clients, it's shared by all instances unless it's an in-memory one.
+//
+// architecture: DB
+type DB interface {
+	// Take takes a token from the bucket stored by key atomically, see Policy.Take.
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+	// DeleteStale removes buckets which were not updated since given time and returns their number.
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Service limits rate of requests of clients.
+//
+// architecture: Service
+type Service struct {
+	log     logger.Logger
+	buckets DB
+	// maxPeriod is the longest period of policies, buckets not updated for it are full and can be removed.
+	maxPeriod time.Duration
+}
+
+// NewService is a constructor for rate limit service, policies are used to know when buckets can be removed.
+func NewService(log logger.Logger, buckets DB, policies ...*Policy) *Service {
+	service := &Service{log: log, buckets: buckets}
+	for _, policy := range policies {
+		if policy != nil && policy.Period > service.maxPeriod {
+			service.maxPeriod = policy.Period
+		}
+	}
+
+	return service
+}
+
+// Allow takes a token of the client identified by key from the policy's bucket.
+func (service *Service) Allow(ctx context.Context, policy Policy, key string) (Result, error) {
+	result, err := service.buckets.Take(ctx, policy.Name+":"+key, policy, time.Now())
+	return result, ErrRateLimit.Wrap(err)
+}
+
+// Run periodically removes buckets which are full again until context is canceled.
+func (service *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if _, err := service.buckets.DeleteStale(ctx, time.Now().Add(-service.maxPeriod)); err != nil {
+			service.log.Error("could not remove stale rate limit buckets", err)
+		}
+	}
+}
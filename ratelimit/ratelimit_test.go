@@ -0,0 +1,67 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"kitchen_nerd/ratelimit"
+)
+
+func TestParsePolicy(t *testing.T) {
+	for value, expected := range map[string]*ratelimit.Policy{
+		"":       nil,
+		"0":      nil,
+		"20/1m":  {Name: "auth", Burst: 20, Period: time.Minute},
+		"5/1.5s": {Name: "auth", Burst: 5, Period: 1500 * time.Millisecond},
+	} {
+		policy, err := ratelimit.ParsePolicy("auth", value)
+		if err != nil {
+			t.Fatalf("%q: %v", value, err)
+		}
+		if (policy == nil) != (expected == nil) || policy != nil && *policy != *expected {
+			t.Fatalf("%q: expected %v, got %v", value, expected, policy)
+		}
+	}
+
+	for _, value := range []string{"20", "20/", "/1m", "-1/1m", "20/0s", "20/minute", "1.5/1m"} {
+		if _, err := ratelimit.ParsePolicy("auth", value); !ratelimit.ErrRateLimit.Has(err) {
+			t.Fatalf("%q: expected rate limit error, got %v", value, err)
+		}
+	}
+}
+
+func TestPolicyTake(t *testing.T) {
+	// two tokens are added every second.
+	policy := ratelimit.Policy{Name: "test", Burst: 4, Period: 2 * time.Second}
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	var bucket *ratelimit.Bucket
+	for i, step := range []struct {
+		at       time.Duration
+		tokens   float64
+		expected ratelimit.Result
+	}{
+		// new bucket is full, burst is taken at once.
+		{at: 0, tokens: 3, expected: ratelimit.Result{Allowed: true, Remaining: 3, ResetAfter: 500 * time.Millisecond}},
+		{at: 0, tokens: 2, expected: ratelimit.Result{Allowed: true, Remaining: 2, ResetAfter: time.Second}},
+		{at: 0, tokens: 1, expected: ratelimit.Result{Allowed: true, Remaining: 1, ResetAfter: 1500 * time.Millisecond}},
+		{at: 0, tokens: 0, expected: ratelimit.Result{Allowed: true, Remaining: 0, RetryAfter: 500 * time.Millisecond, ResetAfter: 2 * time.Second}},
+		{at: 0, tokens: 0, expected: ratelimit.Result{Allowed: false, Remaining: 0, RetryAfter: 500 * time.Millisecond, ResetAfter: 2 * time.Second}},
+		// half a token is not enough.
+		{at: 250 * time.Millisecond, tokens: 0.5, expected: ratelimit.Result{Allowed: false, Remaining: 0, RetryAfter: 250 * time.Millisecond, ResetAfter: 1750 * time.Millisecond}},
+		{at: 500 * time.Millisecond, tokens: 0, expected: ratelimit.Result{Allowed: true, Remaining: 0, RetryAfter: 500 * time.Millisecond, ResetAfter: 2 * time.Second}},
+		// clock going back refills nothing.
+		{at: -time.Second, tokens: 0, expected: ratelimit.Result{Allowed: false, Remaining: 0, RetryAfter: 500 * time.Millisecond, ResetAfter: 2 * time.Second}},
+		// bucket never holds more than the burst.
+		{at: time.Hour, tokens: 3, expected: ratelimit.Result{Allowed: true, Remaining: 3, ResetAfter: 500 * time.Millisecond}},
+		{at: time.Hour + 250*time.Millisecond, tokens: 2.5, expected: ratelimit.Result{Allowed: true, Remaining: 2, ResetAfter: 750 * time.Millisecond}},
+	} {
+		updated, result := policy.Take(bucket, now.Add(step.at))
+		step.expected.Limit = policy.Burst
+		if updated.Tokens != step.tokens || result != step.expected {
+			t.Fatalf("step %d: expected %v tokens and %+v, got %v tokens and %+v", i, step.tokens, step.expected, updated.Tokens, result)
+		}
+
+		bucket = &updated
+	}
+}
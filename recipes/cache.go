@@ -0,0 +1,295 @@
+package recipes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+
+	"kitchen_nerd/pkg/cache"
+	"kitchen_nerd/pkg/util"
+)
+
+// ensures that CachedDB implements DB.
+var _ DB = (*CachedDB)(nil)
+
+// CacheConfig defines configuration of recipes cache.
+type CacheConfig struct {
+	// Size is the maximum number of cached recipes and list pages each.
+	Size int `json:"size"`
+	// TTL is how long cached values are served.
+	TTL time.Duration `json:"ttl"`
+	// ListPages is the number of first list pages which are cached.
+	ListPages uint64 `json:"listPages"`
+}
+
+// CacheStats contains numbers of cache hits and misses.
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// listPage is a cached page of recipes list.
+type listPage struct {
+	recipes []*Recipe
+	cursors util.Cursors
+}
+
+// listPageKey identifies a cached page of unfiltered list.
+type listPageKey struct {
+	page uint64
+	size uint64
+}
+
+// CachedDB is a read-through cache of recipes and first pages of unfiltered recipes list.
+// Cached values are invalidated by writes made through it.
+//
+// architecture: Database
+type CachedDB struct {
+	db     DB
+	config CacheConfig
+
+	recipes *cache.LRU[uuid.UUID, Recipe]
+	pages   *cache.LRU[listPageKey, listPage]
+	count   *cache.LRU[struct{}, uint64]
+	group   singleflight.Group
+
+	// generation changes on every invalidation, so values loaded before it are not cached.
+	generation atomic.Uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewCachedDB is a constructor for recipes cache wrapped around db.
+func NewCachedDB(db DB, config CacheConfig) *CachedDB {
+	return &CachedDB{
+		db:      db,
+		config:  config,
+		recipes: cache.NewLRU[uuid.UUID, Recipe](config.Size, config.TTL),
+		pages:   cache.NewLRU[listPageKey, listPage](config.Size, config.TTL),
+		count:   cache.NewLRU[struct{}, uint64](1, config.TTL),
+	}
+}
+
+// Stats returns numbers of cache hits and misses.
+func (cached *CachedDB) Stats() CacheStats {
+	return CacheStats{Hits: cached.hits.Load(), Misses: cached.misses.Load()}
+}
+
+// cacheTxKey is a context key of writes made in current transaction.
+type cacheTxKey struct{}
+
+// cacheTx collects recipes written in a transaction, they are invalidated again when it ends,
+// because concurrent reads could have cached committed values meanwhile.
+type cacheTx struct {
+	mu      sync.Mutex
+	written []uuid.UUID
+}
+
+// WithTx runs fn in a transaction. Reads in the transaction bypass the cache,
+// so uncommitted values never get to it.
+func (cached *CachedDB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(cacheTxKey{}).(*cacheTx); ok {
+		return cached.db.WithTx(ctx, fn)
+	}
+
+	tx := new(cacheTx)
+	defer func() {
+		tx.mu.Lock()
+		defer tx.mu.Unlock()
+
+		cached.invalidate(tx.written...)
+	}()
+
+	return cached.db.WithTx(context.WithValue(ctx, cacheTxKey{}, tx), fn)
+}
+
+// GetRecipe returns a recipe by its ID.
+func (cached *CachedDB) GetRecipe(ctx context.Context, id uuid.UUID) (*Recipe, error) {
+	if inTx(ctx) {
+		return cached.db.GetRecipe(ctx, id)
+	}
+
+	if recipe, ok := cached.recipes.Get(id); ok {
+		cached.hits.Add(1)
+		clone := cloneRecipe(recipe)
+		return &clone, nil
+	}
+	cached.misses.Add(1)
+
+	value, err := cached.load(ctx, "recipe:"+id.String(), func(ctx context.Context) (interface{}, func(), error) {
+		recipe, err := cached.db.GetRecipe(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return *recipe, func() { cached.recipes.Set(id, cloneRecipe(*recipe)) }, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// loaded value is shared by concurrent callers.
+	recipe := cloneRecipe(value.(Recipe))
+	return &recipe, nil
+}
+
+// List returns a page of recipes. Only first pages of unfiltered list selected by offset are cached.
+func (cached *CachedDB) List(ctx context.Context, pagination *util.PaginationReq, query *util.ListQuery) ([]*Recipe, util.Cursors, error) {
+	cacheable := !inTx(ctx) && pagination.Cursor == nil && pagination.Page <= cached.config.ListPages &&
+		(query == nil || (len(query.Filters) == 0 && len(query.Sort) == 0))
+	if !cacheable {
+		return cached.db.List(ctx, pagination, query)
+	}
+
+	key := listPageKey{page: pagination.Page, size: pagination.Size}
+	if page, ok := cached.pages.Get(key); ok {
+		cached.hits.Add(1)
+		return cloneRecipes(page.recipes), page.cursors, nil
+	}
+	cached.misses.Add(1)
+
+	value, err := cached.load(ctx, fmt.Sprintf("list:%d:%d", key.page, key.size), func(ctx context.Context) (interface{}, func(), error) {
+		list, cursors, err := cached.db.List(ctx, pagination, query)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		page := listPage{recipes: cloneRecipes(list), cursors: cursors}
+		return page, func() { cached.pages.Set(key, page) }, nil
+	})
+	if err != nil {
+		return nil, util.Cursors{}, err
+	}
+
+	page := value.(listPage)
+	return cloneRecipes(page.recipes), page.cursors, nil
+}
+
+// Count returns number of recipes matching the query. Only number of all recipes is cached.
+func (cached *CachedDB) Count(ctx context.Context, query *util.ListQuery) (uint64, error) {
+	if inTx(ctx) || (query != nil && len(query.Filters) > 0) {
+		return cached.db.Count(ctx, query)
+	}
+
+	if count, ok := cached.count.Get(struct{}{}); ok {
+		cached.hits.Add(1)
+		return count, nil
+	}
+	cached.misses.Add(1)
+
+	value, err := cached.load(ctx, "count", func(ctx context.Context) (interface{}, func(), error) {
+		count, err := cached.db.Count(ctx, query)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return count, func() { cached.count.Set(struct{}{}, count) }, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return value.(uint64), nil
+}
+
+// CreateRecipe adds a new recipe.
+func (cached *CachedDB) CreateRecipe(ctx context.Context, recipe *Recipe) error {
+	defer cached.written(ctx, recipe.ID)
+	return cached.db.CreateRecipe(ctx, recipe)
+}
+
+// UpdateRecipe updates a recipe.
+func (cached *CachedDB) UpdateRecipe(ctx context.Context, updatedRecipe *Recipe) error {
+	defer cached.written(ctx, updatedRecipe.ID)
+	return cached.db.UpdateRecipe(ctx, updatedRecipe)
+}
+
+// DeleteRecipe deletes a recipe.
+func (cached *CachedDB) DeleteRecipe(ctx context.Context, id uuid.UUID) error {
+	defer cached.written(ctx, id)
+	return cached.db.DeleteRecipe(ctx, id)
+}
+
+// CreateIngredient adds an ingredient of a recipe.
+func (cached *CachedDB) CreateIngredient(ctx context.Context, ingredient RecipeIngredient) error {
+	defer cached.written(ctx, ingredient.RecipeID)
+	return cached.db.CreateIngredient(ctx, ingredient)
+}
+
+// DeleteIngredients deletes all ingredients of a recipe.
+func (cached *CachedDB) DeleteIngredients(ctx context.Context, recipeID uuid.UUID) error {
+	defer cached.written(ctx, recipeID)
+	return cached.db.DeleteIngredients(ctx, recipeID)
+}
+
+// load loads value once for all concurrent callers with the same key. Value is cached by the returned
+// store func only if nothing was invalidated while it was loading.
+func (cached *CachedDB) load(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, func(), error)) (interface{}, error) {
+	value, err, _ := cached.group.Do(key, func() (interface{}, error) {
+		generation := cached.generation.Load()
+
+		value, store, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if cached.generation.Load() == generation {
+			store()
+		}
+
+		return value, nil
+	})
+
+	return value, err
+}
+
+// written invalidates cached values affected by a write of the recipe.
+func (cached *CachedDB) written(ctx context.Context, id uuid.UUID) {
+	if tx, ok := ctx.Value(cacheTxKey{}).(*cacheTx); ok {
+		tx.mu.Lock()
+		tx.written = append(tx.written, id)
+		tx.mu.Unlock()
+	}
+
+	cached.invalidate(id)
+}
+
+// invalidate removes recipes and all list pages from the cache.
+func (cached *CachedDB) invalidate(ids ...uuid.UUID) {
+	cached.generation.Add(1)
+
+	for _, id := range ids {
+		cached.recipes.Delete(id)
+	}
+	cached.pages.Purge()
+	cached.count.Purge()
+}
+
+// inTx checks if context belongs to a transaction started by CachedDB.
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(cacheTxKey{}).(*cacheTx)
+	return ok
+}
+
+// cloneRecipes copies recipes, so callers can't modify cached values.
+func cloneRecipes(list []*Recipe) []*Recipe {
+	clones := make([]*Recipe, 0, len(list))
+	for _, recipe := range list {
+		clone := cloneRecipe(*recipe)
+		clones = append(clones, &clone)
+	}
+
+	return clones
+}
+
+// cloneRecipe copies recipe along with its ingredients, so callers can't modify cached value.
+func cloneRecipe(recipe Recipe) Recipe {
+	recipe.Ingredients = append([]RecipeIngredient(nil), recipe.Ingredients...)
+	return recipe
+}
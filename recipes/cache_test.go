@@ -0,0 +1,92 @@
+package recipes_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/database/dbtest"
+	"kitchen_nerd/database/memory"
+	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/recipes"
+)
+
+var cacheConfig = recipes.CacheConfig{Size: 100, TTL: time.Minute, ListPages: 3}
+
+func TestCachedDBConformance(t *testing.T) {
+	dbtest.Recipes(t, func(t *testing.T) recipes.DB {
+		return recipes.NewCachedDB(memory.New().Recipes(), cacheConfig)
+	})
+}
+
+func TestCachedDBInvalidation(t *testing.T) {
+	ctx := context.Background()
+	cached := recipes.NewCachedDB(memory.New().Recipes(), cacheConfig)
+
+	recipe := recipes.NewRecipe("Borsch", "", "", "")
+	if err := cached.CreateRecipe(ctx, recipe); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := cached.GetRecipe(ctx, recipe.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := cached.List(ctx, util.NewPaginationReq(10, 1), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := cached.Stats(); stats.Hits != 4 || stats.Misses != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	err := cached.WithTx(ctx, func(ctx context.Context) error {
+		return cached.UpdateRecipe(ctx, &recipes.Recipe{ID: recipe.ID, Title: "Green borsch"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := cached.GetRecipe(ctx, recipe.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, _, err := cached.List(ctx, util.NewPaginationReq(10, 1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Green borsch" || len(list) != 1 || list[0].Title != "Green borsch" {
+		t.Fatalf("cache was not invalidated: %+v %+v", got, list)
+	}
+}
+
+func TestCachedDBCopies(t *testing.T) {
+	ctx := context.Background()
+	cached := recipes.NewCachedDB(memory.New().Recipes(), cacheConfig)
+
+	recipe := recipes.NewRecipe("Borsch", "", "", "")
+	if err := cached.CreateRecipe(ctx, recipe); err != nil {
+		t.Fatal(err)
+	}
+	ingredient := recipes.RecipeIngredient{ID: uuid.New(), Name: "beet", RecipeID: recipe.ID, Quantity: 2, Unit: "piece"}
+	if err := cached.CreateIngredient(ctx, ingredient); err != nil {
+		t.Fatal(err)
+	}
+
+	// first call stores loaded recipe, second one returns the stored value.
+	for i := 0; i < 2; i++ {
+		got, err := cached.GetRecipe(ctx, recipe.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Ingredients) != 1 || got.Ingredients[0].Name != "beet" {
+			t.Fatalf("cached recipe was modified by caller: %+v", got.Ingredients)
+		}
+		got.Ingredients[0].Name = "carrot"
+	}
+	if stats := cached.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
@@ -3,21 +3,36 @@ package recipes
 import (
 	"context"
 	"github.com/google/uuid"
-	"github.com/zeebo/errs"
+	"kitchen_nerd/pkg/apperr"
 	"kitchen_nerd/pkg/util"
+	"kitchen_nerd/pkg/validate"
+	"reflect"
 	"time"
 )
 
-var ErrNoRecipe = errs.Class("recipe does not exist")
+var ErrNoRecipe = apperr.NewClass(apperr.KindNotFound, "recipe_not_found", "recipe does not exist")
+
+// ListFields is a whitelist of fields recipes can be filtered and sorted by.
+var ListFields = util.ListFields{
+	"title":       {Type: util.FieldString, Sortable: true},
+	"description": {Type: util.FieldString},
+	"createdAt":   {Type: util.FieldTime, Sortable: true},
+}
 
 type DB interface {
+	// WithTx runs fn in a transaction, repository calls made with the context passed to fn take part in it.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 	CreateRecipe(ctx context.Context, recipe *Recipe) error
-	List(ctx context.Context, pagination *util.PaginationReq) ([]*Recipe, error)
-	Count(ctx context.Context) (uint64, error)
+	// List returns a page of recipes matching the query, newest first unless query sorts them,
+	// and cursors of neighbouring pages.
+	List(ctx context.Context, pagination *util.PaginationReq, query *util.ListQuery) ([]*Recipe, util.Cursors, error)
+	// Count returns number of recipes matching the query.
+	Count(ctx context.Context, query *util.ListQuery) (uint64, error)
 	GetRecipe(ctx context.Context, id uuid.UUID) (*Recipe, error)
 	UpdateRecipe(ctx context.Context, updatedRecipe *Recipe) error
 	DeleteRecipe(ctx context.Context, id uuid.UUID) error
 	CreateIngredient(ctx context.Context, ingredient RecipeIngredient) error
+	DeleteIngredients(ctx context.Context, recipeID uuid.UUID) error
 }
 
 type Recipe struct {
@@ -42,6 +57,39 @@ func NewRecipe(title, photo, description, instructions string) *Recipe {
 	}
 }
 
+// Patch describes changes of recipe fields, nil fields are left as is.
+type Patch struct {
+	Title        *string `json:"title" validate:"required,max=255"`
+	PhotoBase64  *string `json:"photoBase64" validate:"max=7000000"`
+	Description  *string `json:"description"`
+	Instructions *string `json:"instructions"`
+	// Ingredients replace all ingredients of the recipe when set.
+	Ingredients *[]RecipeIngredient `json:"ingredients"`
+}
+
+// apply sets changed fields of the recipe.
+func (patch Patch) apply(recipe *Recipe) {
+	if patch.Title != nil {
+		recipe.Title = *patch.Title
+	}
+	if patch.PhotoBase64 != nil {
+		recipe.PhotoBase64 = *patch.PhotoBase64
+	}
+	if patch.Description != nil {
+		recipe.Description = *patch.Description
+	}
+	if patch.Instructions != nil {
+		recipe.Instructions = *patch.Instructions
+	}
+}
+
+// auditState returns recipe state to store in the audit log. Photo is omitted since it may be huge.
+func (recipe *Recipe) auditState() *Recipe {
+	state := *recipe
+	state.PhotoBase64 = ""
+	return &state
+}
+
 // UnitType represents the unit of measurement.
 type UnitType string
 
@@ -54,6 +102,16 @@ const (
 	Piece      UnitType = "piece" // Non-standard unit of measurement
 )
 
+func init() {
+	// "unit" rule of request validation accepts known units of measurement only.
+	validate.Register("unit", func(value reflect.Value, _ string) string {
+		if !IsValidUnit(value.String()) {
+			return "must be one of: gram, milliliter, teaspoon, tablespoon, piece"
+		}
+		return ""
+	})
+}
+
 // IsValidUnit checks if the value of string belongs to UnitType.
 func IsValidUnit(unit string) bool {
 	switch UnitType(unit) {
@@ -74,9 +132,9 @@ type IngredientForRecipe struct {
 
 type RecipeIngredient struct {
 	ID       uuid.UUID `json:"id"`
-	Name     string    `json:"name"`
+	Name     string    `json:"name" validate:"required,max=255"`
 	RecipeID uuid.UUID `json:"recipeID"`
-	Quantity float64   `json:"quantity"`
+	Quantity float64   `json:"quantity" validate:"min=0"`
 	Optional bool      `json:"optional"`
-	Unit     string    `json:"unit"`
+	Unit     string    `json:"unit" validate:"unit"`
 }
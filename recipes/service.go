@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"github.com/zeebo/errs"
+	"kitchen_nerd/audit"
 	"kitchen_nerd/pkg/util"
 )
 
@@ -14,10 +15,11 @@ var ErrRecipes = errs.Class("recipes service error")
 // architecture: Service
 type Service struct {
 	recipes DB
+	audit   *audit.Service
 }
 
-func NewService(recipes DB) *Service {
-	return &Service{recipes: recipes}
+func NewService(recipes DB, audit *audit.Service) *Service {
+	return &Service{recipes: recipes, audit: audit}
 }
 
 func (service *Service) CreateIngredients(ctx context.Context, recipeID uuid.UUID, ingredients []RecipeIngredient) error {
@@ -33,32 +35,49 @@ func (service *Service) CreateIngredients(ctx context.Context, recipeID uuid.UUI
 
 func (service *Service) Create(ctx context.Context, recipe *Recipe, ingredients []RecipeIngredient) error {
 	recipe.Ingredients = ingredients
-	err := service.recipes.CreateRecipe(ctx, recipe)
+	err := service.recipes.WithTx(ctx, func(ctx context.Context) error {
+		if err := service.recipes.CreateRecipe(ctx, recipe); err != nil {
+			return err
+		}
+
+		return service.CreateIngredients(ctx, recipe.ID, recipe.Ingredients)
+	})
 	if err != nil {
 		return err
 	}
 
-	err = service.CreateIngredients(ctx, recipe.ID, recipe.Ingredients)
+	service.audit.Record(ctx, audit.ActionRecipeCreate, audit.TargetRecipe, recipe.ID.String(), nil, recipe.auditState())
 
-	return err
+	return nil
 }
 
-func (service *Service) List(ctx context.Context, pagination *util.PaginationReq) ([]*Recipe, uint64, error) {
-	count, err := service.recipes.Count(ctx)
+// List returns a page of recipes matching the query and pagination details, including cursors of neighbouring pages.
+func (service *Service) List(ctx context.Context, pagination *util.PaginationReq, query *util.ListQuery) ([]*Recipe, *util.PaginationResponse, error) {
+	if pagination.IsKeyset() && query.IsSorted() {
+		return nil, nil, util.ErrInvalidListQuery.New("cursor pagination can't be combined with custom sorting")
+	}
+
+	count, err := service.recipes.Count(ctx, query)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
+	response := util.NewPaginationResponse(pagination.Size, pagination.Page, count)
 	if count == 0 {
-		return make([]*Recipe, 0), 0, nil
+		return make([]*Recipe, 0), response, nil
 	}
 
-	list, err := service.recipes.List(ctx, pagination)
+	list, cursors, err := service.recipes.List(ctx, pagination, query)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
-	return list, count, err
+	return list, response.WithCursors(cursors), err
+}
+
+// Count returns total number of recipes.
+func (service *Service) Count(ctx context.Context) (uint64, error) {
+	return service.recipes.Count(ctx, &util.ListQuery{})
 }
 
 func (service *Service) Get(ctx context.Context, id uuid.UUID) (*Recipe, error) {
@@ -67,24 +86,71 @@ func (service *Service) Get(ctx context.Context, id uuid.UUID) (*Recipe, error)
 }
 
 func (service *Service) Delete(ctx context.Context, id uuid.UUID) error {
-	err := service.recipes.DeleteRecipe(ctx, id)
-	return err
+	var before *Recipe
+	err := service.recipes.WithTx(ctx, func(ctx context.Context) (err error) {
+		before, err = service.recipes.GetRecipe(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = service.recipes.DeleteIngredients(ctx, id); err != nil {
+			return err
+		}
+
+		return service.recipes.DeleteRecipe(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	service.audit.Record(ctx, audit.ActionRecipeDelete, audit.TargetRecipe, id.String(), before.auditState(), nil)
+
+	return nil
 }
 
 func (service *Service) Update(ctx context.Context, id uuid.UUID, title, photo, description, instructions string) error {
+	_, err := service.Patch(ctx, id, Patch{
+		Title:        &title,
+		PhotoBase64:  &photo,
+		Description:  &description,
+		Instructions: &instructions,
+	})
 
-	updatedRecipe := &Recipe{
-		ID:           id,
-		Title:        title,
-		PhotoBase64:  photo,
-		Description:  description,
-		Instructions: instructions,
-	}
+	return err
+}
 
-	err := service.recipes.UpdateRecipe(ctx, updatedRecipe)
-	if err != nil {
+// Patch changes given fields of the recipe and returns updated recipe.
+func (service *Service) Patch(ctx context.Context, id uuid.UUID, patch Patch) (*Recipe, error) {
+	var before, after *Recipe
+	err := service.recipes.WithTx(ctx, func(ctx context.Context) (err error) {
+		before, err = service.recipes.GetRecipe(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		updatedRecipe := *before
+		patch.apply(&updatedRecipe)
+		if err = service.recipes.UpdateRecipe(ctx, &updatedRecipe); err != nil {
+			return err
+		}
+
+		if patch.Ingredients != nil {
+			if err = service.recipes.DeleteIngredients(ctx, id); err != nil {
+				return err
+			}
+			if err = service.CreateIngredients(ctx, id, *patch.Ingredients); err != nil {
+				return err
+			}
+		}
+
+		after, err = service.recipes.GetRecipe(ctx, id)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	service.audit.Record(ctx, audit.ActionRecipeUpdate, audit.TargetRecipe, id.String(), before.auditState(), after.auditState())
+
+	return after, nil
 }
@@ -0,0 +1,358 @@
+package seed
+
+import (
+	"kitchen_nerd/recipes"
+)
+
+// ingredient is a shorthand for required ingredient of seed recipes.
+func ingredient(name string, quantity float64, unit recipes.UnitType) recipes.RecipeIngredient {
+	return recipes.RecipeIngredient{Name: name, Quantity: quantity, Unit: string(unit)}
+}
+
+// optional is a shorthand for optional ingredient of seed recipes.
+func optional(name string, quantity float64, unit recipes.UnitType) recipes.RecipeIngredient {
+	seed := ingredient(name, quantity, unit)
+	seed.Optional = true
+	return seed
+}
+
+// Recipes is a curated set of demo recipes using photos from web/images.
+// Keys must not change, since ids of seeded recipes are derived from them.
+var Recipes = []Recipe{
+	{
+		Key:         "borshch",
+		Title:       "Borshch",
+		Photo:       "images/food/borshch.jpg",
+		Description: "Ukrainian beetroot soup with beef, cabbage and a spoon of sour cream.",
+		Steps: []string{
+			"Cover beef with cold water, bring to a boil, skim and simmer for 1.5 hours.",
+			"Grate beetroot, fry it with tomato paste and a spoon of vinegar for 10 minutes.",
+			"Fry chopped onion and grated carrot until soft.",
+			"Add diced potatoes to the broth and cook for 10 minutes, then add shredded cabbage.",
+			"Add fried vegetables and beetroot, season with salt, pepper and bay leaf, cook for 10 minutes more.",
+			"Let it rest for 20 minutes and serve with sour cream, garlic and dill.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("beef on the bone", 500, recipes.Gram),
+			ingredient("beetroot", 2, recipes.Piece),
+			ingredient("white cabbage", 300, recipes.Gram),
+			ingredient("potatoes", 3, recipes.Piece),
+			ingredient("carrot", 1, recipes.Piece),
+			ingredient("onion", 1, recipes.Piece),
+			ingredient("tomato paste", 2, recipes.Tablespoon),
+			ingredient("vinegar", 1, recipes.Tablespoon),
+			ingredient("garlic cloves", 2, recipes.Piece),
+			optional("sour cream", 4, recipes.Tablespoon),
+			optional("dill", 10, recipes.Gram),
+		},
+	},
+	{
+		Key:         "syrniki",
+		Title:       "Syrniki",
+		Photo:       "images/food/syrniki.jpg",
+		Description: "Golden cottage cheese pancakes for breakfast.",
+		Steps: []string{
+			"Mash cottage cheese with eggs, sugar and a pinch of salt.",
+			"Add flour and knead soft dough which doesn't stick to hands.",
+			"Shape small thick patties and coat them in flour.",
+			"Fry on medium heat for 3 minutes on each side.",
+			"Serve warm with sour cream, honey or jam.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("cottage cheese", 500, recipes.Gram),
+			ingredient("eggs", 2, recipes.Piece),
+			ingredient("sugar", 2, recipes.Tablespoon),
+			ingredient("flour", 5, recipes.Tablespoon),
+			ingredient("sunflower oil", 3, recipes.Tablespoon),
+			optional("raisins", 50, recipes.Gram),
+			optional("sour cream", 4, recipes.Tablespoon),
+		},
+	},
+	{
+		Key:         "pizza",
+		Title:       "Homemade pizza",
+		Photo:       "images/food/pizza.jpg",
+		Description: "Thin crust pizza with tomato sauce, mozzarella and salami.",
+		Steps: []string{
+			"Mix flour, yeast, salt, warm water and olive oil, knead and leave for an hour.",
+			"Heat the oven to the maximum temperature.",
+			"Roll the dough out thin and spread tomato sauce over it.",
+			"Cover with mozzarella and sliced salami.",
+			"Bake for 10-12 minutes until the crust is golden.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("flour", 300, recipes.Gram),
+			ingredient("warm water", 200, recipes.Milliliter),
+			ingredient("dry yeast", 1, recipes.Teaspoon),
+			ingredient("olive oil", 2, recipes.Tablespoon),
+			ingredient("salt", 1, recipes.Teaspoon),
+			ingredient("tomato sauce", 100, recipes.Milliliter),
+			ingredient("mozzarella", 200, recipes.Gram),
+			ingredient("salami", 100, recipes.Gram),
+			optional("basil leaves", 5, recipes.Piece),
+		},
+	},
+	{
+		Key:         "pasta-carbonara",
+		Title:       "Pasta carbonara",
+		Photo:       "images/food/pasta_karbonara.jpg",
+		Description: "Roman pasta with crispy pancetta, egg yolks and parmesan.",
+		Steps: []string{
+			"Cook spaghetti in salted water until al dente.",
+			"Fry diced pancetta until crispy.",
+			"Whisk yolks with grated parmesan and plenty of black pepper.",
+			"Toss hot pasta with pancetta off the heat, then stir in the egg mixture with a little pasta water.",
+			"Serve immediately with more parmesan.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("spaghetti", 200, recipes.Gram),
+			ingredient("pancetta", 100, recipes.Gram),
+			ingredient("egg yolks", 3, recipes.Piece),
+			ingredient("parmesan", 50, recipes.Gram),
+			ingredient("black pepper", 1, recipes.Teaspoon),
+		},
+	},
+	{
+		Key:         "grechka",
+		Title:       "Buckwheat with mushrooms",
+		Photo:       "images/food/grechka.jpg",
+		Description: "Fluffy buckwheat with fried mushrooms and onion.",
+		Steps: []string{
+			"Rinse buckwheat and toast it in a dry pan for 3 minutes.",
+			"Pour boiling water, add salt, cover and cook on low heat for 15 minutes.",
+			"Fry sliced mushrooms with onion on butter.",
+			"Mix buckwheat with mushrooms and let it rest for 5 minutes.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("buckwheat", 200, recipes.Gram),
+			ingredient("water", 400, recipes.Milliliter),
+			ingredient("champignons", 250, recipes.Gram),
+			ingredient("onion", 1, recipes.Piece),
+			ingredient("butter", 30, recipes.Gram),
+			ingredient("salt", 1, recipes.Teaspoon),
+		},
+	},
+	{
+		Key:         "blinchiki",
+		Title:       "Thin pancakes",
+		Photo:       "images/food/blinchiki.jpg",
+		Description: "Classic thin pancakes on milk, good both sweet and savory.",
+		Steps: []string{
+			"Whisk eggs with sugar and salt, add half of the milk.",
+			"Add flour gradually and whisk until smooth, then pour the rest of the milk.",
+			"Stir in oil and leave the batter for 15 minutes.",
+			"Fry thin pancakes on a hot greased pan for about a minute on each side.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("milk", 500, recipes.Milliliter),
+			ingredient("eggs", 2, recipes.Piece),
+			ingredient("flour", 200, recipes.Gram),
+			ingredient("sugar", 1, recipes.Tablespoon),
+			ingredient("salt", 0.5, recipes.Teaspoon),
+			ingredient("sunflower oil", 2, recipes.Tablespoon),
+		},
+	},
+	{
+		Key:         "oladushki",
+		Title:       "Kefir fritters",
+		Photo:       "images/food/oladushki.jpg",
+		Description: "Fluffy small pancakes on kefir.",
+		Steps: []string{
+			"Mix warm kefir with egg, sugar and salt.",
+			"Add flour and baking soda, stir into thick batter.",
+			"Spoon the batter onto a hot oiled pan and fry until golden on both sides.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("kefir", 250, recipes.Milliliter),
+			ingredient("egg", 1, recipes.Piece),
+			ingredient("flour", 200, recipes.Gram),
+			ingredient("sugar", 2, recipes.Tablespoon),
+			ingredient("baking soda", 0.5, recipes.Teaspoon),
+			ingredient("sunflower oil", 3, recipes.Tablespoon),
+		},
+	},
+	{
+		Key:         "oladushki-with-apples",
+		Title:       "Apple fritters",
+		Photo:       "images/food/oladushki_with_yabloki.jpeg",
+		Description: "Kefir fritters with grated apple and cinnamon.",
+		Steps: []string{
+			"Mix kefir with egg, sugar and cinnamon.",
+			"Grate apples and add them to the mixture.",
+			"Add flour and baking soda, stir well.",
+			"Fry spoonfuls of batter on a hot oiled pan until golden.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("kefir", 250, recipes.Milliliter),
+			ingredient("apples", 2, recipes.Piece),
+			ingredient("egg", 1, recipes.Piece),
+			ingredient("flour", 200, recipes.Gram),
+			ingredient("sugar", 2, recipes.Tablespoon),
+			ingredient("cinnamon", 1, recipes.Teaspoon),
+			ingredient("baking soda", 0.5, recipes.Teaspoon),
+		},
+	},
+	{
+		Key:         "pampushky",
+		Title:       "Doughnuts",
+		Photo:       "images/food/pon4iki.jpg",
+		Description: "Soft yeast doughnuts dusted with powdered sugar.",
+		Steps: []string{
+			"Dissolve yeast and sugar in warm milk, add eggs, melted butter and flour, knead soft dough.",
+			"Leave the dough to rise for an hour.",
+			"Roll it out and cut rings, let them rise for 20 more minutes.",
+			"Deep fry in hot oil until golden and dust with powdered sugar.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("flour", 400, recipes.Gram),
+			ingredient("warm milk", 200, recipes.Milliliter),
+			ingredient("dry yeast", 2, recipes.Teaspoon),
+			ingredient("eggs", 2, recipes.Piece),
+			ingredient("butter", 50, recipes.Gram),
+			ingredient("sugar", 3, recipes.Tablespoon),
+			ingredient("sunflower oil for frying", 500, recipes.Milliliter),
+			optional("powdered sugar", 2, recipes.Tablespoon),
+		},
+	},
+	{
+		Key:         "spaghetti-bolognese",
+		Title:       "Spaghetti bolognese",
+		Photo:       "images/food/spaghetti.jpg",
+		Description: "Spaghetti with slowly cooked meat and tomato sauce.",
+		Steps: []string{
+			"Fry finely chopped onion, carrot and celery in olive oil.",
+			"Add minced meat and fry until browned.",
+			"Add tomatoes, season and simmer for 40 minutes.",
+			"Cook spaghetti until al dente and serve with the sauce and parmesan.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("spaghetti", 300, recipes.Gram),
+			ingredient("minced beef", 400, recipes.Gram),
+			ingredient("canned tomatoes", 400, recipes.Gram),
+			ingredient("onion", 1, recipes.Piece),
+			ingredient("carrot", 1, recipes.Piece),
+			ingredient("celery stalk", 1, recipes.Piece),
+			ingredient("olive oil", 2, recipes.Tablespoon),
+			optional("parmesan", 30, recipes.Gram),
+		},
+	},
+	{
+		Key:         "hamburger",
+		Title:       "Hamburger",
+		Photo:       "images/food/hamburger.jpg",
+		Description: "Juicy beef burger with cheese and fresh vegetables.",
+		Steps: []string{
+			"Season minced beef with salt and pepper and shape two patties.",
+			"Grill the patties for 3-4 minutes on each side, put cheese on top at the end.",
+			"Toast the buns.",
+			"Assemble burgers with lettuce, tomato, onion and sauce.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("minced beef", 300, recipes.Gram),
+			ingredient("burger buns", 2, recipes.Piece),
+			ingredient("cheddar slices", 2, recipes.Piece),
+			ingredient("tomato", 1, recipes.Piece),
+			ingredient("lettuce leaves", 2, recipes.Piece),
+			optional("red onion", 1, recipes.Piece),
+			optional("ketchup", 2, recipes.Tablespoon),
+		},
+	},
+	{
+		Key:         "fruit-salad",
+		Title:       "Fruit salad",
+		Photo:       "images/food/fruit_salat.jpg",
+		Description: "Fresh fruit salad with yogurt dressing.",
+		Steps: []string{
+			"Peel and dice all the fruits.",
+			"Mix yogurt with honey.",
+			"Combine fruits with the dressing right before serving.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("apple", 1, recipes.Piece),
+			ingredient("banana", 1, recipes.Piece),
+			ingredient("orange", 1, recipes.Piece),
+			ingredient("kiwi", 2, recipes.Piece),
+			ingredient("greek yogurt", 150, recipes.Gram),
+			optional("honey", 1, recipes.Tablespoon),
+		},
+	},
+	{
+		Key:         "salo",
+		Title:       "Salted salo",
+		Photo:       "images/food/salo-salo-salo.jpg",
+		Description: "Dry salted pork fat with garlic, a classic Ukrainian appetizer.",
+		Steps: []string{
+			"Rub salo generously with salt, crushed garlic and black pepper.",
+			"Wrap it tightly and keep in the fridge for 3 days.",
+			"Shake off the salt, slice thinly and serve with rye bread.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("pork fat", 500, recipes.Gram),
+			ingredient("coarse salt", 4, recipes.Tablespoon),
+			ingredient("garlic cloves", 4, recipes.Piece),
+			ingredient("black pepper", 1, recipes.Teaspoon),
+			optional("bay leaves", 2, recipes.Piece),
+		},
+	},
+	{
+		Key:         "rice-with-vegetables",
+		Title:       "Rice with vegetables",
+		Photo:       "images/food/рис.jpg",
+		Description: "Simple side dish of rice with carrot, peas and corn.",
+		Steps: []string{
+			"Rinse rice until water runs clear.",
+			"Fry grated carrot in oil, add rice and stir for a minute.",
+			"Pour water, add salt, cover and cook for 15 minutes.",
+			"Stir in peas and corn and let it rest for 5 minutes.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("long grain rice", 200, recipes.Gram),
+			ingredient("water", 400, recipes.Milliliter),
+			ingredient("carrot", 1, recipes.Piece),
+			ingredient("green peas", 100, recipes.Gram),
+			ingredient("corn", 100, recipes.Gram),
+			ingredient("sunflower oil", 2, recipes.Tablespoon),
+		},
+	},
+	{
+		Key:         "pyrizhky",
+		Title:       "Pyrizhky with meat",
+		Photo:       "images/var/pirojki_s_farshem-56035.jpg",
+		Description: "Fried yeast buns stuffed with minced meat and onion.",
+		Steps: []string{
+			"Knead yeast dough with milk, egg, sugar and flour, leave it to rise for an hour.",
+			"Fry minced meat with onion, season with salt and pepper and let it cool.",
+			"Divide dough into small balls, flatten them, put filling inside and seal the edges.",
+			"Fry on medium heat until golden on both sides.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("flour", 500, recipes.Gram),
+			ingredient("warm milk", 250, recipes.Milliliter),
+			ingredient("dry yeast", 2, recipes.Teaspoon),
+			ingredient("egg", 1, recipes.Piece),
+			ingredient("sugar", 1, recipes.Tablespoon),
+			ingredient("minced pork", 400, recipes.Gram),
+			ingredient("onion", 2, recipes.Piece),
+			ingredient("sunflower oil for frying", 200, recipes.Milliliter),
+		},
+	},
+	{
+		Key:         "broken-glass-cake",
+		Title:       "Broken glass cake",
+		Photo:       "images/food/bitoe_steklo.jpg",
+		Description: "No-bake dessert of colorful jelly cubes in sour cream jelly.",
+		Steps: []string{
+			"Prepare jelly of a few colors and let each set in the fridge.",
+			"Cut set jelly into cubes.",
+			"Dissolve gelatin, mix it with sour cream and sugar.",
+			"Put jelly cubes into a mold, pour the sour cream mixture and leave in the fridge overnight.",
+		},
+		Ingredients: []recipes.RecipeIngredient{
+			ingredient("fruit jelly packs", 3, recipes.Piece),
+			ingredient("sour cream", 500, recipes.Gram),
+			ingredient("sugar", 150, recipes.Gram),
+			ingredient("gelatin", 20, recipes.Gram),
+			ingredient("water", 100, recipes.Milliliter),
+		},
+	},
+}
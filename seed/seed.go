@@ -0,0 +1,139 @@
+package seed
+
+import (
+	"context"
+	"encoding/base64"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/recipes"
+)
+
+// ErrSeed indicates that there was an error in the service.
+var ErrSeed = errs.Class("seed service error")
+
+// namespace derives stable ids of seed recipes from their keys, so seeding can be repeated safely.
+var namespace = uuid.MustParse("6f1d3c0e-6b2a-4c39-9a55-2f4b8f7e0d11")
+
+// Recipe is a demo recipe, its photo is a path relative to the static directory.
+type Recipe struct {
+	Key         string
+	Title       string
+	Photo       string
+	Description string
+	Steps       []string
+	Ingredients []recipes.RecipeIngredient
+}
+
+// ID returns stable id of the recipe.
+func (recipe Recipe) ID() uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte("recipe/"+recipe.Key))
+}
+
+// Result contains numbers of recipes affected by seeding.
+type Result struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+	Deleted int `json:"deleted"`
+}
+
+// Service loads demo recipes with photos bundled in the static directory.
+//
+// architecture: Service
+type Service struct {
+	recipes   *recipes.Service
+	staticDir string
+	list      []Recipe
+}
+
+// NewService is a constructor for seed service, list is usually Recipes.
+func NewService(recipesService *recipes.Service, staticDir string, list []Recipe) *Service {
+	return &Service{
+		recipes:   recipesService,
+		staticDir: staticDir,
+		list:      list,
+	}
+}
+
+// Seed creates recipes which don't exist yet, existing ones are left as is, including their changes.
+func (service *Service) Seed(ctx context.Context) (result Result, err error) {
+	for _, seed := range service.list {
+		_, err = service.recipes.Get(ctx, seed.ID())
+		if err == nil {
+			result.Skipped++
+			continue
+		}
+		if !recipes.ErrNoRecipe.Has(err) {
+			return result, ErrSeed.Wrap(err)
+		}
+
+		photo, err := service.photo(seed.Photo)
+		if err != nil {
+			return result, err
+		}
+
+		recipe := recipes.NewRecipe(seed.Title, photo, seed.Description, instructions(seed.Steps))
+		recipe.ID = seed.ID()
+
+		if err = service.recipes.Create(ctx, recipe, seed.Ingredients); err != nil {
+			return result, ErrSeed.Wrap(err)
+		}
+		result.Created++
+	}
+
+	return result, nil
+}
+
+// Reset deletes seeded recipes and creates them again, recipes created by users are not affected.
+func (service *Service) Reset(ctx context.Context) (result Result, err error) {
+	for _, seed := range service.list {
+		err = service.recipes.Delete(ctx, seed.ID())
+		switch {
+		case err == nil:
+			result.Deleted++
+		case !recipes.ErrNoRecipe.Has(err):
+			return result, ErrSeed.Wrap(err)
+		}
+	}
+
+	created, err := service.Seed(ctx)
+	result.Created, result.Skipped = created.Created, created.Skipped
+
+	return result, err
+}
+
+// photo reads the image as a data url, the same way web ui uploads photos.
+func (service *Service) photo(path string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(service.staticDir, filepath.FromSlash(path)))
+	if err != nil {
+		return "", ErrSeed.Wrap(err)
+	}
+
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if contentType == "" {
+		return "", ErrSeed.New("unknown image type of %s", path)
+	}
+
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// instructions joins steps into numbered lines.
+func instructions(steps []string) string {
+	var builder strings.Builder
+	for i, step := range steps {
+		if i > 0 {
+			builder.WriteByte('\n')
+		}
+		builder.WriteString(strconv.Itoa(i + 1))
+		builder.WriteString(". ")
+		builder.WriteString(step)
+	}
+
+	return builder.String()
+}
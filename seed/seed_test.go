@@ -0,0 +1,84 @@
+package seed_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"kitchen_nerd/audit"
+	"kitchen_nerd/database/memory"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/recipes"
+	"kitchen_nerd/seed"
+)
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New()
+	recipesService := recipes.NewService(db.Recipes(), audit.NewService(logger.NewNop(), db.Audit()))
+	service := seed.NewService(recipesService, "../web", seed.Recipes)
+
+	result, err := service.Seed(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (seed.Result{Created: len(seed.Recipes)}) {
+		t.Fatalf("unexpected result of first seed %+v", result)
+	}
+
+	result, err = service.Seed(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (seed.Result{Skipped: len(seed.Recipes)}) {
+		t.Fatalf("unexpected result of repeated seed %+v", result)
+	}
+
+	borshch := seed.Recipes[0]
+	recipe, err := recipesService.Get(ctx, borshch.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipe.Title != borshch.Title || !strings.HasPrefix(recipe.PhotoBase64, "data:image/jpeg;base64,") ||
+		!strings.HasPrefix(recipe.Instructions, "1. ") {
+		t.Fatalf("unexpected seeded recipe %q %q", recipe.Title, recipe.Instructions)
+	}
+
+	// recipes of users must survive reset.
+	own := recipes.NewRecipe("Own recipe", "", "", "")
+	if err = recipesService.Create(ctx, own, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = service.Reset(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (seed.Result{Created: len(seed.Recipes), Deleted: len(seed.Recipes)}) {
+		t.Fatalf("unexpected result of reset %+v", result)
+	}
+
+	count, err := recipesService.Count(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != uint64(len(seed.Recipes))+1 {
+		t.Fatalf("expected %d recipes, got %d", len(seed.Recipes)+1, count)
+	}
+}
+
+func TestRecipesAreValid(t *testing.T) {
+	keys := make(map[string]bool)
+	for _, recipe := range seed.Recipes {
+		if keys[recipe.Key] {
+			t.Fatalf("duplicate key %q", recipe.Key)
+		}
+		keys[recipe.Key] = true
+
+		for _, ingredient := range recipe.Ingredients {
+			if !recipes.IsValidUnit(ingredient.Unit) {
+				t.Fatalf("recipe %q: invalid unit %q of %q", recipe.Key, ingredient.Unit, ingredient.Name)
+			}
+		}
+	}
+}
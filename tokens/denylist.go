@@ -0,0 +1,78 @@
+package tokens
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revocation describes emergency revocation of signed access tokens: either a single token
+// by its id, or all tokens of the user issued before the revocation.
+type Revocation struct {
+	ID        uuid.UUID `json:"id"`
+	TokenID   string    `json:"tokenID"`
+	UserID    uuid.UUID `json:"userID"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// denylist is an in-memory copy of active revocations, so signed tokens are checked without db access.
+type denylist struct {
+	mu     sync.RWMutex
+	tokens map[string]struct{}
+	users  map[uuid.UUID]time.Time
+}
+
+// newDenylist is a constructor for denylist.
+func newDenylist() *denylist {
+	return &denylist{
+		tokens: make(map[string]struct{}),
+		users:  make(map[uuid.UUID]time.Time),
+	}
+}
+
+// set replaces denylist content with revocations.
+func (list *denylist) set(revocations []Revocation) {
+	tokens := make(map[string]struct{}, len(revocations))
+	users := make(map[uuid.UUID]time.Time)
+	for _, revocation := range revocations {
+		addRevocation(tokens, users, revocation)
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	list.tokens, list.users = tokens, users
+}
+
+// add adds revocation to denylist.
+func (list *denylist) add(revocation Revocation) {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	addRevocation(list.tokens, list.users, revocation)
+}
+
+// isRevoked checks if token with claims is revoked. Times are compared with microseconds precision,
+// tokens issued in the same microsecond as user's revocation are revoked as well.
+func (list *denylist) isRevoked(claims Claims, userID uuid.UUID) bool {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+
+	if _, ok := list.tokens[claims.ID]; ok {
+		return true
+	}
+
+	revokedAt, ok := list.users[userID]
+	return ok && !fromNumericDate(claims.IssuedAt).After(revokedAt)
+}
+
+func addRevocation(tokens map[string]struct{}, users map[uuid.UUID]time.Time, revocation Revocation) {
+	if revocation.TokenID != "" {
+		tokens[revocation.TokenID] = struct{}{}
+		return
+	}
+
+	if revokedAt, ok := users[revocation.UserID]; !ok || revocation.CreatedAt.After(revokedAt) {
+		users[revocation.UserID] = revocation.CreatedAt
+	}
+}
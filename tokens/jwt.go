@@ -0,0 +1,230 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"kitchen_nerd/pkg/apperr"
+)
+
+// ErrInvalidToken indicates that signed token is malformed, expired or has invalid signature.
+var ErrInvalidToken = apperr.NewClass(apperr.KindUnauthorized, "invalid_token", "token is invalid")
+
+// Algorithm defines signing algorithm of access tokens.
+type Algorithm string
+
+const (
+	// AlgorithmHS256 is HMAC with SHA-256, uses shared secret.
+	AlgorithmHS256 Algorithm = "HS256"
+	// AlgorithmEdDSA is Ed25519 signature, key is defined by 32 bytes seed.
+	AlgorithmEdDSA Algorithm = "EdDSA"
+)
+
+// Claims describes payload of signed access token.
+type Claims struct {
+	ID      string `json:"jti"`
+	Subject string `json:"sub"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	// IssuedAt has microseconds fraction, so tokens issued right after user's revocation
+	// are told apart from revoked ones issued earlier in the same second.
+	IssuedAt  float64 `json:"iat"`
+	ExpiresAt int64   `json:"exp"`
+}
+
+// header describes JOSE header of signed access token.
+type header struct {
+	Algorithm Algorithm `json:"alg"`
+	Type      string    `json:"typ"`
+	KeyID     string    `json:"kid"`
+}
+
+// SigningKey is a key used to sign and verify access tokens.
+type SigningKey struct {
+	ID        string
+	Algorithm Algorithm
+
+	secret     []byte
+	privateKey ed25519.PrivateKey
+}
+
+// NewSigningKey is a constructor for SigningKey. For HS256 material is a secret,
+// for EdDSA it is a 32 bytes seed of private key.
+func NewSigningKey(id string, algorithm Algorithm, material []byte) (*SigningKey, error) {
+	key := &SigningKey{ID: id, Algorithm: algorithm}
+
+	switch algorithm {
+	case AlgorithmHS256:
+		if len(material) < 32 {
+			return nil, ErrTokens.New("HS256 key %q must be at least 32 bytes long", id)
+		}
+		key.secret = material
+	case AlgorithmEdDSA:
+		if len(material) != ed25519.SeedSize {
+			return nil, ErrTokens.New("EdDSA key %q must be %d bytes seed", id, ed25519.SeedSize)
+		}
+		key.privateKey = ed25519.NewKeyFromSeed(material)
+	default:
+		return nil, ErrTokens.New("unsupported algorithm %q of key %q", algorithm, id)
+	}
+
+	return key, nil
+}
+
+func (key *SigningKey) sign(message []byte) []byte {
+	if key.Algorithm == AlgorithmEdDSA {
+		return ed25519.Sign(key.privateKey, message)
+	}
+
+	mac := hmac.New(sha256.New, key.secret)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+func (key *SigningKey) verify(message, signature []byte) bool {
+	if key.Algorithm == AlgorithmEdDSA {
+		return ed25519.Verify(key.privateKey.Public().(ed25519.PublicKey), message, signature)
+	}
+
+	return hmac.Equal(key.sign(message), signature)
+}
+
+// KeySet holds signing keys by their ids. New tokens are signed with the current key,
+// while all keys of the set are accepted for verification, which allows to rotate keys.
+type KeySet struct {
+	current string
+	keys    map[string]*SigningKey
+}
+
+// ParseKeySet parses key set from comma separated list of "kid:algorithm:base64 key material" entries.
+func ParseKeySet(spec, currentID string) (*KeySet, error) {
+	keySet := &KeySet{
+		current: currentID,
+		keys:    make(map[string]*SigningKey),
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, ErrTokens.New("invalid key entry %q, expected kid:algorithm:key", entry)
+		}
+
+		material, err := base64.StdEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, ErrTokens.New("key %q is not valid base64: %v", parts[0], err)
+		}
+
+		key, err := NewSigningKey(parts[0], Algorithm(parts[1]), material)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := keySet.keys[key.ID]; ok {
+			return nil, ErrTokens.New("duplicated key id %q", key.ID)
+		}
+		keySet.keys[key.ID] = key
+	}
+
+	if _, ok := keySet.keys[currentID]; !ok {
+		return nil, ErrTokens.New("signing key %q is not in the key set", currentID)
+	}
+
+	return keySet, nil
+}
+
+// Sign encodes and signs claims with the current key.
+func (keySet *KeySet) Sign(claims Claims) (string, error) {
+	key := keySet.keys[keySet.current]
+
+	headerJSON, err := json.Marshal(header{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", ErrTokens.Wrap(err)
+	}
+
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", ErrTokens.Wrap(err)
+	}
+
+	message := encodeSegment(headerJSON) + "." + encodeSegment(claimsJSON)
+	return message + "." + encodeSegment(key.sign([]byte(message))), nil
+}
+
+// Verify checks token signature and expiration and returns its claims.
+func (keySet *KeySet) Verify(token string, now time.Time) (Claims, error) {
+	var claims Claims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrInvalidToken.New("malformed token")
+	}
+
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return claims, ErrInvalidToken.Wrap(err)
+	}
+
+	var h header
+	if err = json.Unmarshal(headerJSON, &h); err != nil {
+		return claims, ErrInvalidToken.Wrap(err)
+	}
+
+	key, ok := keySet.keys[h.KeyID]
+	if !ok {
+		return claims, ErrInvalidToken.New("unknown key id %q", h.KeyID)
+	}
+	if key.Algorithm != h.Algorithm {
+		return claims, ErrInvalidToken.New("algorithm %q does not match key %q", h.Algorithm, h.KeyID)
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return claims, ErrInvalidToken.Wrap(err)
+	}
+	if !key.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return claims, ErrInvalidToken.New("invalid signature")
+	}
+
+	claimsJSON, err := decodeSegment(parts[1])
+	if err != nil {
+		return claims, ErrInvalidToken.Wrap(err)
+	}
+	if err = json.Unmarshal(claimsJSON, &claims); err != nil {
+		return claims, ErrInvalidToken.Wrap(err)
+	}
+
+	if now.Unix() >= claims.ExpiresAt {
+		return claims, ErrInvalidToken.New("token is expired")
+	}
+
+	return claims, nil
+}
+
+// numericDate converts time to seconds since epoch with microseconds fraction, which JWT allows.
+func numericDate(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1e6
+}
+
+// fromNumericDate converts seconds since epoch with microseconds fraction to time.
+func fromNumericDate(date float64) time.Time {
+	return time.UnixMicro(int64(math.Round(date * 1e6))).UTC()
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(segment)
+}
@@ -0,0 +1,159 @@
+package tokens_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/tokens"
+)
+
+// keyEntry returns key set entry of the key with material filled with given byte.
+func keyEntry(id string, algorithm tokens.Algorithm, fill byte) string {
+	material := []byte(strings.Repeat(string(rune(fill)), 32))
+	return id + ":" + string(algorithm) + ":" + base64.StdEncoding.EncodeToString(material)
+}
+
+func mustParseKeySet(t *testing.T, spec, current string) *tokens.KeySet {
+	t.Helper()
+
+	keySet, err := tokens.ParseKeySet(spec, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keySet
+}
+
+func newClaims(now time.Time) tokens.Claims {
+	return tokens.Claims{
+		ID:        uuid.NewString(),
+		Subject:   uuid.NewString(),
+		Name:      "Cook",
+		Status:    "user",
+		IssuedAt:  float64(now.UnixMicro()) / 1e6,
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}
+}
+
+// segment replaces the segment of the token with json encoded value.
+func segment(token string, index int, value string) string {
+	parts := strings.Split(token, ".")
+	parts[index] = base64.RawURLEncoding.EncodeToString([]byte(value))
+	return strings.Join(parts, ".")
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Now()
+
+	for _, algorithm := range []tokens.Algorithm{tokens.AlgorithmHS256, tokens.AlgorithmEdDSA} {
+		keySet := mustParseKeySet(t, keyEntry("k1", algorithm, 'a'), "k1")
+		claims := newClaims(now)
+
+		token, err := keySet.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		verified, err := keySet.Verify(token, now)
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if verified != claims {
+			t.Fatalf("%s: expected claims %+v, got %+v", algorithm, claims, verified)
+		}
+
+		_, err = keySet.Verify(token, now.Add(time.Hour))
+		if !tokens.ErrInvalidToken.Has(err) {
+			t.Fatalf("%s: expected expired token to be invalid, got %v", algorithm, err)
+		}
+	}
+}
+
+func TestVerifyRejectsForgedTokens(t *testing.T) {
+	now := time.Now()
+	keySet := mustParseKeySet(t, keyEntry("hs", tokens.AlgorithmHS256, 'a')+","+keyEntry("ed", tokens.AlgorithmEdDSA, 'b'), "hs")
+
+	token, err := keySet.Sign(newClaims(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := newClaims(now)
+	claims.Status = "admin"
+	admin, err := keySet.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// claims of the admin token with signature of the user token.
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + strings.Split(admin, ".")[1] + "." + parts[2]
+
+	for name, forged := range map[string]string{
+		"malformed":        "not.a-token",
+		"tampered claims":  tampered,
+		"no algorithm":     segment(token, 0, `{"alg":"none","typ":"JWT","kid":"hs"}`),
+		"wrong algorithm":  segment(token, 0, `{"alg":"EdDSA","typ":"JWT","kid":"hs"}`),
+		"unknown key":      segment(token, 0, `{"alg":"HS256","typ":"JWT","kid":"other"}`),
+		"invalid encoding": token + "!",
+	} {
+		if _, err := keySet.Verify(forged, now); !tokens.ErrInvalidToken.Has(err) {
+			t.Fatalf("%s: expected invalid token, got %v", name, err)
+		}
+	}
+
+	other := mustParseKeySet(t, keyEntry("hs", tokens.AlgorithmHS256, 'c'), "hs")
+	if _, err = other.Verify(token, now); !tokens.ErrInvalidToken.Has(err) {
+		t.Fatalf("expected token signed with other secret to be invalid, got %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Now()
+	old := mustParseKeySet(t, keyEntry("k1", tokens.AlgorithmHS256, 'a'), "k1")
+	rotated := mustParseKeySet(t, keyEntry("k1", tokens.AlgorithmHS256, 'a')+", "+keyEntry("k2", tokens.AlgorithmEdDSA, 'b'), "k2")
+
+	oldToken, err := old.Sign(newClaims(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = rotated.Verify(oldToken, now); err != nil {
+		t.Fatalf("token signed with previous key must be valid after rotation: %v", err)
+	}
+
+	newToken, err := rotated.Sign(newClaims(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := base64.RawURLEncoding.DecodeString(strings.Split(newToken, ".")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(header), `"kid":"k2"`) || !strings.Contains(string(header), `"alg":"EdDSA"`) {
+		t.Fatalf("new tokens must be signed with the current key, got header %s", header)
+	}
+	if _, err = old.Verify(newToken, now); !tokens.ErrInvalidToken.Has(err) {
+		t.Fatalf("expected token of unknown key to be invalid, got %v", err)
+	}
+}
+
+func TestParseKeySet(t *testing.T) {
+	for name, spec := range map[string]string{
+		"short secret":    "k1:HS256:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"wrong seed size": "k1:EdDSA:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 40))),
+		"unknown alg":     keyEntry("k1", "RS256", 'a'),
+		"not base64":      "k1:HS256:???",
+		"no material":     "k1:HS256",
+		"duplicated kid":  keyEntry("k1", tokens.AlgorithmHS256, 'a') + "," + keyEntry("k1", tokens.AlgorithmHS256, 'b'),
+		"no signing key":  keyEntry("k2", tokens.AlgorithmHS256, 'a'),
+		"empty key set":   "",
+		"only separators": " , ",
+	} {
+		if _, err := tokens.ParseKeySet(spec, "k1"); !tokens.ErrTokens.Has(err) {
+			t.Fatalf("%s: expected tokens error, got %v", name, err)
+		}
+	}
+}
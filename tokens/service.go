@@ -2,24 +2,77 @@ package tokens
 
 import (
 	"context"
-	"github.com/zeebo/errs"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/errs"
+
+	"kitchen_nerd/pkg/logger"
 )
 
 // ErrTokens indicates that there was an error in the service.
-var ErrTokens = errs.Class("users service error")
+var ErrTokens = errs.Class("tokens service error")
+
+// Mode defines how access tokens are issued and verified.
+type Mode string
+
+const (
+	// ModeOpaque issues random tokens stored in the database.
+	ModeOpaque Mode = "opaque"
+	// ModeJWT issues signed tokens verified without database access.
+	ModeJWT Mode = "jwt"
+)
+
+const (
+	// defaultTokenExpirationTime is used when expiration time is not configured.
+	defaultTokenExpirationTime = 5 * 24 * time.Hour
+	// defaultDenylistRefreshInterval is used when denylist refresh interval is not configured.
+	defaultDenylistRefreshInterval = time.Minute
+)
 
 // Config defines configuration for tokens.
 type Config struct {
 	TokenExpirationTime time.Duration `json:"tokenExpirationTime"`
+	Mode                Mode          `json:"mode"`
+	// DenylistRefreshInterval defines how often revocations made by other instances are loaded.
+	DenylistRefreshInterval time.Duration `json:"denylistRefreshInterval"`
+	// Keys are used to sign and verify tokens in ModeJWT.
+	Keys *KeySet `json:"-"`
 }
 
 // Service is handling tokens related logic.
 //
 // architecture: Service
 type Service struct {
-	config Config
-	tokens DB
+	log      logger.Logger
+	config   Config
+	tokens   DB
+	denylist *denylist
+}
+
+// NewService is a constructor for tokens service.
+func NewService(log logger.Logger, config Config, tokens DB) *Service {
+	if config.TokenExpirationTime == 0 {
+		config.TokenExpirationTime = defaultTokenExpirationTime
+	}
+	if config.Mode == "" {
+		config.Mode = ModeOpaque
+	}
+	if config.DenylistRefreshInterval == 0 {
+		config.DenylistRefreshInterval = defaultDenylistRefreshInterval
+	}
+
+	return &Service{
+		log:      log,
+		config:   config,
+		tokens:   tokens,
+		denylist: newDenylist(),
+	}
+}
+
+// IsStateless checks if tokens carry user information and are verified without database.
+func (service *Service) IsStateless() bool {
+	return service.config.Mode == ModeJWT
 }
 
 // GetToken returns UserToken by token.
@@ -29,10 +82,181 @@ func (service *Service) GetToken(ctx context.Context, token string) (UserToken,
 	return userToken, ErrTokens.Wrap(err)
 }
 
-// NewService is a constructor for tokens service.
-func NewService(config Config, tokens DB) *Service {
-	return &Service{
-		config: config,
-		tokens: tokens,
+// Issue creates new access token for the user.
+func (service *Service) Issue(ctx context.Context, userID uuid.UUID, username, status string) (*UserToken, error) {
+	userToken, err := NewUserToken(userID, username, service.config.TokenExpirationTime)
+	if err != nil {
+		return nil, err
+	}
+	userToken.Status = status
+
+	if service.IsStateless() {
+		userToken.Token, err = service.config.Keys.Sign(Claims{
+			ID:        userToken.ID.String(),
+			Subject:   userID.String(),
+			Name:      username,
+			Status:    status,
+			IssuedAt:  numericDate(userToken.CreatedAt),
+			ExpiresAt: userToken.ExpiredAt.Unix(),
+		})
+
+		return userToken, ErrTokens.Wrap(err)
+	}
+
+	return userToken, ErrTokens.Wrap(service.tokens.AddToken(ctx, userToken))
+}
+
+// Authenticate checks access token and returns its owner. Signed tokens are verified
+// without database access, so only claims are filled.
+func (service *Service) Authenticate(ctx context.Context, token string) (UserToken, error) {
+	if !service.IsStateless() {
+		userToken, err := service.tokens.GetToken(ctx, token)
+		if err != nil {
+			return userToken, ErrTokens.Wrap(err)
+		}
+
+		if time.Now().After(userToken.ExpiredAt) {
+			return userToken, ErrTokens.Wrap(ErrNoToken.New("token is expired"))
+		}
+
+		return userToken, nil
+	}
+
+	claims, err := service.config.Keys.Verify(token, time.Now())
+	if err != nil {
+		return UserToken{}, ErrTokens.Wrap(err)
+	}
+
+	userToken, err := claimsToUserToken(claims)
+	if err != nil {
+		return UserToken{}, ErrTokens.Wrap(err)
+	}
+	userToken.Token = token
+
+	if service.denylist.isRevoked(claims, userToken.UserID) {
+		return UserToken{}, ErrTokens.Wrap(ErrInvalidToken.New("token is revoked"))
+	}
+
+	return userToken, nil
+}
+
+// Revoke invalidates access token, signed tokens are added to the denylist until they expire.
+func (service *Service) Revoke(ctx context.Context, token string) error {
+	if !service.IsStateless() {
+		return ErrTokens.Wrap(service.tokens.DeleteToken(ctx, token))
+	}
+
+	claims, err := service.config.Keys.Verify(token, time.Now())
+	if err != nil {
+		return ErrTokens.Wrap(err)
+	}
+
+	return service.addRevocation(ctx, Revocation{
+		ID:        uuid.New(),
+		TokenID:   claims.ID,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC(),
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// RevokeUser invalidates all access tokens of the user issued so far.
+func (service *Service) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	if !service.IsStateless() {
+		err := service.tokens.DeleteTokenByUserId(ctx, userID)
+		if ErrNoToken.Has(err) {
+			return nil
+		}
+		return ErrTokens.Wrap(err)
+	}
+
+	// databases keep microseconds, so revocation time is the same after denylist refresh.
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	return service.addRevocation(ctx, Revocation{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ExpiresAt: now.Add(service.config.TokenExpirationTime),
+		CreatedAt: now,
+	})
+}
+
+// PurgeStats contains numbers of records removed by Purge.
+type PurgeStats struct {
+	Tokens      int64 `json:"tokens"`
+	Revocations int64 `json:"revocations"`
+}
+
+// Purge removes expired tokens and revocations, which are kept in the database otherwise.
+func (service *Service) Purge(ctx context.Context) (stats PurgeStats, err error) {
+	now := time.Now().UTC()
+
+	stats.Tokens, err = service.tokens.DeleteExpiredTokens(ctx, now)
+	if err != nil {
+		return stats, ErrTokens.Wrap(err)
+	}
+
+	stats.Revocations, err = service.tokens.DeleteExpiredRevocations(ctx, now)
+	return stats, ErrTokens.Wrap(err)
+}
+
+// Run periodically loads revocations made by other instances until context is canceled.
+func (service *Service) Run(ctx context.Context) error {
+	if !service.IsStateless() {
+		return nil
+	}
+
+	ticker := time.NewTicker(service.config.DenylistRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := service.refreshDenylist(ctx); err != nil {
+			service.log.Error("could not refresh tokens denylist", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
 	}
 }
+
+// refreshDenylist replaces in-memory denylist with active revocations from the database.
+func (service *Service) refreshDenylist(ctx context.Context) error {
+	revocations, err := service.tokens.ListRevocations(ctx, time.Now().UTC())
+	if err != nil {
+		return ErrTokens.Wrap(err)
+	}
+
+	service.denylist.set(revocations)
+	return nil
+}
+
+func (service *Service) addRevocation(ctx context.Context, revocation Revocation) error {
+	if err := service.tokens.AddRevocation(ctx, revocation); err != nil {
+		return ErrTokens.Wrap(err)
+	}
+
+	service.denylist.add(revocation)
+	return nil
+}
+
+func claimsToUserToken(claims Claims) (UserToken, error) {
+	id, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return UserToken{}, ErrInvalidToken.Wrap(err)
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return UserToken{}, ErrInvalidToken.Wrap(err)
+	}
+
+	return UserToken{
+		ID:        id,
+		UserID:    userID,
+		Username:  claims.Name,
+		Status:    claims.Status,
+		ExpiredAt: time.Unix(claims.ExpiresAt, 0).UTC(),
+		CreatedAt: fromNumericDate(claims.IssuedAt),
+	}, nil
+}
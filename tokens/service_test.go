@@ -0,0 +1,201 @@
+package tokens_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"kitchen_nerd/database/memory"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/tokens"
+)
+
+// newJWTService returns tokens service issuing signed tokens.
+func newJWTService(t *testing.T, db tokens.DB, refreshInterval time.Duration) *tokens.Service {
+	return tokens.NewService(logger.NewNop(), tokens.Config{
+		Mode:                    tokens.ModeJWT,
+		DenylistRefreshInterval: refreshInterval,
+		Keys:                    mustParseKeySet(t, keyEntry("k1", tokens.AlgorithmHS256, 'a'), "k1"),
+	}, db)
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	service := newJWTService(t, memory.New().Tokens(), 0)
+	userID := uuid.New()
+
+	revoked, err := service.Issue(ctx, userID, "Cook", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := service.Issue(ctx, userID, "Cook", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticated, err := service.Authenticate(ctx, revoked.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authenticated.ID != revoked.ID || authenticated.UserID != userID || authenticated.Status != "user" {
+		t.Fatalf("unexpected authenticated token %+v", authenticated)
+	}
+
+	if err = service.Revoke(ctx, revoked.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = service.Authenticate(ctx, revoked.Token); !tokens.ErrInvalidToken.Has(err) {
+		t.Fatalf("expected revoked token to be rejected, got %v", err)
+	}
+	if _, err = service.Authenticate(ctx, active.Token); err != nil {
+		t.Fatalf("expected other token to stay valid, got %v", err)
+	}
+}
+
+func TestRevokeUser(t *testing.T) {
+	ctx := context.Background()
+	service := newJWTService(t, memory.New().Tokens(), 0)
+	userID, otherID := uuid.New(), uuid.New()
+
+	before, err := service.Issue(ctx, userID, "Cook", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := service.Issue(ctx, otherID, "Other", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = service.RevokeUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	// token issued within the same second as revocation, e.g. login right after password reset.
+	after, err := service.Issue(ctx, userID, "Cook", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = service.Authenticate(ctx, before.Token); !tokens.ErrInvalidToken.Has(err) {
+		t.Fatalf("expected token issued before revocation to be rejected, got %v", err)
+	}
+	if _, err = service.Authenticate(ctx, after.Token); err != nil {
+		t.Fatalf("expected token issued after revocation to be valid, got %v", err)
+	}
+	if _, err = service.Authenticate(ctx, other.Token); err != nil {
+		t.Fatalf("expected token of other user to be valid, got %v", err)
+	}
+}
+
+func TestDenylistRefresh(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// two instances share the database, the second one learns revocations on refresh only.
+	db := memory.New().Tokens()
+	first := newJWTService(t, db, time.Hour)
+	second := newJWTService(t, db, 10*time.Millisecond)
+	userID := uuid.New()
+
+	token, err := first.Issue(ctx, userID, "Cook", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = second.Authenticate(ctx, token.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = first.RevokeUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- second.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err = second.Authenticate(ctx, token.Token)
+		if tokens.ErrInvalidToken.Has(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected revocation to be loaded from the database, got %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	after, err := first.Issue(ctx, userID, "Cook", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = second.Authenticate(ctx, after.Token); err != nil {
+		t.Fatalf("expected token issued after revocation to be valid after refresh, got %v", err)
+	}
+
+	cancel()
+	if err = <-done; err != context.Canceled {
+		t.Fatalf("expected run to stop on cancel, got %v", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New().Tokens()
+	service := tokens.NewService(logger.NewNop(), tokens.Config{TokenExpirationTime: time.Hour}, db)
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	active, err := service.Issue(ctx, userID, "Cook", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.AddToken(ctx, &tokens.UserToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Username:  "Cook",
+		Token:     "expired",
+		ExpiredAt: now.Add(-time.Minute),
+		CreatedAt: now.Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, revocation := range []tokens.Revocation{
+		{ID: uuid.New(), UserID: userID, ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)},
+		{ID: uuid.New(), TokenID: uuid.NewString(), ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+	} {
+		if err = db.AddRevocation(ctx, revocation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := service.Purge(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (tokens.PurgeStats{Tokens: 1, Revocations: 1}) {
+		t.Fatalf("unexpected purge stats %+v", stats)
+	}
+
+	if _, err = db.GetToken(ctx, "expired"); !tokens.ErrNoToken.Has(err) {
+		t.Fatalf("expected expired token to be removed, got %v", err)
+	}
+	if _, err = service.Authenticate(ctx, active.Token); err != nil {
+		t.Fatalf("expected active token to stay, got %v", err)
+	}
+	revocations, err := db.ListRevocations(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revocations) != 1 || revocations[0].ExpiresAt.Before(now) {
+		t.Fatalf("expected active revocation to stay, got %+v", revocations)
+	}
+
+	// nothing is left to purge.
+	if stats, err = service.Purge(ctx); err != nil || stats != (tokens.PurgeStats{}) {
+		t.Fatalf("unexpected second purge %+v %v", stats, err)
+	}
+}
@@ -5,13 +5,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/zeebo/errs"
 
+	"kitchen_nerd/pkg/apperr"
 	"kitchen_nerd/pkg/rand"
 )
 
 // ErrNoToken indicates that token does not exist.
-var ErrNoToken = errs.Class("token does not exist")
+var ErrNoToken = apperr.NewClass(apperr.KindUnauthorized, "token_not_found", "token does not exist")
 
 type DB interface {
 	// GetToken returns user's token from the database.
@@ -28,6 +28,14 @@ type DB interface {
 	ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]UserToken, error)
 	// DeleteSessionToken removes a token from the database by session id.
 	DeleteSessionToken(ctx context.Context, userId, sessionId uuid.UUID) error
+	// AddRevocation inserts a signed tokens revocation in the database.
+	AddRevocation(ctx context.Context, revocation Revocation) error
+	// ListRevocations returns revocations which are not expired at the moment.
+	ListRevocations(ctx context.Context, now time.Time) ([]Revocation, error)
+	// DeleteExpiredTokens removes tokens expired at the moment and returns their number.
+	DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error)
+	// DeleteExpiredRevocations removes revocations expired at the moment and returns their number.
+	DeleteExpiredRevocations(ctx context.Context, now time.Time) (int64, error)
 	//// AddAdminSession inserts an access token in tha database.
 	//AddAdminSession(ctx context.Context, session AdminSession) error
 	//// GetAdminSession returns an admin token from the database.
@@ -40,26 +48,30 @@ type UserToken struct {
 	ID        uuid.UUID `json:"id"`
 	UserID    uuid.UUID `json:"userID"`
 	Username  string    `json:"username"`
+	Status    string    `json:"status,omitempty"`
 	Token     string    `json:"token"`
 	ExpiredAt time.Time `json:"expiredAt"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+// NewUserToken creates random token of the user which expires after given time.
 func NewUserToken(
 	userID uuid.UUID,
 	username string,
+	expiration time.Duration,
 ) (*UserToken, error) {
 	token, err := rand.RandStr(36, rand.TypeToken)
 	if err != nil {
 		return nil, ErrTokens.Wrap(err)
 	}
 
+	now := time.Now()
 	return &UserToken{
 		ID:        uuid.New(),
 		UserID:    userID,
 		Username:  username,
 		Token:     token,
-		ExpiredAt: time.Now().Add(time.Minute * 60 * 24 * 5),
-		CreatedAt: time.Now(),
+		ExpiredAt: now.Add(expiration),
+		CreatedAt: now,
 	}, nil
 }
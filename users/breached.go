@@ -0,0 +1,105 @@
+package users
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zeebo/errs"
+)
+
+// ErrBreachedPasswords indicates that there was an error reading breached passwords list.
+var ErrBreachedPasswords = errs.Class("breached passwords error")
+
+// hashPrefixLength is the number of leading SHA-1 hex characters the index is partitioned by.
+const hashPrefixLength = 5
+
+// BreachedPasswords checks passwords against a local list of SHA-1 hashes of breached passwords.
+//
+// The list is either a single file with one upper case hex SHA-1 per line, or a directory of
+// k-anonymity range files, where file named by 5 characters hash prefix holds
+// the remaining 35 characters of hashes, as served by "Have I Been Pwned" range API.
+// Lines may be followed by ":count", which is ignored.
+type BreachedPasswords struct {
+	// dir is set when range files are read on demand.
+	dir string
+	// index maps hash prefix to sorted hash suffixes when the whole list is loaded in memory.
+	index map[string][]string
+}
+
+// LoadBreachedPasswords opens breached passwords list located at path.
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, ErrBreachedPasswords.Wrap(err)
+	}
+
+	if info.IsDir() {
+		return &BreachedPasswords{dir: path}, nil
+	}
+
+	index := make(map[string][]string)
+	err = readHashes(path, func(hash string) {
+		if len(hash) != sha1.Size*2 {
+			return
+		}
+		prefix := hash[:hashPrefixLength]
+		index[prefix] = append(index[prefix], hash[hashPrefixLength:])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, suffixes := range index {
+		sort.Strings(suffixes)
+	}
+
+	return &BreachedPasswords{index: index}, nil
+}
+
+// Contains checks if password is in the breached passwords list.
+func (breached *BreachedPasswords) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:hashPrefixLength], hash[hashPrefixLength:]
+
+	if breached.dir == "" {
+		suffixes := breached.index[prefix]
+		i := sort.SearchStrings(suffixes, suffix)
+		return i < len(suffixes) && suffixes[i] == suffix, nil
+	}
+
+	found := false
+	err := readHashes(filepath.Join(breached.dir, prefix), func(hash string) {
+		found = found || hash == suffix
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	return found, err
+}
+
+// readHashes calls fn for every hash in the file, with count suffix stripped.
+func readHashes(path string, fn func(hash string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return ErrBreachedPasswords.Wrap(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash != "" {
+			fn(strings.ToUpper(hash))
+		}
+	}
+
+	return ErrBreachedPasswords.Wrap(scanner.Err())
+}
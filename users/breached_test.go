@@ -0,0 +1,85 @@
+package users_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kitchen_nerd/users"
+)
+
+// sha1Hex returns upper case hex SHA-1 of the password.
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBreachedPasswordsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	writeFile(t, path, strings.Join([]string{
+		sha1Hex("password1"),
+		strings.ToLower(sha1Hex("qwerty")) + ":42",
+		"",
+		"not a hash",
+		sha1Hex("123456") + ":7",
+	}, "\n"))
+
+	breached, err := users.LoadBreachedPasswords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for password, expected := range map[string]bool{
+		"password1":   true,
+		"qwerty":      true,
+		"123456":      true,
+		"Borsch-1234": false,
+		"not a hash":  false,
+	} {
+		contains, err := breached.Contains(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contains != expected {
+			t.Fatalf("%q: expected %v, got %v", password, expected, contains)
+		}
+	}
+}
+
+func TestBreachedPasswordsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	hash := sha1Hex("password1")
+	writeFile(t, filepath.Join(dir, hash[:5]), "0000000000000000000000000000000000A:1\n"+hash[5:]+":3\n")
+
+	breached, err := users.LoadBreachedPasswords(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contains, err := breached.Contains("password1")
+	if err != nil || !contains {
+		t.Fatalf("expected password from range file to be breached, got %v %v", contains, err)
+	}
+
+	// missing range file means no breached passwords with such prefix.
+	contains, err = breached.Contains("Borsch-1234")
+	if err != nil || contains {
+		t.Fatalf("expected password to be safe, got %v %v", contains, err)
+	}
+
+	_, err = users.LoadBreachedPasswords(filepath.Join(dir, "missing"))
+	if !users.ErrBreachedPasswords.Has(err) {
+		t.Fatalf("expected breached passwords error, got %v", err)
+	}
+}
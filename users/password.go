@@ -0,0 +1,135 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zeebo/errs"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPasswordHash indicates that stored password hash is malformed.
+var ErrPasswordHash = errs.Class("password hash error")
+
+const (
+	// argon2idPrefix starts password hashes in PHC string format produced by argon2id.
+	argon2idPrefix = "$argon2id$"
+	// bcryptPrefix starts legacy bcrypt password hashes.
+	bcryptPrefix = "$2"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Argon2Params defines cost parameters of argon2id password hashing.
+type Argon2Params struct {
+	// Memory is amount of memory in KiB.
+	Memory      uint32 `json:"memory"`
+	Iterations  uint32 `json:"iterations"`
+	Parallelism uint8  `json:"parallelism"`
+}
+
+// DefaultArgon2Params follows OWASP recommendations for argon2id.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+}
+
+// PasswordHasher hashes passwords with argon2id and verifies both argon2id and legacy bcrypt hashes.
+type PasswordHasher struct {
+	params Argon2Params
+}
+
+// NewPasswordHasher is a constructor for PasswordHasher.
+func NewPasswordHasher(params Argon2Params) *PasswordHasher {
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		params = DefaultArgon2Params
+	}
+
+	return &PasswordHasher{params: params}
+}
+
+// Hash generates argon2id hash of the password in PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>.
+func (hasher *PasswordHasher) Hash(password []byte) ([]byte, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, ErrPasswordHash.Wrap(err)
+	}
+
+	params := hasher.params
+	key := argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, argon2KeyLength)
+
+	encoded := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+
+	return []byte(encoded), nil
+}
+
+// Verify checks password against the hash. It also reports whether the hash
+// should be replaced, because it was produced by another algorithm or with other parameters.
+func (hasher *PasswordHasher) Verify(hash, password []byte) (ok, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(string(hash), argon2idPrefix):
+		params, salt, key, err := decodeArgon2id(string(hash))
+		if err != nil {
+			return false, false, err
+		}
+
+		actual := argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return false, false, nil
+		}
+
+		return true, params != hasher.params, nil
+	case strings.HasPrefix(string(hash), bcryptPrefix):
+		err := bcrypt.CompareHashAndPassword(hash, password)
+		if err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, ErrPasswordHash.Wrap(err)
+		}
+
+		return true, true, nil
+	default:
+		return false, false, ErrPasswordHash.New("unknown hash format")
+	}
+}
+
+// decodeArgon2id parses argon2id hash in PHC string format.
+func decodeArgon2id(encoded string) (params Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrPasswordHash.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrPasswordHash.Wrap(err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, ErrPasswordHash.New("unsupported argon2 version %d", version)
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return params, nil, nil, ErrPasswordHash.Wrap(err)
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, ErrPasswordHash.Wrap(err)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, ErrPasswordHash.Wrap(err)
+	}
+
+	return params, salt, key, nil
+}
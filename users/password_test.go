@@ -0,0 +1,100 @@
+package users_test
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"kitchen_nerd/users"
+)
+
+// testArgon2Params keeps hashing cheap in tests.
+var testArgon2Params = users.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestHashAndVerify(t *testing.T) {
+	hasher := users.NewPasswordHasher(testArgon2Params)
+
+	hash, err := hasher.Hash([]byte("Borsch-1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(hash), "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected hash format %q", hash)
+	}
+
+	other, err := hasher.Hash([]byte("Borsch-1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(other) == string(hash) {
+		t.Fatal("expected hashes of the same password to have different salts")
+	}
+
+	ok, needsRehash, err := hasher.Verify(hash, []byte("Borsch-1234"))
+	if err != nil || !ok || needsRehash {
+		t.Fatalf("expected valid password without rehash, got %v %v %v", ok, needsRehash, err)
+	}
+
+	ok, needsRehash, err = hasher.Verify(hash, []byte("Borsch-1235"))
+	if err != nil || ok || needsRehash {
+		t.Fatalf("expected wrong password to be rejected, got %v %v %v", ok, needsRehash, err)
+	}
+
+	// hash produced with other parameters is valid, but should be upgraded.
+	stronger := users.NewPasswordHasher(users.Argon2Params{Memory: 2048, Iterations: 1, Parallelism: 1})
+	ok, needsRehash, err = stronger.Verify(hash, []byte("Borsch-1234"))
+	if err != nil || !ok || !needsRehash {
+		t.Fatalf("expected valid password with rehash, got %v %v %v", ok, needsRehash, err)
+	}
+}
+
+func TestVerifyLegacyBcrypt(t *testing.T) {
+	hasher := users.NewPasswordHasher(testArgon2Params)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("Borsch-1234"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, needsRehash, err := hasher.Verify(hash, []byte("Borsch-1234"))
+	if err != nil || !ok || !needsRehash {
+		t.Fatalf("expected valid bcrypt password with rehash, got %v %v %v", ok, needsRehash, err)
+	}
+
+	ok, needsRehash, err = hasher.Verify(hash, []byte("Borsch-1235"))
+	if err != nil || ok || needsRehash {
+		t.Fatalf("expected wrong bcrypt password to be rejected, got %v %v %v", ok, needsRehash, err)
+	}
+
+	_, _, err = hasher.Verify([]byte("$2a$04$short"), []byte("Borsch-1234"))
+	if !users.ErrPasswordHash.Has(err) {
+		t.Fatalf("expected password hash error for malformed bcrypt hash, got %v", err)
+	}
+}
+
+func TestVerifyMalformedHash(t *testing.T) {
+	hasher := users.NewPasswordHasher(testArgon2Params)
+
+	hash, err := hasher.Hash([]byte("Borsch-1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(string(hash), "$")
+
+	for name, malformed := range map[string]string{
+		"unknown algorithm": "$scrypt$" + strings.Join(parts[2:], "$"),
+		"plain text":        "Borsch-1234",
+		"missing part":      strings.Join(parts[:5], "$"),
+		"unknown version":   strings.Replace(string(hash), "v=19", "v=16", 1),
+		"bad version":       strings.Replace(string(hash), "v=19", "v=x", 1),
+		"bad parameters":    strings.Replace(string(hash), "m=1024,t=1,p=1", "m=1024;t=1", 1),
+		"bad salt":          strings.Join(append(parts[:4:4], "!!!", parts[5]), "$"),
+		"bad key":           strings.Join(append(parts[:5:5], "!!!"), "$"),
+	} {
+		_, _, err := hasher.Verify([]byte(malformed), []byte("Borsch-1234"))
+		if !users.ErrPasswordHash.Has(err) {
+			t.Fatalf("%s: expected password hash error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package users
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy defines rules every new password must follow.
+type PasswordPolicy struct {
+	MinLength      int  `json:"minLength"`
+	MaxLength      int  `json:"maxLength"`
+	RequireLower   bool `json:"requireLower"`
+	RequireUpper   bool `json:"requireUpper"`
+	RequireDigit   bool `json:"requireDigit"`
+	RequireSpecial bool `json:"requireSpecial"`
+	// DisallowPersonalInfo rejects passwords containing user's name or email local part.
+	DisallowPersonalInfo bool `json:"disallowPersonalInfo"`
+}
+
+// DefaultPasswordPolicy is used when password policy is not configured.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:            8,
+	MaxLength:            128,
+	RequireLower:         true,
+	RequireUpper:         true,
+	RequireDigit:         true,
+	DisallowPersonalInfo: true,
+}
+
+// minPersonalInfoLength is the shortest name or email part that is checked for in a password.
+const minPersonalInfoLength = 3
+
+// Validate checks the password against the policy and returns ErrInvalidPassword describing all violated rules.
+func (policy PasswordPolicy) Validate(password, email, name string) error {
+	var lower, upper, digit, special bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsNumber(c):
+			digit = true
+		case !unicode.IsLetter(c) && !unicode.IsSpace(c):
+			special = true
+		}
+	}
+
+	var violations []string
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		violations = append(violations, "be at least "+strconv.Itoa(policy.MinLength)+" characters long")
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		violations = append(violations, "be at most "+strconv.Itoa(policy.MaxLength)+" characters long")
+	}
+	if policy.RequireLower && !lower {
+		violations = append(violations, "contain a lowercase letter")
+	}
+	if policy.RequireUpper && !upper {
+		violations = append(violations, "contain an uppercase letter")
+	}
+	if policy.RequireDigit && !digit {
+		violations = append(violations, "contain a digit")
+	}
+	if policy.RequireSpecial && !special {
+		violations = append(violations, "contain a special character")
+	}
+	if policy.DisallowPersonalInfo && containsPersonalInfo(password, email, name) {
+		violations = append(violations, "not contain your name or email")
+	}
+
+	if len(violations) > 0 {
+		return ErrInvalidPassword.New("the password must %s", strings.Join(violations, ", "))
+	}
+
+	return nil
+}
+
+// containsPersonalInfo checks if password contains name, any part of it, or email local part.
+func containsPersonalInfo(password, email, name string) bool {
+	password = strings.ToLower(password)
+
+	candidates := strings.Fields(strings.ToLower(name))
+	if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok {
+		candidates = append(candidates, local)
+	}
+
+	for _, candidate := range candidates {
+		if utf8.RuneCountInString(candidate) >= minPersonalInfoLength && strings.Contains(password, candidate) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package users_test
+
+import (
+	"strings"
+	"testing"
+
+	"kitchen_nerd/users"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := users.DefaultPasswordPolicy
+	policy.MaxLength = 16
+
+	for _, test := range []struct {
+		password  string
+		violation string
+	}{
+		{"Borsch-1234", ""},
+		{"Борщ-1234", ""},
+		{"Bo-12", "be at least 8 characters long"},
+		{"Borsch-1234567890", "be at most 16 characters long"},
+		{"BORSCH-1234", "contain a lowercase letter"},
+		{"borsch-1234", "contain an uppercase letter"},
+		{"Borsch-borsch", "contain a digit"},
+		{"Cook-1234", "not contain your name or email"},
+		{"Chef-1234", "not contain your name or email"},
+		{"XEmily-1234", "not contain your name or email"},
+	} {
+		err := policy.Validate(test.password, "chef@example.com", "Emily Cook")
+		if test.violation == "" {
+			if err != nil {
+				t.Fatalf("%q: expected valid password, got %v", test.password, err)
+			}
+			continue
+		}
+
+		if !users.ErrInvalidPassword.Has(err) || !strings.Contains(err.Error(), test.violation) {
+			t.Fatalf("%q: expected violation %q, got %v", test.password, test.violation, err)
+		}
+	}
+}
+
+func TestPasswordPolicyOptionalRules(t *testing.T) {
+	// minimal length is counted in characters, not bytes.
+	policy := users.PasswordPolicy{MinLength: 4, RequireSpecial: true}
+	if err := policy.Validate("ääää", "", ""); !users.ErrInvalidPassword.Has(err) || !strings.Contains(err.Error(), "contain a special character") {
+		t.Fatalf("expected only special character violation, got %v", err)
+	}
+	if err := policy.Validate("äää!", "", ""); err != nil {
+		t.Fatalf("expected valid password, got %v", err)
+	}
+
+	// every violated rule is reported at once, personal info shorter than 3 characters is allowed.
+	err := users.DefaultPasswordPolicy.Validate("al", "al@example.com", "Al")
+	for _, violation := range []string{"at least 8", "uppercase", "digit"} {
+		if !strings.Contains(err.Error(), violation) {
+			t.Fatalf("expected violation %q in %v", violation, err)
+		}
+	}
+	if strings.Contains(err.Error(), "name or email") {
+		t.Fatalf("expected short name to be allowed, got %v", err)
+	}
+}
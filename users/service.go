@@ -2,9 +2,13 @@ package users
 
 import (
 	"context"
-	"golang.org/x/crypto/bcrypt"
+	"kitchen_nerd/audit"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/pkg/util"
 	"kitchen_nerd/tokens"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,44 +20,83 @@ var (
 	ErrUsers = errs.Class("users service error")
 
 	// ErrInvalidPassword should be returned when users password is invalid.
-	ErrInvalidPassword = errs.Class("password is invalid")
+	ErrInvalidPassword = apperr.NewClass(apperr.KindValidation, "invalid_password", "password is invalid")
 
 	// ErrWrongCredentials indicates that user entered wrong credentials.
-	ErrWrongCredentials = errs.New("wrong credentials")
+	ErrWrongCredentials = apperr.Sentinel(apperr.KindUnauthorized, "wrong_credentials", "wrong credentials")
 
 	// ErrEmailAddressAlreadyInUse indicates that user with current email already exists.
-	ErrEmailAddressAlreadyInUse = errs.New("user with such email address already exists")
+	ErrEmailAddressAlreadyInUse = apperr.Sentinel(apperr.KindConflict, "email_in_use", "user with such email address already exists")
+
+	// ErrUserDisabled indicates that user was disabled by an admin.
+	ErrUserDisabled = apperr.Sentinel(apperr.KindForbidden, "user_disabled", "user is disabled")
 )
 
+// Config defines configuration for users.
+type Config struct {
+	Argon2         Argon2Params   `json:"argon2"`
+	PasswordPolicy PasswordPolicy `json:"passwordPolicy"`
+	// Breached is an optional list of breached passwords new passwords are checked against.
+	Breached *BreachedPasswords `json:"-"`
+}
+
 // Service is handling users related logic.
 //
 // architecture: Service
 type Service struct {
-	tokens tokens.DB
+	log    logger.Logger
+	config Config
+	tokens *tokens.Service
+	audit  *audit.Service
 	users  DB
+	hasher *PasswordHasher
+
+	loginsSucceeded atomic.Uint64
+	loginsFailed    atomic.Uint64
+}
+
+// LoginStats contains numbers of successful and failed logins since start.
+type LoginStats struct {
+	Succeeded uint64 `json:"succeeded"`
+	Failed    uint64 `json:"failed"`
 }
 
 // NewService is a constructor for users service.
-func NewService(users DB, tokens tokens.DB) *Service {
+func NewService(log logger.Logger, config Config, users DB, tokens *tokens.Service, audit *audit.Service) *Service {
+	if config.PasswordPolicy == (PasswordPolicy{}) {
+		config.PasswordPolicy = DefaultPasswordPolicy
+	}
+
 	return &Service{
+		log:    log,
+		config: config,
 		tokens: tokens,
+		audit:  audit,
 		users:  users,
+		hasher: NewPasswordHasher(config.Argon2),
 	}
 }
 
 // Create creates a user.
-func (service *Service) Create(ctx context.Context, name, email, password string) (err error) {
+func (service *Service) Create(ctx context.Context, name, email, password string) error {
+	_, err := service.CreateAccount(ctx, name, email, password, StatusUser)
+	return err
+}
+
+// CreateAccount creates a user with given status and returns its account.
+func (service *Service) CreateAccount(ctx context.Context, name, email, password string, status Status) (_ *Account, err error) {
+	email = strings.ToLower(email)
+
 	_, err = service.users.GetByEmail(ctx, email)
 	if err == nil {
-		return ErrEmailAddressAlreadyInUse
-	} else {
-		if err.Error() != "user does not exist: no rows in result set" {
-			return ErrUsers.Wrap(err)
-		}
+		return nil, ErrEmailAddressAlreadyInUse
+	}
+	if !ErrNoUser.Has(err) {
+		return nil, ErrUsers.Wrap(err)
 	}
 
-	if !IsPasswordValid(password) {
-		return ErrInvalidPassword.New("the password must contain at least one lowercase (a-z) letter, one uppercase (A-Z) letter, one digit (0-9) and one special character.")
+	if err = service.validatePassword(password, email, name); err != nil {
+		return nil, err
 	}
 
 	id := uuid.New()
@@ -61,18 +104,25 @@ func (service *Service) Create(ctx context.Context, name, email, password string
 	user := User{
 		ID:           id,
 		Name:         name,
-		Status:       StatusUser,
-		Email:        strings.ToLower(email),
+		Status:       status,
+		Email:        email,
 		PasswordHash: []byte(password),
 		LastLogin:    time.Now().UTC(),
 		CreatedAt:    time.Now().UTC(),
 	}
 
-	if err = user.EncodePass(); err != nil {
-		return ErrUsers.Wrap(err)
+	if err = user.EncodePass(service.hasher); err != nil {
+		return nil, ErrUsers.Wrap(err)
 	}
 
-	return ErrUsers.Wrap(service.users.Create(ctx, &user))
+	if err = service.users.Create(ctx, &user); err != nil {
+		return nil, ErrUsers.Wrap(err)
+	}
+
+	service.audit.Record(ctx, audit.ActionUserRegister, audit.TargetUser, user.ID.String(), nil, user.Profile())
+
+	account := user.Account()
+	return &account, nil
 }
 
 func (service *Service) Login(ctx context.Context, email string, password string) (*tokens.UserToken, error) {
@@ -86,44 +136,195 @@ func (service *Service) Login(ctx context.Context, email string, password string
 }
 
 func (service *Service) LoginToken(ctx context.Context, session *Session) (*tokens.UserToken, error) {
+	session = NewSession(strings.ToLower(session.Email), session.Password)
+
 	user, err := service.users.GetByEmail(ctx, session.Email)
 	if err != nil {
+		service.loginsFailed.Add(1)
+		service.audit.Record(ctx, audit.ActionUserLoginFailed, audit.TargetUser, session.Email, nil, nil)
 		return nil, ErrWrongCredentials
 	}
 
-	if err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(session.Password)); err != nil {
+	ok, needsRehash, err := service.hasher.Verify(user.PasswordHash, []byte(session.Password))
+	if err != nil {
+		return nil, ErrUsers.Wrap(err)
+	}
+	if !ok {
+		service.loginsFailed.Add(1)
+		service.audit.Record(ctx, audit.ActionUserLoginFailed, audit.TargetUser, session.Email, nil, nil)
 		return nil, ErrWrongCredentials
 	}
+	if user.Status == StatusDisabled {
+		service.loginsFailed.Add(1)
+		service.audit.Record(ctx, audit.ActionUserLoginFailed, audit.TargetUser, session.Email, nil, nil)
+		return nil, ErrUserDisabled
+	}
+
+	// upgrade hash to the current algorithm and parameters while plain password is known.
+	if needsRehash {
+		if err = service.rehashPassword(ctx, user.ID, session.Password); err != nil {
+			logger.FromContext(ctx, service.log).Error("could not rehash user's password", err)
+		}
+	}
 
 	token, err := service.AddSession(ctx, session)
+	if err != nil {
+		return nil, ErrUsers.Wrap(err)
+	}
+
+	service.loginsSucceeded.Add(1)
+	service.audit.Record(audit.WithActor(ctx, user.ID), audit.ActionUserLogin, audit.TargetUser, user.ID.String(), nil, nil)
 
-	return token, ErrUsers.Wrap(err)
+	return token, nil
+}
+
+// LoginStats returns numbers of successful and failed logins.
+func (service *Service) LoginStats() LoginStats {
+	return LoginStats{Succeeded: service.loginsSucceeded.Load(), Failed: service.loginsFailed.Load()}
+}
+
+// ResetPassword sets new password of the user with such email and ends all user's sessions.
+func (service *Service) ResetPassword(ctx context.Context, email, password string) error {
+	user, err := service.users.GetByEmail(ctx, strings.ToLower(email))
+	if err != nil {
+		return ErrUsers.Wrap(err)
+	}
+
+	if err = service.validatePassword(password, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	hash, err := service.hasher.Hash([]byte(password))
+	if err != nil {
+		return ErrUsers.Wrap(err)
+	}
+
+	err = service.users.WithTx(ctx, func(ctx context.Context) error {
+		if err := service.users.UpdatePassword(ctx, user.ID, hash); err != nil {
+			return err
+		}
+
+		return service.tokens.RevokeUser(ctx, user.ID)
+	})
+	if err != nil {
+		return ErrUsers.Wrap(err)
+	}
+
+	service.audit.Record(ctx, audit.ActionUserPasswordReset, audit.TargetUser, user.ID.String(), nil, nil)
+
+	return nil
+}
+
+// SetStatus changes status of the user with such email and ends all user's sessions,
+// so that new status takes effect immediately.
+func (service *Service) SetStatus(ctx context.Context, email string, status Status) (*Account, error) {
+	switch status {
+	case StatusAdmin, StatusUser, StatusDisabled:
+	default:
+		return nil, apperr.ErrValidation.New("unknown user status %q", status)
+	}
+
+	user, err := service.users.GetByEmail(ctx, strings.ToLower(email))
+	if err != nil {
+		return nil, ErrUsers.Wrap(err)
+	}
+
+	before := user.Profile()
+	err = service.users.WithTx(ctx, func(ctx context.Context) error {
+		if err := service.users.UpdateStatus(ctx, user.ID, status); err != nil {
+			return err
+		}
+
+		return service.tokens.RevokeUser(ctx, user.ID)
+	})
+	if err != nil {
+		return nil, ErrUsers.Wrap(err)
+	}
+	user.Status = status
+
+	service.audit.Record(ctx, audit.ActionUserStatusChange, audit.TargetUser, user.ID.String(), before, user.Profile())
+
+	account := user.Account()
+	return &account, nil
+}
+
+// GetAccount returns account of the user with such email.
+func (service *Service) GetAccount(ctx context.Context, email string) (*Account, error) {
+	user, err := service.users.GetByEmail(ctx, strings.ToLower(email))
+	if err != nil {
+		return nil, ErrUsers.Wrap(err)
+	}
+
+	account := user.Account()
+	return &account, nil
+}
+
+// validatePassword checks new password against password policy and breached passwords list.
+func (service *Service) validatePassword(password, email, name string) error {
+	if err := service.config.PasswordPolicy.Validate(password, email, name); err != nil {
+		return err
+	}
+
+	if service.config.Breached == nil {
+		return nil
+	}
+
+	breached, err := service.config.Breached.Contains(password)
+	if err != nil {
+		return ErrUsers.Wrap(err)
+	}
+	if breached {
+		return ErrInvalidPassword.New("the password has appeared in a data breach, please choose another one")
+	}
+
+	return nil
+}
+
+// rehashPassword replaces user's password hash with the one produced by current hasher.
+func (service *Service) rehashPassword(ctx context.Context, id uuid.UUID, password string) error {
+	hash, err := service.hasher.Hash([]byte(password))
+	if err != nil {
+		return ErrUsers.Wrap(err)
+	}
+
+	return ErrUsers.Wrap(service.users.UpdatePassword(ctx, id, hash))
 }
 
 // AddSession creates a session.
 func (service *Service) AddSession(ctx context.Context, session *Session) (*tokens.UserToken, error) {
 	var user *User
 	var err error
-	user, err = service.users.GetByEmail(ctx, session.Email)
+	user, err = service.users.GetByEmail(ctx, strings.ToLower(session.Email))
 	if err != nil {
 		return nil, ErrWrongCredentials
 	}
 
-	userToken, err := tokens.NewUserToken(user.ID, user.Name)
-	if err != nil {
-		return nil, err
-	}
+	var userToken *tokens.UserToken
+	err = service.users.WithTx(ctx, func(ctx context.Context) (err error) {
+		userToken, err = service.tokens.Issue(ctx, user.ID, user.Name, string(user.Status))
+		if err != nil {
+			return err
+		}
 
-	err = service.tokens.AddToken(ctx, userToken)
+		return service.users.UpdateLastLogin(ctx, user.ID)
+	})
 	if err != nil {
 		return nil, ErrUsers.Wrap(err)
 	}
 
-	if err = service.users.UpdateLastLogin(ctx, user.ID); err != nil {
-		return nil, ErrUsers.Wrap(err)
+	return userToken, nil
+}
+
+// Logout removes session by its token.
+func (service *Service) Logout(ctx context.Context, token string) error {
+	if err := service.tokens.Revoke(ctx, token); err != nil {
+		return ErrUsers.Wrap(err)
 	}
 
-	return userToken, nil
+	actorID := audit.GetRequestInfo(ctx).ActorID
+	service.audit.Record(ctx, audit.ActionUserLogout, audit.TargetUser, actorID.String(), nil, nil)
+
+	return nil
 }
 
 func (service *Service) Get(ctx context.Context, id uuid.UUID) (*User, error) {
@@ -140,3 +341,28 @@ func (service *Service) GetProfile(ctx context.Context, id uuid.UUID) (*Profile,
 	profile := NewProfile(id, user.Name, user.Status)
 	return profile, nil
 }
+
+// List returns a page of accounts of users matching the query and pagination details.
+func (service *Service) List(ctx context.Context, pagination *util.PaginationReq, query *util.ListQuery) ([]Account, *util.PaginationResponse, error) {
+	count, err := service.users.Count(ctx, query)
+	if err != nil {
+		return nil, nil, ErrUsers.Wrap(err)
+	}
+
+	accounts := make([]Account, 0)
+	response := util.NewPaginationResponse(pagination.Size, pagination.Page, count)
+	if count == 0 {
+		return accounts, response, nil
+	}
+
+	list, err := service.users.List(ctx, pagination, query)
+	if err != nil {
+		return nil, nil, ErrUsers.Wrap(err)
+	}
+
+	for _, user := range list {
+		accounts = append(accounts, user.Account())
+	}
+
+	return accounts, response, nil
+}
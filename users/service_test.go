@@ -0,0 +1,147 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"kitchen_nerd/audit"
+	"kitchen_nerd/database/memory"
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/logger"
+	"kitchen_nerd/tokens"
+	"kitchen_nerd/users"
+)
+
+// newTestService returns users service backed by in-memory database and the tokens service it issues tokens with.
+func newTestService() (*users.Service, *tokens.Service) {
+	db := memory.New()
+
+	auditService := audit.NewService(logger.NewNop(), db.Audit())
+	tokensService := tokens.NewService(logger.NewNop(), tokens.Config{TokenExpirationTime: time.Hour}, db.Tokens())
+	usersService := users.NewService(logger.NewNop(), users.Config{Argon2: testArgon2Params}, db.Users(), tokensService, auditService)
+
+	return usersService, tokensService
+}
+
+func TestEmailCase(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService()
+
+	account, err := service.CreateAccount(ctx, "Cook", "Cook@Example.com", "Borsch-1234", users.StatusUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Email != "cook@example.com" {
+		t.Fatalf("expected lowercased email, got %q", account.Email)
+	}
+
+	for _, email := range []string{"cook@example.com", "COOK@EXAMPLE.COM", "Cook@Example.com"} {
+		if _, err = service.CreateAccount(ctx, "Cook", email, "Borsch-1234", users.StatusUser); !errors.Is(err, users.ErrEmailAddressAlreadyInUse) {
+			t.Fatalf("%s: expected email in use error, got %v", email, err)
+		}
+		if _, err = service.Login(ctx, email, "Borsch-1234"); err != nil {
+			t.Fatalf("%s: expected login regardless of email case, got %v", email, err)
+		}
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	ctx := context.Background()
+	service, tokensService := newTestService()
+
+	if _, err := service.CreateAccount(ctx, "Cook", "cook@example.com", "Borsch-1234", users.StatusUser); err != nil {
+		t.Fatal(err)
+	}
+	session, err := service.Login(ctx, "cook@example.com", "Borsch-1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := service.SetStatus(ctx, "Cook@Example.com", users.StatusDisabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Status != users.StatusDisabled {
+		t.Fatalf("unexpected account %+v", account)
+	}
+
+	// disabled user can't log in and existing sessions end.
+	if _, err = tokensService.Authenticate(ctx, session.Token); err == nil {
+		t.Fatal("expected session of disabled user to be revoked")
+	}
+	if _, err = service.Login(ctx, "cook@example.com", "Borsch-1234"); !errors.Is(err, users.ErrUserDisabled) {
+		t.Fatalf("expected disabled user error, got %v", err)
+	}
+
+	if _, err = service.SetStatus(ctx, "cook@example.com", users.StatusUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = service.Login(ctx, "cook@example.com", "Borsch-1234"); err != nil {
+		t.Fatalf("expected re-enabled user to log in, got %v", err)
+	}
+
+	if _, err = service.SetStatus(ctx, "cook@example.com", "chef"); apperr.KindOf(err) != apperr.KindValidation {
+		t.Fatalf("expected unknown status to be rejected, got %v", err)
+	}
+	if _, err = service.SetStatus(ctx, "nobody@example.com", users.StatusAdmin); !users.ErrNoUser.Has(err) {
+		t.Fatalf("expected missing user error, got %v", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	service, tokensService := newTestService()
+
+	if _, err := service.CreateAccount(ctx, "Cook", "cook@example.com", "Borsch-1234", users.StatusUser); err != nil {
+		t.Fatal(err)
+	}
+	session, err := service.Login(ctx, "cook@example.com", "Borsch-1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = service.ResetPassword(ctx, "cook@example.com", "weak"); apperr.KindOf(err) != apperr.KindValidation {
+		t.Fatalf("expected weak password to be rejected, got %v", err)
+	}
+	if _, err = tokensService.Authenticate(ctx, session.Token); err != nil {
+		t.Fatalf("expected session to stay after rejected reset, got %v", err)
+	}
+
+	if err = service.ResetPassword(ctx, "Cook@Example.com", "Okroshka-5678"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = tokensService.Authenticate(ctx, session.Token); err == nil {
+		t.Fatal("expected session to be revoked after password reset")
+	}
+	if _, err = service.Login(ctx, "cook@example.com", "Borsch-1234"); !errors.Is(err, users.ErrWrongCredentials) {
+		t.Fatalf("expected old password to be rejected, got %v", err)
+	}
+	if _, err = service.Login(ctx, "cook@example.com", "Okroshka-5678"); err != nil {
+		t.Fatalf("expected new password to be accepted, got %v", err)
+	}
+}
+
+func TestGetAccount(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService()
+
+	created, err := service.CreateAccount(ctx, "Cook", "cook@example.com", "Borsch-1234", users.StatusAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := service.GetAccount(ctx, "COOK@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.ID != created.ID || account.Email != "cook@example.com" || account.Name != "Cook" || account.Status != users.StatusAdmin {
+		t.Fatalf("unexpected account %+v", account)
+	}
+
+	if _, err = service.GetAccount(ctx, "nobody@example.com"); !users.ErrNoUser.Has(err) {
+		t.Fatalf("expected missing user error, got %v", err)
+	}
+}
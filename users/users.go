@@ -4,15 +4,15 @@ import (
 	"context"
 	"html/template"
 	"time"
-	"unicode"
 
 	"github.com/google/uuid"
-	"github.com/zeebo/errs"
-	"golang.org/x/crypto/bcrypt"
+
+	"kitchen_nerd/pkg/apperr"
+	"kitchen_nerd/pkg/util"
 )
 
 // ErrNoUser indicates that user does not exist.
-var ErrNoUser = errs.Class("user does not exist")
+var ErrNoUser = apperr.NewClass(apperr.KindNotFound, "user_not_found", "user does not exist")
 
 // Status represents types of user rights.
 type Status string
@@ -22,6 +22,8 @@ const (
 	StatusAdmin Status = "admin"
 	// StatusUser default type of user with read-only possibility.
 	StatusUser Status = "user"
+	// StatusDisabled denies user to log in and to use issued api keys.
+	StatusDisabled Status = "disabled"
 )
 
 // User describes user entity.
@@ -35,6 +37,37 @@ type User struct {
 	CreatedAt    time.Time `json:"createdAt"`
 }
 
+// ListFields is a whitelist of fields users can be filtered and sorted by.
+var ListFields = util.ListFields{
+	"email":     {Type: util.FieldString, Sortable: true},
+	"name":      {Type: util.FieldString, Sortable: true},
+	"status":    {Type: util.FieldString},
+	"lastLogin": {Type: util.FieldTime, Sortable: true},
+	"createdAt": {Type: util.FieldTime, Sortable: true},
+}
+
+// Account describes user's fields available to admins.
+type Account struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	LastLogin time.Time `json:"lastLogin"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Account returns user's account.
+func (user *User) Account() Account {
+	return Account{
+		ID:        user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Status:    user.Status,
+		LastLogin: user.LastLogin,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
 // Profile describes a user's available fields to check.
 type Profile struct {
 	ID     uuid.UUID `json:"id"`
@@ -46,6 +79,11 @@ func NewProfile(ID uuid.UUID, name string, status Status) *Profile {
 	return &Profile{ID: ID, Name: name, Status: status}
 }
 
+// Profile returns user's profile.
+func (user *User) Profile() *Profile {
+	return NewProfile(user.ID, user.Name, user.Status)
+}
+
 // UserTemplates holds all users related templates.
 type UserTemplates struct {
 	List   *template.Template
@@ -54,8 +92,8 @@ type UserTemplates struct {
 }
 
 // EncodePass encode the password and generate "hash" to store from users password.
-func (user *User) EncodePass() error {
-	hash, err := bcrypt.GenerateFromPassword(user.PasswordHash, bcrypt.DefaultCost)
+func (user *User) EncodePass(hasher *PasswordHasher) error {
+	hash, err := hasher.Hash(user.PasswordHash)
 	if err != nil {
 		return err
 	}
@@ -68,29 +106,26 @@ func (user *User) EncodePass() error {
 //
 // architecture: DB
 type DB interface {
+	// WithTx runs fn in a transaction, repository calls made with the context passed to fn take part in it.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 	// Create creates a user and writes to the database.
 	Create(ctx context.Context, user *User) error
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	Get(ctx context.Context, id uuid.UUID) (*User, error)
 	UpdateLastLogin(ctx context.Context, id uuid.UUID) error
+	// List returns a page of users matching the query, newest first unless query sorts them.
+	List(ctx context.Context, pagination *util.PaginationReq, query *util.ListQuery) ([]User, error)
+	// Count returns number of users matching the query.
+	Count(ctx context.Context, query *util.ListQuery) (uint64, error)
+	// UpdatePassword replaces password hash of the user.
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash []byte) error
+	// UpdateStatus replaces status of the user.
+	UpdateStatus(ctx context.Context, id uuid.UUID, status Status) error
 }
 
-// IsPasswordValid check the password for all conditions.
+// IsPasswordValid check the password for all conditions of default password policy.
 func IsPasswordValid(s string) bool {
-	var number, upper bool
-	letters := 0
-	for _, c := range s {
-		switch {
-		case unicode.IsNumber(c):
-			number = true
-		case unicode.IsUpper(c):
-			upper = true
-		case unicode.IsLetter(c) || c == ' ':
-			letters++
-		}
-	}
-
-	return len(s) >= 8 && letters >= 1 && number && upper
+	return DefaultPasswordPolicy.Validate(s, "", "") == nil
 }
 
 // Session contains user sign-in fields.
@@ -9,8 +9,8 @@ document.addEventListener("DOMContentLoaded", function () {
     }
 
     if (!user || tokenExpired) {
-        // Перенаправляем на страницу входа, только если мы не уже находимся на странице входа
-        if (!window.location.href.includes('login')) {
+        // Перенаправляем на страницу входа, только если мы не уже находимся на странице входа или регистрации
+        if (!window.location.href.includes('login') && !window.location.href.includes('register')) {
             window.location.href = 'login';
         }
     } else {
@@ -44,9 +44,15 @@ function sendData() {
         headers: {
             'Content-Type': 'application/json',
         },
+        credentials: 'same-origin',
         body: JSON.stringify(data)
     })
         .then(response => {
+            if (response.status === 422) {
+                return response.json().then(errorData => {
+                    throw new Error(showFieldErrors(errorData, {email: 'emailError', password: 'passwordError'}));
+                });
+            }
             if (!response.ok) {
                 throw new Error('Wrong credentials.');
             }
@@ -89,4 +95,31 @@ function validatePassword(password) {
 
 function showError(elementId, message) {
     document.getElementById(elementId).innerHTML = message;
-}
\ No newline at end of file
+}
+
+// getCookie returns cookie value by its name.
+function getCookie(name) {
+    var match = document.cookie.match(new RegExp('(?:^|; )' + name + '=([^;]*)'));
+    return match ? decodeURIComponent(match[1]) : '';
+}
+
+// csrfHeaders returns headers required by the server for state-changing requests.
+function csrfHeaders() {
+    return {'X-CSRF-Token': getCookie('csrf_token')};
+}
+
+// showFieldErrors shows messages of invalid fields of 422 error response next to form inputs.
+// elementIds maps field paths, e.g. "ingredients[2].unit", to ids of error elements,
+// messages of fields without an element are returned joined.
+function showFieldErrors(errorData, elementIds) {
+    var rest = [];
+    (errorData.fields || []).forEach(function (field) {
+        if (elementIds[field.field]) {
+            showError(elementIds[field.field], field.message);
+        } else {
+            rest.push(field.field + ' ' + field.message);
+        }
+    });
+
+    return rest.join('; ');
+}
@@ -50,6 +50,7 @@ function fetchRecipes() {
     const xhr = new XMLHttpRequest();
     xhr.open('POST', 'http://localhost:8088/recipes/list', true);
     xhr.setRequestHeader('Content-Type', 'application/json');
+    xhr.setRequestHeader('X-CSRF-Token', getCookie('csrf_token'));
 
     xhr.onreadystatechange = function () {
         if (xhr.readyState === XMLHttpRequest.DONE) {